@@ -0,0 +1,45 @@
+package bayesian
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DOTOptions configures the Graphviz export of a network.
+type DOTOptions struct {
+	// Cardinalities annotates every node with the number of values it can take.
+	Cardinalities bool
+}
+
+// ExportDOT writes the structure of the network (nodes and parent edges) in Graphviz DOT format.
+func (bn *Network) ExportDOT(w io.Writer, options *DOTOptions) error {
+	if options == nil {
+		options = &DOTOptions{}
+	}
+
+	if _, err := fmt.Fprintln(w, "digraph network {"); err != nil {
+		return err
+	}
+
+	for _, node := range bn.NodesInSamplingOrder {
+		label := node.Definition.Name
+		if options.Cardinalities {
+			label = fmt.Sprintf("%s\n(%d values)", label, len(node.Definition.PossibleValues))
+		}
+		if _, err := fmt.Fprintf(w, "\t%s [label=%s];\n", strconv.Quote(node.Definition.Name), strconv.Quote(label)); err != nil {
+			return err
+		}
+	}
+
+	for _, node := range bn.NodesInSamplingOrder {
+		for _, parentName := range node.Definition.ParentNames {
+			if _, err := fmt.Fprintf(w, "\t%s -> %s;\n", strconv.Quote(parentName), strconv.Quote(node.Definition.Name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
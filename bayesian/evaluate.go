@@ -0,0 +1,100 @@
+package bayesian
+
+import (
+	"math"
+	"math/rand"
+)
+
+// minProbability stands in for the probability of values the network does not know, so that a
+// single unseen value does not make the log-loss infinite.
+const minProbability = 1e-9
+
+// NodeEvaluation is the fit of one node to held-out records.
+type NodeEvaluation struct {
+	Node string `json:"node"`
+	// LogLoss is the mean negative natural logarithm of the probability of the recorded value given the
+	// recorded parent values. Lower is better.
+	LogLoss float64 `json:"logLoss"`
+	// Unseen is the number of records whose value has no probability given the parent values.
+	Unseen int `json:"unseen"`
+}
+
+// Evaluation is the fit of a network to held-out records.
+type Evaluation struct {
+	Records int              `json:"records"`
+	Nodes   []NodeEvaluation `json:"nodes"`
+	// LogLikelihood is the mean log probability of a whole record, the sum over the nodes.
+	LogLikelihood float64 `json:"logLikelihood"`
+	// Plausibility is the geometric mean of the node probabilities of a record, in (0, 1]. Unlike
+	// LogLikelihood, it can be compared between networks with different numbers of nodes.
+	Plausibility float64 `json:"plausibility"`
+}
+
+// Evaluate measures how likely the network finds records, e.g. records held out from its training
+// with SplitRecords. Record values that are not strings and nodes a record has no value for are
+// skipped.
+func (bn *Network) Evaluate(records RecordList) *Evaluation {
+	evaluation := &Evaluation{Records: len(records), Nodes: make([]NodeEvaluation, 0, len(bn.NodesInSamplingOrder))}
+	if len(records) == 0 {
+		return evaluation
+	}
+
+	logLosses := make([]float64, len(bn.NodesInSamplingOrder))
+	counts := make([]int, len(bn.NodesInSamplingOrder))
+	unseen := make([]int, len(bn.NodesInSamplingOrder))
+	var totalLogLikelihood float64
+	var totalCounted int
+	values := make(map[string]string)
+	for _, record := range records {
+		clear(values)
+		for name, value := range record {
+			if s, ok := value.(string); ok {
+				values[name] = s
+			}
+		}
+		for i, node := range bn.NodesInSamplingOrder {
+			value, ok := values[node.Definition.Name]
+			if !ok {
+				continue
+			}
+			probabilities := node.getProbabilitiesGivenKnownValues(values)
+			probability := probabilities[value]
+			releaseProbabilities(probabilities)
+			if probability < minProbability {
+				probability = minProbability
+				unseen[i]++
+			}
+			logLosses[i] -= math.Log(probability)
+			counts[i]++
+			totalLogLikelihood += math.Log(probability)
+			totalCounted++
+		}
+	}
+
+	for i, node := range bn.NodesInSamplingOrder {
+		nodeEvaluation := NodeEvaluation{Node: node.Definition.Name, Unseen: unseen[i]}
+		if counts[i] > 0 {
+			nodeEvaluation.LogLoss = logLosses[i] / float64(counts[i])
+		}
+		evaluation.Nodes = append(evaluation.Nodes, nodeEvaluation)
+	}
+	evaluation.LogLikelihood = totalLogLikelihood / float64(len(records))
+	if totalCounted > 0 {
+		evaluation.Plausibility = math.Exp(totalLogLikelihood / float64(totalCounted))
+	}
+	return evaluation
+}
+
+// SplitRecords holds out a fraction of records for evaluation, chosen at random from seed so that
+// networks trained on the same dataset are evaluated on the same records.
+func SplitRecords(records RecordList, holdout float64, seed int64) (training RecordList, heldOut RecordList) {
+	random := rand.New(rand.NewSource(seed))
+	for _, record := range records {
+		if random.Float64() < holdout {
+			heldOut = append(heldOut, record)
+		} else {
+			training = append(training, record)
+		}
+	}
+	return training, heldOut
+}
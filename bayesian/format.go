@@ -0,0 +1,69 @@
+package bayesian
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DefinitionFormatVersion is the version of the network definition format this package reads. It is
+// stored in the "formatVersion" field of a definition; definitions without it are version 1, the
+// format of the original fingerprint-suite definitions.
+const DefinitionFormatVersion = 2
+
+// definitionMigrations migrate a decoded definition of a version to the next version. Versions that
+// are read unchanged by the next version have a nil migration, so loading them costs no extra decoding.
+var definitionMigrations = map[int]func(definition map[string]any) error{
+	// Version 2 only adds formatVersion.
+	1: nil,
+}
+
+// DefinitionVersion returns the format version of the JSON network definition content.
+func DefinitionVersion(content []byte) (int, error) {
+	var header struct {
+		FormatVersion *int `json:"formatVersion"`
+	}
+	if err := json.Unmarshal(content, &header); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal network JSON: %w", err)
+	}
+	if header.FormatVersion == nil {
+		return 1, nil
+	}
+	if *header.FormatVersion < 1 {
+		return 0, fmt.Errorf("invalid network definition format version %d", *header.FormatVersion)
+	}
+	return *header.FormatVersion, nil
+}
+
+// migrateDefinition returns the JSON network definition content in DefinitionFormatVersion. Definitions
+// of newer versions are an error rather than read with fields this package does not know about.
+func migrateDefinition(content []byte) ([]byte, error) {
+	version, err := DefinitionVersion(content)
+	if err != nil {
+		return nil, err
+	}
+	if version > DefinitionFormatVersion {
+		return nil, fmt.Errorf("the network definition has format version %d, newer than the supported version %d; upgrade fingerprint-go",
+			version, DefinitionFormatVersion)
+	}
+
+	var definition map[string]any
+	for ; version < DefinitionFormatVersion; version++ {
+		migrate := definitionMigrations[version]
+		if migrate == nil {
+			continue
+		}
+		if definition == nil {
+			if err := json.Unmarshal(content, &definition); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal network JSON: %w", err)
+			}
+		}
+		if err := migrate(definition); err != nil {
+			return nil, fmt.Errorf("failed to migrate the network definition from format version %d: %w", version, err)
+		}
+	}
+	if definition == nil {
+		return content, nil
+	}
+	definition["formatVersion"] = DefinitionFormatVersion
+	return json.Marshal(definition)
+}
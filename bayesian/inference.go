@@ -0,0 +1,166 @@
+package bayesian
+
+import (
+	"maps"
+	"math/rand"
+	"time"
+)
+
+// SamplingMethod selects how a sample consistent with value restrictions is generated.
+type SamplingMethod int
+
+const (
+	// Backtracking samples nodes in order and backtracks on dead ends. It always finds a consistent
+	// sample when one exists, but under tight restrictions it may retry many times and it over-represents
+	// values that happen to be tried first.
+	Backtracking SamplingMethod = iota
+	// LikelihoodWeighting samples restricted nodes only from their allowed values, weights every sample by
+	// the probability of the restrictions given the sample, and picks one of LikelihoodWeightingSamples
+	// samples proportionally to its weight. This approximates the exact posterior distribution given the
+	// restrictions.
+	LikelihoodWeighting
+)
+
+// SamplingOptions tunes how samples consistent with value restrictions are drawn.
+type SamplingOptions struct {
+	Method SamplingMethod
+	// Temperature rescales every conditional distribution to p^(1/Temperature) before sampling. Values
+	// above 1 flatten the distributions towards uniform and favour diversity, values below 1 sharpen them
+	// towards the most common values. 0 and 1 leave the distributions unchanged.
+	Temperature float64
+	// BannedValues lists, per node name, values that are never sampled.
+	BannedValues map[string][]string
+	// Consistent, when set, is called after a value is chosen for node with the partial sample so far.
+	// Returning false rejects the value and another one is tried, which prunes implausible combinations
+	// while sampling instead of rejecting complete samples afterwards.
+	Consistent func(sample map[string]string, node string) bool
+	// Trace, when set, is filled with the choices made by GenerateConsistentSample, see SamplingTrace.
+	Trace *SamplingTrace
+	// Deadline, when set, makes GenerateConsistentSample give up once it passes. Backtracking then
+	// returns an empty sample, LikelihoodWeighting the sample chosen so far, if any.
+	Deadline time.Time
+}
+
+// Expired reports whether the deadline of o has passed.
+func (o *SamplingOptions) Expired() bool {
+	return o != nil && !o.Deadline.IsZero() && !time.Now().Before(o.Deadline)
+}
+
+func (o *SamplingOptions) temperature() float64 {
+	if o == nil {
+		return 1
+	}
+	return o.Temperature
+}
+
+func (o *SamplingOptions) consistent(sample map[string]string, node string) bool {
+	if o == nil || o.Consistent == nil {
+		return true
+	}
+	return o.Consistent(sample, node)
+}
+
+func (o *SamplingOptions) bannedValues(node string) []string {
+	if o == nil {
+		return nil
+	}
+	return o.BannedValues[node]
+}
+
+// LikelihoodWeightingSamples is the number of weighted samples drawn per LikelihoodWeighting call.
+var LikelihoodWeightingSamples = 200
+
+// GenerateConsistentSample generates a sample consistent with valuePossibilities according to options.
+// LikelihoodWeighting falls back to Backtracking when none of its samples satisfy the restrictions.
+func (bn *Network) GenerateConsistentSample(valuePossibilities map[string][]string, options *SamplingOptions) map[string]string {
+	if options != nil && options.Method == LikelihoodWeighting {
+		if options.Trace != nil {
+			bn.startTrace(options.Trace, LikelihoodWeighting, valuePossibilities)
+		}
+		if sample := bn.generateWeightedSample(valuePossibilities, options); len(sample) > 0 {
+			if options.Trace != nil {
+				options.Trace.Sample = maps.Clone(sample)
+			}
+			return sample
+		}
+	}
+
+	if options != nil && options.Trace != nil {
+		bn.startTrace(options.Trace, Backtracking, valuePossibilities)
+	}
+	sample := bn.recursivelyGenerateConsistentSampleWhenPossible(make(map[string]string), valuePossibilities, 0, options)
+	if options != nil && options.Trace != nil {
+		options.Trace.Sample = maps.Clone(sample)
+	}
+	return sample
+}
+
+func (bn *Network) generateWeightedSample(valuePossibilities map[string][]string, options *SamplingOptions) map[string]string {
+	var chosen map[string]string
+	totalWeight := 0.0
+
+	var steps []TraceStep
+	for i := 0; i < LikelihoodWeightingSamples && !options.Expired(); i++ {
+		sample, weight, sampleSteps := bn.likelihoodWeightedSample(valuePossibilities, options)
+		if weight <= 0 {
+			continue
+		}
+		// Weighted reservoir sampling keeps one sample with probability proportional to its weight.
+		totalWeight += weight
+		if rand.Float64()*totalWeight < weight {
+			chosen = sample
+			steps = sampleSteps
+		}
+	}
+	if options.Trace != nil {
+		options.Trace.Steps = steps
+	}
+
+	return chosen
+}
+
+func (bn *Network) likelihoodWeightedSample(valuePossibilities map[string][]string, options *SamplingOptions) (map[string]string, float64, []TraceStep) {
+	sample := make(map[string]string, len(bn.NodesInSamplingOrder))
+	weight := 1.0
+	var steps []TraceStep
+	var validValues []string
+
+	for _, node := range bn.NodesInSamplingOrder {
+		probabilities := applyTemperature(node.getProbabilitiesGivenKnownValues(sample), options.temperature())
+		allowed := valuePossibilities[node.Definition.Name]
+		if len(allowed) == 0 {
+			allowed = node.Definition.PossibleValues
+		}
+
+		banned := options.bannedValues(node.Definition.Name)
+		// The candidate slice is reused across nodes, the trace keeps copies.
+		validValues = validValues[:0]
+		mass := 0.0
+		for _, value := range allowed {
+			p, ok := probabilities[value]
+			if !ok || p <= 0 || slicesContains(banned, value) {
+				continue
+			}
+			sample[node.Definition.Name] = value
+			if !options.consistent(sample, node.Definition.Name) {
+				continue
+			}
+			validValues = append(validValues, value)
+			mass += p
+		}
+		if mass == 0 {
+			releaseProbabilities(probabilities)
+			return nil, 0, nil
+		}
+
+		// Unrestricted nodes have a mass of 1, so only restrictions, bans and rejected values lower the weight.
+		weight *= mass
+		sample[node.Definition.Name] = node.sampleRandomValueFromPossibilities(validValues, mass, probabilities)
+		releaseProbabilities(probabilities)
+		if options.Trace != nil {
+			steps = append(steps, traceStep(node, sample, validValues, banned, sample[node.Definition.Name], false))
+		}
+	}
+
+	return sample, weight, steps
+}
@@ -2,9 +2,14 @@ package bayesian
 
 import (
 	"archive/zip"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
 )
 
 // Network is an implementation of a bayesian network capable of randomly sampling from the distribution
@@ -16,41 +21,78 @@ type Network struct {
 
 // NewNetwork creates a new BayesianNetwork from a zip file definition.
 func NewNetwork(path string) *Network {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Error opening zip file %s: %v\n", path, err)
+		return &Network{NodesByName: make(map[string]*Node)}
+	}
+	return newNetworkFromZip(data, path)
+}
+
+// NewNetworkFromFS creates a new BayesianNetwork from the zip file definition name in fsys, e.g. an
+// embed.FS, which also works where there is no file system such as WebAssembly.
+func NewNetworkFromFS(fsys fs.FS, name string) *Network {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		fmt.Printf("Error opening zip file %s: %v\n", name, err)
+		return &Network{NodesByName: make(map[string]*Node)}
+	}
+	return newNetworkFromZip(data, name)
+}
+
+// ReadNetworkFS reads the zip file definition name in fsys like NewNetworkFromFS, but returns why it
+// can not be read instead of printing it, e.g. that it was written in a newer format version.
+func ReadNetworkFS(fsys fs.FS, name string) (*Network, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return &Network{NodesByName: make(map[string]*Node)}, err
+	}
+	return readNetworkZip(data)
+}
+
+func newNetworkFromZip(data []byte, path string) *Network {
+	network, err := readNetworkZip(data)
+	if err != nil {
+		fmt.Printf("Error reading network %s: %v\n", path, err)
+	}
+	return network
+}
+
+func readNetworkZip(data []byte) (*Network, error) {
 	network := &Network{
 		NodesByName: make(map[string]*Node),
 	}
 
-	r, err := zip.OpenReader(path)
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
-		fmt.Printf("Error opening zip file %s: %v\n", path, err)
-		return network
+		return network, fmt.Errorf("failed to open zip file: %w", err)
 	}
-	defer r.Close()
 
 	if len(r.File) == 0 {
-		return network
+		return network, nil
 	}
 
 	f, err := r.File[0].Open()
 	if err != nil {
-		fmt.Printf("Error opening file in zip: %v\n", err)
-		return network
+		return network, fmt.Errorf("failed to open file in zip: %w", err)
 	}
 	defer f.Close()
 
 	content, err := io.ReadAll(f)
 	if err != nil {
-		fmt.Printf("Error reading file in zip: %v\n", err)
-		return network
+		return network, fmt.Errorf("failed to read file in zip: %w", err)
+	}
+
+	definition, err := migrateDefinition(content)
+	if err != nil {
+		return network, err
 	}
 
 	var networkDef struct {
 		Nodes []NodeDefinition `json:"nodes"`
 	}
-	err = json.Unmarshal(content, &networkDef)
-	if err != nil {
-		fmt.Printf("Error unmarshaling network JSON: %v\n", err)
-		return network
+	if err := json.Unmarshal(definition, &networkDef); err != nil {
+		return network, fmt.Errorf("failed to unmarshal network JSON: %w", err)
 	}
 
 	for _, nDef := range networkDef.Nodes {
@@ -59,12 +101,60 @@ func NewNetwork(path string) *Network {
 		network.NodesByName[nDef.Name] = node
 	}
 
-	return network
+	ordered, err := topologicalOrder(network.NodesInSamplingOrder, network.NodesByName)
+	if err != nil {
+		return network, fmt.Errorf("failed to order network: %w", err)
+	}
+	network.NodesInSamplingOrder = ordered
+
+	return network, nil
+}
+
+// topologicalOrder orders nodes so that every node comes after its parents. Among nodes whose parents
+// are all placed, the original order is kept, so definitions that are already ordered are unchanged.
+// Parents that are not defined in the network are ignored here and reported by Validate.
+func topologicalOrder(nodes []*Node, nodesByName map[string]*Node) ([]*Node, error) {
+	placed := make(map[string]bool, len(nodes))
+	ordered := make([]*Node, 0, len(nodes))
+	remaining := slices.Clone(nodes)
+
+	for len(remaining) > 0 {
+		progress := false
+		for i := 0; i < len(remaining); i++ {
+			node := remaining[i]
+			ready := true
+			for _, parentName := range node.Definition.ParentNames {
+				if _, defined := nodesByName[parentName]; defined && !placed[parentName] {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+
+			placed[node.Definition.Name] = true
+			ordered = append(ordered, node)
+			remaining = slices.Delete(remaining, i, i+1)
+			progress = true
+			break
+		}
+
+		if !progress {
+			names := make([]string, 0, len(remaining))
+			for _, node := range remaining {
+				names = append(names, node.Definition.Name)
+			}
+			return nil, fmt.Errorf("dependency cycle between nodes %v", names)
+		}
+	}
+
+	return ordered, nil
 }
 
 // GenerateSample randomly samples from the distribution represented by the bayesian network.
 func (bn *Network) GenerateSample(inputValues map[string]string) map[string]string {
-	sample := make(map[string]string)
+	sample := make(map[string]string, len(bn.NodesInSamplingOrder)+len(inputValues))
 	for k, v := range inputValues {
 		sample[k] = v
 	}
@@ -77,35 +167,73 @@ func (bn *Network) GenerateSample(inputValues map[string]string) map[string]stri
 	return sample
 }
 
+// Enumerate calls visit with every complete sample of positive probability and its probability, in no
+// particular order. The number of samples grows with the product of the numbers of values of the
+// nodes, so it is meant for small networks such as the input network of the header generator. sample
+// is reused between calls.
+func (bn *Network) Enumerate(visit func(sample map[string]string, probability float64)) {
+	sample := make(map[string]string, len(bn.NodesInSamplingOrder))
+	var enumerate func(depth int, probability float64)
+	enumerate = func(depth int, probability float64) {
+		if depth == len(bn.NodesInSamplingOrder) {
+			visit(sample, probability)
+			return
+		}
+		node := bn.NodesInSamplingOrder[depth]
+		probabilities := node.getProbabilitiesGivenKnownValues(sample)
+		values := maps.Clone(probabilities)
+		releaseProbabilities(probabilities)
+		for value, p := range values {
+			if p <= 0 {
+				continue
+			}
+			sample[node.Definition.Name] = value
+			enumerate(depth+1, probability*p)
+		}
+		delete(sample, node.Definition.Name)
+	}
+	enumerate(0, 1)
+}
+
 // GenerateConsistentSampleWhenPossible randomly samples values from the distribution represented by the bayesian network,
 // making sure the sample is consistent with the provided restrictions on value possibilities.
 func (bn *Network) GenerateConsistentSampleWhenPossible(valuePossibilities map[string][]string) map[string]string {
-	return bn.recursivelyGenerateConsistentSampleWhenPossible(make(map[string]string), valuePossibilities, 0)
+	return bn.recursivelyGenerateConsistentSampleWhenPossible(make(map[string]string), valuePossibilities, 0, nil)
 }
 
 func (bn *Network) recursivelyGenerateConsistentSampleWhenPossible(
 	sampleSoFar map[string]string,
 	valuePossibilities map[string][]string,
 	depth int,
+	options *SamplingOptions,
 ) map[string]string {
 	if depth >= len(bn.NodesInSamplingOrder) {
 		return sampleSoFar
 	}
 
-	bannedValues := make([]string, 0)
 	node := bn.NodesInSamplingOrder[depth]
+	bannedValues := slices.Clone(options.bannedValues(node.Definition.Name))
 	var sampleValue string
 
-	for {
-		sampleValue = node.SampleAccordingToRestrictions(sampleSoFar, valuePossibilities[node.Definition.Name], bannedValues)
+	for !options.Expired() {
+		candidates, totalProbability, probabilities := node.restrictedValues(sampleSoFar, valuePossibilities[node.Definition.Name], bannedValues, options.temperature())
+		sampleValue = node.sampleRandomValueFromPossibilities(candidates, totalProbability, probabilities)
+		releaseProbabilities(probabilities)
 		if sampleValue == "" {
+			options.trace(node, sampleSoFar, candidates, bannedValues, "", false)
 			break
 		}
 
 		sampleSoFar[node.Definition.Name] = sampleValue
+		if !options.consistent(sampleSoFar, node.Definition.Name) {
+			options.trace(node, sampleSoFar, candidates, bannedValues, sampleValue, true)
+			bannedValues = append(bannedValues, sampleValue)
+			continue
+		}
+		options.trace(node, sampleSoFar, candidates, bannedValues, sampleValue, false)
 
 		if depth+1 < len(bn.NodesInSamplingOrder) {
-			sample := bn.recursivelyGenerateConsistentSampleWhenPossible(sampleSoFar, valuePossibilities, depth+1)
+			sample := bn.recursivelyGenerateConsistentSampleWhenPossible(sampleSoFar, valuePossibilities, depth+1, options)
 			if len(sample) > 0 {
 				return sample
 			}
@@ -116,5 +244,6 @@ func (bn *Network) recursivelyGenerateConsistentSampleWhenPossible(
 		bannedValues = append(bannedValues, sampleValue)
 	}
 
+	delete(sampleSoFar, node.Definition.Name)
 	return make(map[string]string)
 }
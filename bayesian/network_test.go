@@ -0,0 +1,89 @@
+package bayesian
+
+import (
+	"fmt"
+	"testing"
+)
+
+// benchmarkNetwork returns a network shaped like the fingerprint network: a few input nodes and
+// attribute nodes with many values conditioned on them.
+func benchmarkNetwork(b *testing.B) *Network {
+	b.Helper()
+	values := func(prefix string, n int) []string {
+		result := make([]string, n)
+		for i := range result {
+			result[i] = fmt.Sprintf("%s%d", prefix, i)
+		}
+		return result
+	}
+	distribution := func(values []string) map[string]any {
+		probabilities := make(map[string]any, len(values))
+		for _, value := range values {
+			probabilities[value] = 1 / float64(len(values))
+		}
+		return probabilities
+	}
+	// conditional returns the probabilities of values given every combination of values of the parents.
+	var conditional func(parents [][]string, values []string) any
+	conditional = func(parents [][]string, values []string) any {
+		if len(parents) == 0 {
+			return distribution(values)
+		}
+		deeper := make(map[string]any, len(parents[0]))
+		for _, parentValue := range parents[0] {
+			deeper[parentValue] = conditional(parents[1:], values)
+		}
+		return map[string]any{"deeper": deeper, "skip": distribution(values)}
+	}
+
+	browsers, operatingSystems, devices := values("browser", 8), values("os", 5), values("device", 2)
+	definitions := []NodeDefinition{
+		{Name: "browser", PossibleValues: browsers, ConditionalProbabilities: distribution(browsers)},
+		{Name: "os", ParentNames: []string{"browser"}, PossibleValues: operatingSystems,
+			ConditionalProbabilities: conditional([][]string{browsers}, operatingSystems)},
+		{Name: "device", ParentNames: []string{"os"}, PossibleValues: devices,
+			ConditionalProbabilities: conditional([][]string{operatingSystems}, devices)},
+	}
+	for i := range 20 {
+		attributeValues := values(fmt.Sprintf("attribute%d-", i), 40)
+		definitions = append(definitions, NodeDefinition{
+			Name:                     fmt.Sprintf("attribute%d", i),
+			ParentNames:              []string{"browser", "os", "device"},
+			PossibleValues:           attributeValues,
+			ConditionalProbabilities: conditional([][]string{browsers, operatingSystems, devices}, attributeValues),
+		})
+	}
+
+	network := &Network{NodesByName: make(map[string]*Node)}
+	for _, definition := range definitions {
+		node := NewNode(definition)
+		network.NodesInSamplingOrder = append(network.NodesInSamplingOrder, node)
+		network.NodesByName[definition.Name] = node
+	}
+	return network
+}
+
+func BenchmarkGenerateSample(b *testing.B) {
+	network := benchmarkNetwork(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		network.GenerateSample(nil)
+	}
+}
+
+func BenchmarkGenerateConsistentSampleWhenPossible(b *testing.B) {
+	network := benchmarkNetwork(b)
+	possibilities := map[string][]string{
+		"browser":    {"browser0", "browser1"},
+		"os":         {"os2"},
+		"attribute0": {"attribute0-1", "attribute0-2", "attribute0-3"},
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if sample := network.GenerateConsistentSampleWhenPossible(possibilities); len(sample) == 0 {
+			b.Fatal("no consistent sample")
+		}
+	}
+}
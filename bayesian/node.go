@@ -1,25 +1,41 @@
 package bayesian
 
 import (
+	"math"
 	"math/rand"
+	"sync"
+
+	"fingerprint-go/internal/constants"
 )
 
 // RecordList represents a list of records for Bayesian logic
 type RecordList []map[string]any
 
+// RecordWeightKey holds the weight of a record in the frequencies, 1 when it is not set.
+const RecordWeightKey = constants.RecordWeightKey
+
+func recordWeight(record map[string]any) float64 {
+	if weight, ok := record[RecordWeightKey].(float64); ok {
+		return weight
+	}
+	return 1
+}
+
 func getRelativeFrequencies(data RecordList, attributeName string) map[string]float64 {
-	frequencies := make(map[string]int)
-	totalCount := len(data)
+	frequencies := make(map[string]float64)
+	totalWeight := 0.0
 
 	for _, record := range data {
+		weight := recordWeight(record)
+		totalWeight += weight
 		if val, ok := record[attributeName].(string); ok {
-			frequencies[val]++
+			frequencies[val] += weight
 		}
 	}
 
 	result := make(map[string]float64)
 	for key, value := range frequencies {
-		result[key] = float64(value) / float64(totalCount)
+		result[key] = value / totalWeight
 	}
 	return result
 }
@@ -42,6 +58,7 @@ func NewNode(def NodeDefinition) *Node {
 }
 
 func (n *Node) getProbabilitiesGivenKnownValues(parentValues map[string]string) map[string]float64 {
+	defer n.annotatePanic()
 	probabilities := n.Definition.ConditionalProbabilities
 
 	for _, parentName := range n.Definition.ParentNames {
@@ -67,7 +84,7 @@ func (n *Node) getProbabilitiesGivenKnownValues(parentValues map[string]string)
 	}
 
 	// We expect the final probabilities to be map[string]float64 or similar
-	result := make(map[string]float64)
+	result := probabilityMaps.Get().(map[string]float64)
 	if m, ok := probabilities.(map[string]any); ok {
 		for k, v := range m {
 			if f, ok := v.(float64); ok {
@@ -79,6 +96,7 @@ func (n *Node) getProbabilitiesGivenKnownValues(parentValues map[string]string)
 }
 
 func (n *Node) sampleRandomValueFromPossibilities(possibleValues []string, totalProbability float64, probabilities map[string]float64) string {
+	defer n.annotatePanic()
 	if len(possibleValues) == 0 {
 		return ""
 	}
@@ -102,7 +120,8 @@ func (n *Node) Sample(parentValues map[string]string) string {
 		parentValues = make(map[string]string)
 	}
 	probabilities := n.getProbabilitiesGivenKnownValues(parentValues)
-	var possibleValues []string
+	defer releaseProbabilities(probabilities)
+	possibleValues := make([]string, 0, len(probabilities))
 	for k := range probabilities {
 		possibleValues = append(possibleValues, k)
 	}
@@ -111,32 +130,67 @@ func (n *Node) Sample(parentValues map[string]string) string {
 }
 
 func (n *Node) SampleAccordingToRestrictions(parentValues map[string]string, valuePossibilities []string, bannedValues []string) string {
-	probabilities := n.getProbabilitiesGivenKnownValues(parentValues)
-	totalProbability := 0.0
-	var validValues []string
+	return n.sampleAccordingToRestrictions(parentValues, valuePossibilities, bannedValues, 1)
+}
 
-	var valuesInDistribution []string
-	for k := range probabilities {
-		valuesInDistribution = append(valuesInDistribution, k)
-	}
+func (n *Node) sampleAccordingToRestrictions(parentValues map[string]string, valuePossibilities []string, bannedValues []string, temperature float64) string {
+	validValues, totalProbability, probabilities := n.restrictedValues(parentValues, valuePossibilities, bannedValues, temperature)
+	defer releaseProbabilities(probabilities)
+	return n.sampleRandomValueFromPossibilities(validValues, totalProbability, probabilities)
+}
+
+// restrictedValues returns the values of valuePossibilities (every value when empty) that are not
+// banned and possible given parentValues, with their total probability and the distribution. The
+// distribution is pooled; release it with releaseProbabilities once the value is sampled.
+func (n *Node) restrictedValues(parentValues map[string]string, valuePossibilities []string, bannedValues []string, temperature float64) ([]string, float64, map[string]float64) {
+	probabilities := applyTemperature(n.getProbabilitiesGivenKnownValues(parentValues), temperature)
+	totalProbability := 0.0
 
 	possibleValues := valuePossibilities
 	if len(possibleValues) == 0 {
-		possibleValues = valuesInDistribution
+		possibleValues = make([]string, 0, len(probabilities))
+		for k := range probabilities {
+			possibleValues = append(possibleValues, k)
+		}
 	}
 
+	validValues := make([]string, 0, len(possibleValues))
 	for _, value := range possibleValues {
-		if !slicesContains(bannedValues, value) && slicesContains(valuesInDistribution, value) {
+		p, inDistribution := probabilities[value]
+		if inDistribution && !slicesContains(bannedValues, value) {
 			validValues = append(validValues, value)
-			totalProbability += probabilities[value]
+			totalProbability += p
 		}
 	}
 
-	if len(validValues) == 0 {
-		return ""
+	return validValues, totalProbability, probabilities
+}
+
+// probabilityMaps recycles the conditional distributions computed for every node of every sample.
+var probabilityMaps = sync.Pool{New: func() any { return make(map[string]float64) }}
+
+func releaseProbabilities(probabilities map[string]float64) {
+	clear(probabilities)
+	probabilityMaps.Put(probabilities)
+}
+
+// applyTemperature rescales probabilities to p^(1/temperature) and renormalizes them in place.
+func applyTemperature(probabilities map[string]float64, temperature float64) map[string]float64 {
+	if temperature <= 0 || temperature == 1 {
+		return probabilities
 	}
 
-	return n.sampleRandomValueFromPossibilities(validValues, totalProbability, probabilities)
+	total := 0.0
+	for _, p := range probabilities {
+		total += math.Pow(p, 1/temperature)
+	}
+	if total == 0 {
+		return probabilities
+	}
+	for value, p := range probabilities {
+		probabilities[value] = math.Pow(p, 1/temperature) / total
+	}
+	return probabilities
 }
 
 func slicesContains(slice []string, val string) bool {
@@ -0,0 +1,51 @@
+package bayesian
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// SamplingPanic is the error a panic during sampling is converted to, e.g. a panic on a malformed
+// conditional probability table. Node is the node being sampled, if known.
+type SamplingPanic struct {
+	Node  string
+	Value any
+	// Stack is the stack trace of the panic.
+	Stack []byte
+}
+
+func (e *SamplingPanic) Error() string {
+	if e.Node == "" {
+		return fmt.Sprintf("sampling panicked: %v", e.Value)
+	}
+	return fmt.Sprintf("sampling node %q panicked, its definition may be malformed: %v", e.Node, e.Value)
+}
+
+// annotatePanic is deferred by the functions reading the definition of n, so that a panic reaching
+// RecoverSampling names the offending node.
+func (n *Node) annotatePanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if _, ok := r.(*SamplingPanic); ok {
+		panic(r)
+	}
+	panic(&SamplingPanic{Node: n.Definition.Name, Value: r, Stack: debug.Stack()})
+}
+
+// RecoverSampling converts a panic into a *SamplingPanic stored in *err. Entry points that sample
+// networks defer it, so that malformed definitions fail the call instead of crashing the process:
+//
+//	defer bayesian.RecoverSampling(&err)
+func RecoverSampling(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if samplingPanic, ok := r.(*SamplingPanic); ok {
+		*err = samplingPanic
+		return
+	}
+	*err = &SamplingPanic{Value: r, Stack: debug.Stack()}
+}
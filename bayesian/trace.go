@@ -0,0 +1,75 @@
+package bayesian
+
+import (
+	"maps"
+	"slices"
+)
+
+// TraceStep is a value choice for a node made while sampling.
+type TraceStep struct {
+	Node string `json:"node"`
+	// Evidence holds the values of the node's parents the choice was conditioned on.
+	Evidence map[string]string `json:"evidence,omitempty"`
+	// Candidates are the values that could be chosen: allowed by the restrictions, not banned and
+	// possible given the evidence.
+	Candidates []string `json:"candidates"`
+	// Banned are the values excluded by the sampling options and by earlier rejections and backtracking.
+	Banned []string `json:"banned,omitempty"`
+	// Chosen is the value picked, "" when no candidate was left and sampling backtracked.
+	Chosen string `json:"chosen"`
+	// Rejected is set when the Consistent rule of the sampling options rejected Chosen.
+	Rejected bool `json:"rejected,omitempty"`
+}
+
+// SamplingTrace records the choices made while generating a sample. Set it in SamplingOptions.Trace to
+// find out why restricted sampling fails or produces unexpected combinations.
+type SamplingTrace struct {
+	Method SamplingMethod `json:"method"`
+	// NodeOrder is the order nodes are sampled in.
+	NodeOrder []string `json:"nodeOrder"`
+	// Restrictions are the allowed values per node the sample had to be consistent with.
+	Restrictions map[string][]string `json:"restrictions,omitempty"`
+	// Steps are the choices in the order they were made. With backtracking, a node appears once per
+	// attempt; with likelihood weighting, only the choices of the selected sample are kept.
+	Steps []TraceStep `json:"steps"`
+	// Sample is the generated sample, empty when sampling failed.
+	Sample map[string]string `json:"sample"`
+}
+
+func (bn *Network) startTrace(trace *SamplingTrace, method SamplingMethod, valuePossibilities map[string][]string) {
+	trace.Method = method
+	trace.NodeOrder = trace.NodeOrder[:0]
+	for _, node := range bn.NodesInSamplingOrder {
+		trace.NodeOrder = append(trace.NodeOrder, node.Definition.Name)
+	}
+	trace.Restrictions = maps.Clone(valuePossibilities)
+	trace.Steps = nil
+	trace.Sample = nil
+}
+
+func (o *SamplingOptions) trace(node *Node, sample map[string]string, candidates []string, banned []string, chosen string, rejected bool) {
+	if o == nil || o.Trace == nil {
+		return
+	}
+	o.Trace.Steps = append(o.Trace.Steps, traceStep(node, sample, candidates, banned, chosen, rejected))
+}
+
+func traceStep(node *Node, sample map[string]string, candidates []string, banned []string, chosen string, rejected bool) TraceStep {
+	var evidence map[string]string
+	for _, parentName := range node.Definition.ParentNames {
+		if value, ok := sample[parentName]; ok {
+			if evidence == nil {
+				evidence = make(map[string]string, len(node.Definition.ParentNames))
+			}
+			evidence[parentName] = value
+		}
+	}
+	return TraceStep{
+		Node:       node.Definition.Name,
+		Evidence:   evidence,
+		Candidates: slices.Clone(candidates),
+		Banned:     slices.Clone(banned),
+		Chosen:     chosen,
+		Rejected:   rejected,
+	}
+}
@@ -112,8 +112,16 @@ func filterByLastLevelKeys(tree any, validKeys []string) [][]string {
 	return foundPaths
 }
 
+// parentValuePaths returns the values of the parents of n under which one of values is possible, per
+// parent.
+func (n *Node) parentValuePaths(values []string) [][]string {
+	defer n.annotatePanic()
+	return filterByLastLevelKeys(Undeeper(n.Definition.ConditionalProbabilities), values)
+}
+
 // GetConstraintClosure returns an extended set of constraints induced by the original constraints and network structure.
-func GetConstraintClosure(network *Network, possibleValues map[string][]string) (map[string][]string, error) {
+func GetConstraintClosure(network *Network, possibleValues map[string][]string) (_ map[string][]string, err error) {
+	defer RecoverSampling(&err)
 	sets := make([]map[string][]string, 0)
 	foundMatchingValues := false
 
@@ -127,8 +135,7 @@ func GetConstraintClosure(network *Network, possibleValues map[string][]string)
 			continue // skip if node not found
 		}
 
-		tree := Undeeper(node.Definition.ConditionalProbabilities)
-		zippedValues := filterByLastLevelKeys(tree, values)
+		zippedValues := node.parentValuePaths(values)
 
 		if len(zippedValues) > 0 {
 			foundMatchingValues = true
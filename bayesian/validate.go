@@ -0,0 +1,169 @@
+package bayesian
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Diagnostic describes a single problem found in a network definition.
+type Diagnostic struct {
+	Node    string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("node %q: %s", d.Node, d.Message)
+}
+
+// ValidationError is returned by Validate and lists every problem found in the network.
+type ValidationError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Diagnostics))
+	for _, d := range e.Diagnostics {
+		messages = append(messages, d.String())
+	}
+	return fmt.Sprintf("invalid network definition (%d problems): %s", len(e.Diagnostics), strings.Join(messages, "; "))
+}
+
+// Validate checks that the network can be sampled: node names are unique, every parent exists and is
+// sampled before its children, the graph is acyclic, and the conditional probability tables are non-empty
+// and only reference possible values of the node and its parents.
+func (bn *Network) Validate() error {
+	var diagnostics []Diagnostic
+	report := func(node string, format string, args ...any) {
+		diagnostics = append(diagnostics, Diagnostic{Node: node, Message: fmt.Sprintf(format, args...)})
+	}
+
+	position := make(map[string]int, len(bn.NodesInSamplingOrder))
+	for i, node := range bn.NodesInSamplingOrder {
+		if _, seen := position[node.Definition.Name]; seen {
+			report(node.Definition.Name, "defined more than once")
+			continue
+		}
+		position[node.Definition.Name] = i
+	}
+
+	for i, node := range bn.NodesInSamplingOrder {
+		name := node.Definition.Name
+		for _, parentName := range node.Definition.ParentNames {
+			parentPosition, ok := position[parentName]
+			if !ok {
+				report(name, "parent %q is not defined in the network", parentName)
+			} else if parentPosition >= i {
+				report(name, "parent %q is sampled after the node; reorder the nodes so parents come first", parentName)
+			}
+		}
+
+		if len(node.Definition.PossibleValues) == 0 {
+			report(name, "has no possible values")
+		}
+
+		cpt, ok := node.Definition.ConditionalProbabilities.(map[string]any)
+		if !ok || len(cpt) == 0 {
+			report(name, "has an empty conditional probability table")
+			continue
+		}
+		bn.validateProbabilities(node, cpt, 0, report)
+	}
+
+	if cycle := bn.findCycle(); cycle != nil {
+		report(cycle[0], "is part of a dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	if len(diagnostics) == 0 {
+		return nil
+	}
+	return &ValidationError{Diagnostics: diagnostics}
+}
+
+func (bn *Network) validateProbabilities(node *Node, table map[string]any, level int, report func(string, string, ...any)) {
+	name := node.Definition.Name
+
+	if level >= len(node.Definition.ParentNames) {
+		total := 0.0
+		for value, p := range table {
+			probability, ok := p.(float64)
+			if !ok {
+				report(name, "probability of %q is not a number", value)
+				continue
+			}
+			if !slices.Contains(node.Definition.PossibleValues, value) {
+				report(name, "value %q in the probability table is not a possible value", value)
+			}
+			total += probability
+		}
+		if len(table) > 0 && math.Abs(total-1) > 1e-3 {
+			report(name, "probabilities at depth %d sum to %.4f instead of 1", level, total)
+		}
+		return
+	}
+
+	parentName := node.Definition.ParentNames[level]
+	var parentValues []string
+	if parent, ok := bn.NodesByName[parentName]; ok {
+		parentValues = parent.Definition.PossibleValues
+	}
+
+	if deeper, ok := table["deeper"].(map[string]any); ok {
+		for parentValue, subtable := range deeper {
+			if parentValues != nil && !slices.Contains(parentValues, parentValue) {
+				report(name, "probability table references value %q which parent %q cannot take", parentValue, parentName)
+			}
+			if m, ok := subtable.(map[string]any); ok {
+				bn.validateProbabilities(node, m, level+1, report)
+			}
+		}
+	}
+	if skip, ok := table["skip"].(map[string]any); ok {
+		bn.validateProbabilities(node, skip, level+1, report)
+	}
+}
+
+// findCycle returns the names of the nodes forming a parent cycle, or nil when the graph is acyclic.
+func (bn *Network) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(bn.NodesByName))
+	var stack []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			start := slices.Index(stack, name)
+			return append(slices.Clone(stack[start:]), name)
+		case visited:
+			return nil
+		}
+		node, ok := bn.NodesByName[name]
+		if !ok {
+			return nil
+		}
+
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, parentName := range node.Definition.ParentNames {
+			if cycle := visit(parentName); cycle != nil {
+				return cycle
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, node := range bn.NodesInSamplingOrder {
+		if cycle := visit(node.Definition.Name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+// Package client builds HTTP clients that present a single browser identity: the headers of one
+// header.Session, the TLS parameters and HTTP/2 settings of the browser and a cookie jar. The package
+// depends on the standard library only: without a DialTLSContext, the ClientHello is the one of
+// crypto/tls configured as close to the browser as it allows, not the browser's.
+package client
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"fingerprint-go/header"
+)
+
+// DialTLSFunc opens a TLS connection to addr presenting the ClientHello of profile.
+type DialTLSFunc func(ctx context.Context, network, addr string, profile *header.TLSProfile) (net.Conn, error)
+
+// Decoder returns a reader decompressing a response body of a content coding.
+type Decoder func(body io.Reader) (io.ReadCloser, error)
+
+// BrowserClientOptions configures NewBrowserClient.
+type BrowserClientOptions struct {
+	// Generator generates the headers of the identity. It is required, since the data files are not
+	// bundled with the package.
+	Generator *header.HeaderGenerator
+	// HeaderOptions restrict the identity further, e.g. to operating systems or locales. Browsers is
+	// replaced by the requested browser and version.
+	HeaderOptions *header.HeaderGeneratorOptions
+	// DialTLSContext opens TLS connections with the browser's ClientHello, e.g. with a uTLS UClient
+	// for profile.UTLSClientHelloID. Without it, crypto/tls is configured as close to the profile as it
+	// allows, which does not reproduce the JA3 or JA4 of the browser. net/http only speaks HTTP/2 over
+	// connections that are a *tls.Conn.
+	DialTLSContext DialTLSFunc
+	// Decoders decompress the content codings the package has no decoder for, e.g. "br" and "zstd",
+	// by name. gzip and deflate are decoded without one. Codings without a decoder are removed from the
+	// generated Accept-Encoding, so that no response arrives in a coding that can not be read.
+	Decoders map[string]Decoder
+	// Jar stores the cookies of the identity, the jar of its session when nil, see header.Session.Cookies.
+	Jar http.CookieJar
+	// Timeout is the http.Client Timeout.
+	Timeout time.Duration
+}
+
+// Transport sends requests with the headers of a Session. Header values set on a request take
+// precedence over the generated ones.
+type Transport struct {
+	Session *header.Session
+	// Base sends the requests, http.DefaultTransport when nil.
+	Base http.RoundTripper
+	// Decoders decompress responses, see BrowserClientOptions.
+	Decoders map[string]Decoder
+}
+
+// NewBrowserClient returns an http.Client presenting a sticky identity of browser ("chrome", "edge",
+// "firefox" or "safari") at the major version: every request carries the headers of the same
+// header.Session and cookies are kept. The transport is a *Transport whose Base is an *http.Transport
+// configured with the TLS profile and HTTP/2 settings of the browser, see header.TLSProfileFor and
+// header.HTTP2SettingsFor.
+//
+// The client approximates the browser with the standard library. Without DialTLSContext, e.g. dialing
+// with uTLS, its JA3 and JA4 are those of crypto/tls. net/http does not let a client order its headers
+// or send the exact SETTINGS frame of a browser, so the header order and HTTP/2 fingerprint only
+// approximate the browser too. The identity is the one of the headers; fingerprints for a browser to
+// inject, see fingerprint.GetFingerprintForHeaders, are not part of the client.
+func NewBrowserClient(browser string, version int, options *BrowserClientOptions) (*http.Client, error) {
+	if options == nil || options.Generator == nil {
+		return nil, fmt.Errorf("a header generator is required")
+	}
+	browser = strings.ToLower(browser)
+	profile, ok := header.TLSProfileFor(browser, version, "")
+	if !ok {
+		return nil, fmt.Errorf("unsupported browser %q", browser)
+	}
+
+	headerOptions := header.HeaderGeneratorOptions{}
+	if options.HeaderOptions != nil {
+		headerOptions = *options.HeaderOptions
+	}
+	headerOptions.Browsers = []any{header.BrowserSpecification{Name: browser, MinVersion: version, MaxVersion: version}}
+	session := options.Generator.NewSession(&headerOptions, nil)
+
+	var jar http.CookieJar = session.Cookies()
+	if options.Jar != nil {
+		jar = options.Jar
+	}
+
+	base := newBrowserTransport(browser, profile, options.DialTLSContext)
+	return &http.Client{
+		Transport: &Transport{Session: session, Base: base, Decoders: options.Decoders},
+		Jar:       jar,
+		Timeout:   options.Timeout,
+	}, nil
+}
+
+// newBrowserTransport returns an http.Transport configured with the TLS parameters crypto/tls supports
+// and the HTTP/2 settings net/http announces.
+func newBrowserTransport(browser string, profile *header.TLSProfile, dial DialTLSFunc) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ForceAttemptHTTP2 = true
+	// Compressed responses are decoded by Transport, since the generated Accept-Encoding disables the
+	// transparent decompression of net/http.
+	transport.DisableCompression = true
+	if profile == nil {
+		return transport
+	}
+	transport.TLSClientConfig = tlsConfig(profile)
+	if dial != nil {
+		transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dial(ctx, network, addr, profile)
+		}
+	}
+
+	if settings, ok := header.HTTP2SettingsFor(browser); ok {
+		transport.HTTP2 = &http.HTTP2Config{
+			MaxDecoderHeaderTableSize:     int(settings.HeaderTableSize),
+			MaxReadFrameSize:              int(settings.MaxFrameSize),
+			MaxReceiveBufferPerStream:     int(settings.InitialWindowSize),
+			MaxReceiveBufferPerConnection: int(settings.ConnectionWindowSize),
+		}
+		if settings.MaxHeaderListSize > 0 {
+			transport.MaxResponseHeaderBytes = int64(settings.MaxHeaderListSize)
+		}
+	}
+	return transport
+}
+
+// supportedCurves are the groups of TLS profiles crypto/tls implements.
+var supportedCurves = []tls.CurveID{tls.X25519MLKEM768, tls.X25519, tls.CurveP256, tls.CurveP384, tls.CurveP521}
+
+// tlsConfig returns the crypto/tls configuration closest to profile. crypto/tls picks the order of
+// cipher suites itself and does not configure the TLS 1.3 suites.
+func tlsConfig(profile *header.TLSProfile) *tls.Config {
+	implemented := make(map[uint16]bool)
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		implemented[suite.ID] = slices.Contains(suite.SupportedVersions, tls.VersionTLS12)
+	}
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: slices.Clone(profile.ALPN),
+	}
+	for _, suite := range profile.CipherSuites {
+		if implemented[suite] {
+			config.CipherSuites = append(config.CipherSuites, suite)
+		}
+	}
+	for _, group := range profile.SupportedGroups {
+		if slices.Contains(supportedCurves, tls.CurveID(group)) {
+			config.CurvePreferences = append(config.CurvePreferences, tls.CurveID(group))
+		}
+	}
+	return config
+}
+
+// RoundTrip sends req with the headers of the Session for its URL.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers, err := t.Session.Headers(&header.Request{URL: req.URL.String(), Referrer: req.Header.Get("Referer")})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate headers: %w", err)
+	}
+
+	out := withHeaders(req, headers, t.Decoders)
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	return decodeResponse(resp, t.Decoders)
+}
+
+// withHeaders returns a copy of req carrying the generated headers, with the content codings decoders
+// can not decode removed from Accept-Encoding. Header values set on req take precedence.
+func withHeaders(req *http.Request, headers map[string]string, decoders map[string]Decoder) *http.Request {
+	out := req.Clone(req.Context())
+	out.Header = make(http.Header, len(headers)+len(req.Header))
+	for name, value := range headers {
+		switch strings.ToLower(name) {
+		case "host", "connection", "content-length":
+			// Set by net/http from the request.
+			continue
+		}
+		if strings.EqualFold(name, "accept-encoding") {
+			if value = decodableEncodings(value, decoders); value == "" {
+				continue
+			}
+		}
+		// The names keep the generated casing, which HTTP/1 connections send as is.
+		out.Header[name] = []string{value}
+	}
+	for name, values := range req.Header {
+		for generated := range out.Header {
+			if strings.EqualFold(generated, name) {
+				delete(out.Header, generated)
+				name = generated
+			}
+		}
+		out.Header[name] = values
+	}
+	return out
+}
+
+// decodableEncodings returns the codings of the Accept-Encoding value acceptEncoding that are gzip,
+// deflate or have a decoder.
+func decodableEncodings(acceptEncoding string, decoders map[string]Decoder) string {
+	var kept []string
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, _, _ := strings.Cut(coding, ";")
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "gzip", "x-gzip", "deflate", "identity", "*":
+		default:
+			if decoders[name] == nil {
+				continue
+			}
+		}
+		kept = append(kept, strings.TrimSpace(coding))
+	}
+	return strings.Join(kept, ", ")
+}
+
+// decodeResponse decompresses gzip and deflate bodies and those of the codings of decoders. Other
+// codings are returned as received with their Content-Encoding.
+func decodeResponse(resp *http.Response, decoders map[string]Decoder) (*http.Response, error) {
+	var body io.ReadCloser
+	var err error
+	switch encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); encoding {
+	case "gzip", "x-gzip":
+		body, err = gzip.NewReader(resp.Body)
+	case "deflate":
+		body, err = zlib.NewReader(resp.Body)
+	default:
+		decoder := decoders[encoding]
+		if decoder == nil {
+			return resp, nil
+		}
+		body, err = decoder(resp.Body)
+	}
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to decompress response body: %w", err)
+	}
+	resp.Body = &decodedBody{ReadCloser: body, raw: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// decodedBody closes the decompressor and the body it reads.
+type decodedBody struct {
+	io.ReadCloser
+	raw io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	b.ReadCloser.Close()
+	return b.raw.Close()
+}
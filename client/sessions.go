@@ -0,0 +1,176 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"fingerprint-go/header"
+)
+
+// SessionTransport sends requests with the identities of a SessionManager, one per session key. Every
+// identity gets its own connections, configured with the TLS profile and HTTP/2 settings of its
+// browser, so that a connection opened for one identity never carries the headers of another. When
+// the manager rotates the identity of a key or drops it, the next request opens new connections and
+// those of the old identity are closed once its requests in flight have finished. Set KeepCookies of
+// the manager to keep the cookies of every identity in its session instead of using the Jar of the
+// http.Client, which is shared by all identities.
+type SessionTransport struct {
+	Manager *header.SessionManager
+	// Key returns the session key of a request. Nil pins the identities per host.
+	Key func(req *http.Request) string
+	// DialTLSContext opens the TLS connections of the identities, see BrowserClientOptions.
+	DialTLSContext DialTLSFunc
+	// Decoders decompress responses, see BrowserClientOptions.
+	Decoders map[string]Decoder
+
+	mu   sync.Mutex
+	pins map[string]*pinnedIdentity
+	// retired are the identities no longer pinned whose requests are still in flight.
+	retired   map[*pinnedIdentity]bool
+	rotations int
+}
+
+// pinnedIdentity is the session of a key and the transport of its connections.
+type pinnedIdentity struct {
+	session   *header.Session
+	transport *http.Transport
+	inFlight  int
+	retired   bool
+}
+
+// RoundTrip sends req with the headers of the session of its key and records the response for the
+// rotation policies of the manager and the session.
+func (t *SessionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.URL.Host
+	if t.Key != nil {
+		key = t.Key(req)
+	}
+	session, headers, err := t.Manager.SessionHeaders(key, &header.Request{URL: req.URL.String(), Referrer: req.Header.Get("Referer")})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate headers: %w", err)
+	}
+
+	pin := t.acquire(key, session)
+	resp, err := pin.transport.RoundTrip(withHeaders(req, headers, t.Decoders))
+	if err != nil {
+		t.release(pin)
+		return nil, err
+	}
+	// The connection is in use until the body is closed.
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: func() { t.release(pin) }}
+	t.Manager.RecordOutcome(key, resp.StatusCode)
+	session.RecordResponse(req.URL.String(), resp.Header)
+	return decodeResponse(resp, t.Decoders)
+}
+
+// acquire returns the identity of session for a request of key. A session that is the current one of
+// key replaces the pinned identity of key. A session the manager rotated away after generating the
+// headers of the request is not pinned again; it gets connections of its own, closed after the request.
+func (t *SessionTransport) acquire(key string, session *header.Session) *pinnedIdentity {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pins == nil {
+		t.pins = make(map[string]*pinnedIdentity)
+		t.retired = make(map[*pinnedIdentity]bool)
+	}
+	if rotations := t.Manager.Rotations(); rotations != t.rotations {
+		t.rotations = rotations
+		t.pruneLocked()
+	}
+
+	pin, ok := t.pins[key]
+	if !ok || pin.session != session {
+		pin = nil
+		for retired := range t.retired {
+			if retired.session == session {
+				pin = retired
+				break
+			}
+		}
+		if pin == nil {
+			pin = &pinnedIdentity{session: session, transport: t.newTransport(session)}
+			if current, ok := t.Manager.CurrentSession(key); ok && current == session {
+				if old, ok := t.pins[key]; ok {
+					t.retireLocked(old)
+				}
+				t.pins[key] = pin
+			} else {
+				pin.retired = true
+				t.retired[pin] = true
+			}
+		}
+	}
+	pin.inFlight++
+	return pin
+}
+
+// release ends a request of pin, closing the connections of a retired identity after its last one.
+func (t *SessionTransport) release(pin *pinnedIdentity) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pin.inFlight--
+	if pin.retired && pin.inFlight == 0 {
+		pin.transport.CloseIdleConnections()
+		delete(t.retired, pin)
+	}
+}
+
+// pruneLocked retires the pinned identities of keys the manager rotated or no longer holds.
+func (t *SessionTransport) pruneLocked() {
+	for key, pin := range t.pins {
+		if current, ok := t.Manager.CurrentSession(key); !ok || current != pin.session {
+			delete(t.pins, key)
+			t.retireLocked(pin)
+		}
+	}
+}
+
+// retireLocked unpins pin. Its connections are closed now when it has no request in flight, or else
+// once the last one finishes.
+func (t *SessionTransport) retireLocked(pin *pinnedIdentity) {
+	pin.retired = true
+	if pin.inFlight == 0 {
+		pin.transport.CloseIdleConnections()
+		return
+	}
+	t.retired[pin] = true
+}
+
+// newTransport returns a transport configured for the browser of session.
+func (t *SessionTransport) newTransport(session *header.Session) *http.Transport {
+	userAgent := session.UserAgent()
+	browser := header.GetBrowser(userAgent)
+	var major int
+	if version := header.GetBrowserVersion(userAgent); len(version) > 0 {
+		major = version[0]
+	}
+	profile, _ := header.TLSProfileFor(browser, major, header.GetOperatingSystem(userAgent))
+	return newBrowserTransport(browser, profile, t.DialTLSContext)
+}
+
+// CloseIdleConnections closes the idle connections of all identities.
+func (t *SessionTransport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, pin := range t.pins {
+		pin.transport.CloseIdleConnections()
+	}
+	for pin := range t.retired {
+		pin.transport.CloseIdleConnections()
+	}
+}
+
+// releasingBody calls release once when it is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,253 @@
+// Command fingerprint works with the data files of the header and fingerprint generators.
+//
+// Usage:
+//
+//	fingerprint build-data -dataset records.json -out data_files [flags]
+//	fingerprint export-stats -dataset records.json -out stats [flags]
+//	fingerprint evaluate -dataset records.json -data data_files [flags]
+//	fingerprint export -data data_files -count 100 -out bundle.zip [flags]
+//
+// build-data rebuilds the data files from a dataset of collected browser records with the network
+// package's GeneratorNetworksCreator and writes the bundled capabilities.json; with -headers-only it
+// leaves out the fingerprint network.
+// export-stats writes the aggregate value frequencies of a dataset, without its records, to share it
+// for debugging generation quality. evaluate reports how well the network definitions of a data
+// files directory fit records held out from a dataset. export writes a bundle of generated
+// fingerprints with their headers, TLS and HTTP/2 profiles and init scripts, see
+// fingerprint.WriteBundle, to provision identities offline for worker nodes; with -key-env the bundle
+// is sealed with the AES key in that environment variable, see fingerprint.WriteSealedBundle.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"fingerprint-go/fingerprint"
+	"fingerprint-go/header"
+	"fingerprint-go/network"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "build-data":
+		err = buildData(os.Args[2:])
+	case "export-stats":
+		err = exportStats(os.Args[2:])
+	case "evaluate":
+		err = evaluate(os.Args[2:])
+	case "export":
+		err = export(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "fingerprint: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fingerprint: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: fingerprint <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  build-data    rebuild the generator data files from a dataset")
+	fmt.Fprintln(os.Stderr, "  export-stats  export anonymized value frequencies of a dataset")
+	fmt.Fprintln(os.Stderr, "  evaluate      evaluate network definitions on held-out dataset records")
+	fmt.Fprintln(os.Stderr, "  export        export generated fingerprints as a bundle for worker nodes")
+}
+
+func buildData(args []string) error {
+	flags := flag.NewFlagSet("build-data", flag.ExitOnError)
+	datasetPath := flags.String("dataset", "", "dataset file, directory of dataset files or glob pattern of dataset files")
+	outPath := flags.String("out", ".", "directory the data files are written to")
+	strict := flags.Bool("strict", false, "fail on invalid records instead of skipping them")
+	parallelism := flags.Int("parallelism", 2, "number of data file sets built at the same time")
+	format := flags.String("format", network.FormatJSON, "dataset format: json or apify")
+	halfLife := flags.Duration("half-life", 0, "weight records by capture time, halving the weight per half-life, e.g. 720h")
+	timestampField := flags.String("timestamp-field", "timestamp", "record field holding the capture time")
+	bucketScreens := flags.Bool("bucket-screens", false, "round screen offsets and collapse rare window heights")
+	minPluginRecords := flags.Int("min-plugin-records", network.DefaultMinPluginSetRecords, "records a plugin set must be seen in to be kept")
+	headersOnly := flags.Bool("headers-only", false, "build only the data files of the header generator")
+	flags.Parse(args)
+
+	if *datasetPath == "" {
+		return errors.New("the -dataset flag is required")
+	}
+	if !slices.Contains(network.DatasetFormats, *format) {
+		return fmt.Errorf("unsupported dataset format %q, supported formats: %v", *format, network.DatasetFormats)
+	}
+	if *parallelism < 1 {
+		return errors.New("the -parallelism flag must be at least 1")
+	}
+	if err := os.MkdirAll(*outPath, 0755); err != nil {
+		return err
+	}
+
+	creator := network.NewGeneratorNetworksCreator()
+	creator.Strict = *strict
+	creator.Format = *format
+	creator.RecencyHalfLife = *halfLife
+	creator.TimestampField = *timestampField
+	creator.MinPluginSetRecords = *minPluginRecords
+	creator.BucketScreens = *bucketScreens
+	steps := []func(string, string) error{creator.PrepareHeaderGeneratorFiles, writeCapabilities}
+	if !*headersOnly {
+		steps = append(steps, creator.PrepareFingerprintGeneratorFiles)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(steps))
+	slots := make(chan struct{}, *parallelism)
+	for i, step := range steps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			errs[i] = step(*datasetPath, *outPath)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// writeCapabilities writes the bundled capability matrix as capabilities.json, so that it can be
+// updated with the data files.
+func writeCapabilities(_ string, outPath string) error {
+	b, err := json.MarshalIndent(header.DefaultCapabilities, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outPath, "capabilities.json"), b, 0644)
+}
+
+func exportStats(args []string) error {
+	flags := flag.NewFlagSet("export-stats", flag.ExitOnError)
+	datasetPath := flags.String("dataset", "", "dataset file, directory of dataset files or glob pattern of dataset files")
+	outPath := flags.String("out", ".", "directory dataset-statistics.json is written to")
+	strict := flags.Bool("strict", false, "fail on invalid records instead of skipping them")
+	minCount := flags.Int("min-count", 10, "number of records a value must be seen in to be exported")
+	format := flags.String("format", network.FormatJSON, "dataset format: json or apify")
+	flags.Parse(args)
+
+	if *datasetPath == "" {
+		return errors.New("the -dataset flag is required")
+	}
+	if err := os.MkdirAll(*outPath, 0755); err != nil {
+		return err
+	}
+
+	creator := network.NewGeneratorNetworksCreator()
+	creator.Strict = *strict
+	creator.Format = *format
+	return creator.ExportStatistics(*datasetPath, *outPath, *minCount)
+}
+
+func evaluate(args []string) error {
+	flags := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	datasetPath := flags.String("dataset", "", "dataset file, directory of dataset files or glob pattern of dataset files")
+	dataPath := flags.String("data", ".", "directory of the network definitions to evaluate")
+	holdout := flags.Float64("holdout", 0.2, "fraction of the records held out for the evaluation")
+	format := flags.String("format", network.FormatJSON, "dataset format: json or apify")
+	flags.Parse(args)
+
+	if *datasetPath == "" {
+		return errors.New("the -dataset flag is required")
+	}
+	if *holdout <= 0 || *holdout > 1 {
+		return errors.New("the -holdout flag must be in (0, 1]")
+	}
+
+	creator := network.NewGeneratorNetworksCreator()
+	creator.Format = *format
+	evaluations, err := creator.EvaluateNetworks(*datasetPath, *dataPath, *holdout)
+	if err != nil {
+		return err
+	}
+	if len(evaluations) == 0 {
+		return fmt.Errorf("no network definitions found in %s", *dataPath)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(evaluations)
+}
+
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	dataPath := flags.String("data", ".", "data files directory of the generator")
+	outPath := flags.String("out", "bundle.zip", "bundle file to write")
+	count := flags.Int("count", 100, "number of fingerprints to export")
+	browsers := flags.String("browsers", "", "comma-separated browsers to generate, e.g. chrome,firefox")
+	operatingSystems := flags.String("operating-systems", "", "comma-separated operating systems to generate")
+	devices := flags.String("devices", "", "comma-separated devices to generate: desktop or mobile")
+	minify := flags.Bool("minify", false, "minify the init scripts")
+	keyEnv := flags.String("key-env", "", "environment variable holding a base64 AES key to seal the bundle with")
+	flags.Parse(args)
+
+	if *count <= 0 {
+		return errors.New("the -count flag must be positive")
+	}
+	headerOptions := &header.HeaderGeneratorOptions{}
+	for _, browser := range splitList(*browsers) {
+		headerOptions.Browsers = append(headerOptions.Browsers, browser)
+	}
+	headerOptions.OperatingSystems = splitList(*operatingSystems)
+	headerOptions.Devices = splitList(*devices)
+	generator, err := fingerprint.NewFingerprintGenerator(&fingerprint.FingerprintGeneratorOptions{HeaderGeneratorOptions: headerOptions}, *dataPath)
+	if err != nil {
+		return err
+	}
+
+	scriptOptions := &fingerprint.InitScriptOptions{Minify: *minify}
+	if *keyEnv != "" {
+		var bundle bytes.Buffer
+		if err := generator.ExportBundle(&bundle, *count, nil, scriptOptions); err != nil {
+			return err
+		}
+		sealed, err := header.SealWithKey(bundle.Bytes(), header.KeyFromEnv(*keyEnv))
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(*outPath, sealed, 0o600)
+	}
+
+	f, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	if err := generator.ExportBundle(f, *count, nil, scriptOptions); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// splitList returns the non-empty comma-separated values of list.
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
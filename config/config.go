@@ -0,0 +1,253 @@
+// Package config loads generator options from JSON, YAML or TOML files, so deployments can configure
+// the generators declaratively.
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"fingerprint-go/fingerprint"
+	"fingerprint-go/header"
+)
+
+// Browser configures one browser, optionally restricted to a version range and HTTP version.
+type Browser struct {
+	Name        string `json:"name" yaml:"name" toml:"name"`
+	MinVersion  int    `json:"minVersion,omitempty" yaml:"minVersion,omitempty" toml:"minVersion,omitempty"`
+	MaxVersion  int    `json:"maxVersion,omitempty" yaml:"maxVersion,omitempty" toml:"maxVersion,omitempty"`
+	HttpVersion string `json:"httpVersion,omitempty" yaml:"httpVersion,omitempty" toml:"httpVersion,omitempty"`
+}
+
+// OperatingSystem configures one operating system, optionally restricted to a range of major versions.
+type OperatingSystem struct {
+	Name       string `json:"name" yaml:"name" toml:"name"`
+	MinVersion int    `json:"minVersion,omitempty" yaml:"minVersion,omitempty" toml:"minVersion,omitempty"`
+	MaxVersion int    `json:"maxVersion,omitempty" yaml:"maxVersion,omitempty" toml:"maxVersion,omitempty"`
+}
+
+// Screen bounds the generated screen size.
+type Screen struct {
+	MinWidth  *float64 `json:"minWidth,omitempty" yaml:"minWidth,omitempty" toml:"minWidth,omitempty"`
+	MaxWidth  *float64 `json:"maxWidth,omitempty" yaml:"maxWidth,omitempty" toml:"maxWidth,omitempty"`
+	MinHeight *float64 `json:"minHeight,omitempty" yaml:"minHeight,omitempty" toml:"minHeight,omitempty"`
+	MaxHeight *float64 `json:"maxHeight,omitempty" yaml:"maxHeight,omitempty" toml:"maxHeight,omitempty"`
+}
+
+// Presets are the ready-made options a Config can start from, by name.
+var Presets = map[string]func() *fingerprint.FingerprintGeneratorOptions{
+	"ios-safari": fingerprint.IOSSafariOptions,
+}
+
+// Config is the declarative form of fingerprint.FingerprintGeneratorOptions.
+type Config struct {
+	// DataFilesPath is the directory holding the network definitions and helper files.
+	DataFilesPath string `json:"dataFilesPath,omitempty" yaml:"dataFilesPath,omitempty" toml:"dataFilesPath,omitempty"`
+	// Preset names the options of Presets the configuration starts from. Browsers, operating systems
+	// and devices set in the configuration replace those of the preset, other set values are added.
+	Preset           string            `json:"preset,omitempty" yaml:"preset,omitempty" toml:"preset,omitempty"`
+	Browsers         []Browser         `json:"browsers,omitempty" yaml:"browsers,omitempty" toml:"browsers,omitempty"`
+	BrowserListQuery string            `json:"browserListQuery,omitempty" yaml:"browserListQuery,omitempty" toml:"browserListQuery,omitempty"`
+	OperatingSystems []OperatingSystem `json:"operatingSystems,omitempty" yaml:"operatingSystems,omitempty" toml:"operatingSystems,omitempty"`
+	Devices          []string          `json:"devices,omitempty" yaml:"devices,omitempty" toml:"devices,omitempty"`
+	Locales          []string          `json:"locales,omitempty" yaml:"locales,omitempty" toml:"locales,omitempty"`
+	HttpVersion      string            `json:"httpVersion,omitempty" yaml:"httpVersion,omitempty" toml:"httpVersion,omitempty"`
+	Strict           bool              `json:"strict,omitempty" yaml:"strict,omitempty" toml:"strict,omitempty"`
+	Temperature      float64           `json:"temperature,omitempty" yaml:"temperature,omitempty" toml:"temperature,omitempty"`
+	WebViewPackage   string            `json:"webViewPackage,omitempty" yaml:"webViewPackage,omitempty" toml:"webViewPackage,omitempty"`
+
+	Screen                *Screen             `json:"screen,omitempty" yaml:"screen,omitempty" toml:"screen,omitempty"`
+	MockWebRTC            bool                `json:"mockWebRTC,omitempty" yaml:"mockWebRTC,omitempty" toml:"mockWebRTC,omitempty"`
+	Slim                  bool                `json:"slim,omitempty" yaml:"slim,omitempty" toml:"slim,omitempty"`
+	UserAgentFallback     bool                `json:"userAgentFallback,omitempty" yaml:"userAgentFallback,omitempty" toml:"userAgentFallback,omitempty"`
+	Constraints           map[string][]string `json:"constraints,omitempty" yaml:"constraints,omitempty" toml:"constraints,omitempty"`
+	BannedValues          map[string][]string `json:"bannedValues,omitempty" yaml:"bannedValues,omitempty" toml:"bannedValues,omitempty"`
+	ExcludeVirtualGPUs    bool                `json:"excludeVirtualGPUs,omitempty" yaml:"excludeVirtualGPUs,omitempty" toml:"excludeVirtualGPUs,omitempty"`
+	MinCores              int                 `json:"minCores,omitempty" yaml:"minCores,omitempty" toml:"minCores,omitempty"`
+	MinMemoryGB           float64             `json:"minMemoryGB,omitempty" yaml:"minMemoryGB,omitempty" toml:"minMemoryGB,omitempty"`
+	Model                 string              `json:"model,omitempty" yaml:"model,omitempty" toml:"model,omitempty"`
+	TimeZone              string              `json:"timeZone,omitempty" yaml:"timeZone,omitempty" toml:"timeZone,omitempty"`
+	AvoidSuspiciousValues bool                `json:"avoidSuspiciousValues,omitempty" yaml:"avoidSuspiciousValues,omitempty" toml:"avoidSuspiciousValues,omitempty"`
+}
+
+// Load reads a configuration file. The format is selected by the extension: .json, .yaml, .yml or .toml.
+// Unknown keys are rejected, so that a misspelled option does not silently change generation.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&cfg)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		// An empty file is an empty configuration.
+		if err = decoder.Decode(&cfg); errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case ".toml":
+		var metadata toml.MetaData
+		metadata, err = toml.Decode(string(data), &cfg)
+		if undecoded := metadata.Undecoded(); err == nil && len(undecoded) > 0 {
+			err = fmt.Errorf("unknown key %q", undecoded[0].String())
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks the values that have a fixed set of allowed values.
+func (c *Config) Validate() error {
+	if _, ok := Presets[c.Preset]; c.Preset != "" && !ok {
+		return fmt.Errorf("unknown preset %q", c.Preset)
+	}
+	for _, b := range c.Browsers {
+		if !slices.Contains(header.SupportedBrowsers, b.Name) {
+			return fmt.Errorf("unsupported browser %q", b.Name)
+		}
+		if b.MinVersion != 0 && b.MaxVersion != 0 && b.MinVersion > b.MaxVersion {
+			return fmt.Errorf("browser %q has minVersion above maxVersion", b.Name)
+		}
+	}
+	for _, os := range c.OperatingSystems {
+		if !slices.Contains(header.SupportedOperatingSystems, os.Name) {
+			return fmt.Errorf("unsupported operating system %q", os.Name)
+		}
+		if os.MinVersion != 0 && os.MaxVersion != 0 && os.MinVersion > os.MaxVersion {
+			return fmt.Errorf("operating system %q has minVersion above maxVersion", os.Name)
+		}
+	}
+	for _, device := range c.Devices {
+		if !slices.Contains(header.SupportedDevices, device) {
+			return fmt.Errorf("unsupported device %q", device)
+		}
+	}
+	if c.HttpVersion != "" && !slices.Contains(header.SupportedHttpVersions, c.HttpVersion) {
+		return fmt.Errorf("unsupported HTTP version %q", c.HttpVersion)
+	}
+	return nil
+}
+
+// preset returns the options of the preset of the configuration, or empty options without one.
+func (c *Config) preset() *fingerprint.FingerprintGeneratorOptions {
+	if preset, ok := Presets[c.Preset]; ok {
+		return preset()
+	}
+	return &fingerprint.FingerprintGeneratorOptions{}
+}
+
+// HeaderOptions converts the configuration to header generator options.
+func (c *Config) HeaderOptions() *header.HeaderGeneratorOptions {
+	return c.headerOptions(c.preset().HeaderGeneratorOptions)
+}
+
+// headerOptions returns base, the header options of a preset, with the values set in the configuration.
+func (c *Config) headerOptions(base *header.HeaderGeneratorOptions) *header.HeaderGeneratorOptions {
+	options := &header.HeaderGeneratorOptions{}
+	if base != nil {
+		*options = *base
+	}
+	options.BrowserListQuery = cmp.Or(c.BrowserListQuery, options.BrowserListQuery)
+	options.HttpVersion = cmp.Or(c.HttpVersion, options.HttpVersion)
+	options.Temperature = cmp.Or(c.Temperature, options.Temperature)
+	options.WebViewPackage = cmp.Or(c.WebViewPackage, options.WebViewPackage)
+	options.Strict = c.Strict || options.Strict
+	if len(c.Devices) > 0 {
+		options.Devices = c.Devices
+	}
+	if len(c.Locales) > 0 {
+		options.Locales = c.Locales
+	}
+	if len(c.OperatingSystems) > 0 {
+		options.OperatingSystems, options.OperatingSystemSpecifications = nil, nil
+	}
+	if len(c.Browsers) > 0 {
+		options.Browsers = nil
+	}
+	for _, os := range c.OperatingSystems {
+		options.OperatingSystemSpecifications = append(options.OperatingSystemSpecifications, header.OperatingSystemSpecification{
+			Name:       os.Name,
+			MinVersion: os.MinVersion,
+			MaxVersion: os.MaxVersion,
+		})
+	}
+	for _, b := range c.Browsers {
+		options.Browsers = append(options.Browsers, header.BrowserSpecification{
+			Name:        b.Name,
+			MinVersion:  b.MinVersion,
+			MaxVersion:  b.MaxVersion,
+			HttpVersion: b.HttpVersion,
+		})
+	}
+	return options
+}
+
+// FingerprintOptions converts the configuration to fingerprint generator options.
+func (c *Config) FingerprintOptions() *fingerprint.FingerprintGeneratorOptions {
+	options := c.preset()
+	options.HeaderGeneratorOptions = c.headerOptions(options.HeaderGeneratorOptions)
+	options.MockWebRTC = c.MockWebRTC || options.MockWebRTC
+	options.Slim = c.Slim || options.Slim
+	options.UserAgentFallback = c.UserAgentFallback || options.UserAgentFallback
+	options.ExcludeVirtualGPUs = c.ExcludeVirtualGPUs || options.ExcludeVirtualGPUs
+	options.AvoidSuspiciousValues = c.AvoidSuspiciousValues || options.AvoidSuspiciousValues
+	options.MinCores = cmp.Or(c.MinCores, options.MinCores)
+	options.MinMemoryGB = cmp.Or(c.MinMemoryGB, options.MinMemoryGB)
+	options.Model = cmp.Or(c.Model, options.Model)
+	options.TimeZone = cmp.Or(c.TimeZone, options.TimeZone)
+	options.Constraints = mergeValues(options.Constraints, c.Constraints)
+	options.BannedValues = mergeValues(options.BannedValues, c.BannedValues)
+	if c.Screen != nil {
+		options.Screen = &fingerprint.FingerprintScreenOptions{
+			MinWidth:  c.Screen.MinWidth,
+			MaxWidth:  c.Screen.MaxWidth,
+			MinHeight: c.Screen.MinHeight,
+			MaxHeight: c.Screen.MaxHeight,
+		}
+	}
+	return options
+}
+
+// mergeValues returns the values of base with those of values replacing them per node.
+func mergeValues(base, values map[string][]string) map[string][]string {
+	if len(base) == 0 {
+		return values
+	}
+	merged := maps.Clone(base)
+	maps.Copy(merged, values)
+	return merged
+}
+
+// NewFingerprintGenerator creates a fingerprint generator configured by the file at path.
+func NewFingerprintGenerator(path string) (*fingerprint.FingerprintGenerator, error) {
+	cfg, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return fingerprint.NewFingerprintGenerator(cfg.FingerprintOptions(), cfg.DataFilesPath)
+}
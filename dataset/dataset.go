@@ -0,0 +1,32 @@
+// Package dataset exposes the stable parts of the dataset format: the names of the special network
+// nodes and the tokens used in values. Tools that build or validate datasets can rely on them.
+package dataset
+
+import (
+	"fingerprint-go/bayesian"
+	"fingerprint-go/internal/constants"
+)
+
+// Names of the input nodes of the header networks.
+const (
+	BrowserHttpNodeName     = constants.BrowserHttpNodeName
+	HttpVersionNodeName     = constants.HttpVersionNodeName
+	BrowserNodeName         = constants.BrowserNodeName
+	OperatingSystemNodeName = constants.OperatingSystemNodeName
+	DeviceNodeName          = constants.DeviceNodeName
+)
+
+const (
+	// MissingValueToken is the value of attributes a record does not have.
+	MissingValueToken = constants.MissingValueDatasetToken
+	// StringifiedPrefix prefixes values holding JSON-encoded objects, e.g. the screen of a fingerprint.
+	StringifiedPrefix = constants.StringifiedPrefix
+	// RecordWeightKey holds the training weight of a prepared record, e.g. its recency weight.
+	RecordWeightKey = constants.RecordWeightKey
+	// ScreenOffsetStep is the step screenX and the page offsets are bucketed to by the network builder.
+	ScreenOffsetStep = constants.ScreenOffsetStep
+)
+
+// NetworkFormatVersion is the format version of the network definitions the generators read, stored in
+// their "formatVersion" field. Definitions without it are read as version 1.
+const NetworkFormatVersion = bayesian.DefinitionFormatVersion
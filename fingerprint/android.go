@@ -0,0 +1,169 @@
+package fingerprint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AndroidModel is an Android device as it identifies itself in the user agent and in
+// navigator.userAgentData.model, with the screen it reports in CSS pixels.
+type AndroidModel struct {
+	Name             string
+	Weight           float64
+	Width            float64
+	Height           float64
+	DevicePixelRatio float64
+}
+
+// AndroidModels is the catalog of Android devices used for generated fingerprints. Models are picked
+// according to their weights. Entries may be added before generating fingerprints.
+var AndroidModels = []AndroidModel{
+	{Name: "SM-S911B", Weight: 9, Width: 360, Height: 780, DevicePixelRatio: 3},
+	{Name: "SM-S918B", Weight: 7, Width: 384, Height: 824, DevicePixelRatio: 3.75},
+	{Name: "SM-A546B", Weight: 10, Width: 384, Height: 854, DevicePixelRatio: 2.8125},
+	{Name: "SM-A536B", Weight: 8, Width: 412, Height: 915, DevicePixelRatio: 2.625},
+	{Name: "SM-G991B", Weight: 6, Width: 360, Height: 800, DevicePixelRatio: 3},
+	{Name: "Pixel 7", Weight: 8, Width: 412, Height: 915, DevicePixelRatio: 2.625},
+	{Name: "Pixel 7 Pro", Weight: 4, Width: 412, Height: 892, DevicePixelRatio: 3.5},
+	{Name: "Pixel 8", Weight: 6, Width: 412, Height: 915, DevicePixelRatio: 2.625},
+	{Name: "Pixel 6a", Weight: 5, Width: 412, Height: 915, DevicePixelRatio: 2.625},
+	{Name: "23021RAAEG", Weight: 7, Width: 393, Height: 873, DevicePixelRatio: 2.75},
+	{Name: "2201117TG", Weight: 6, Width: 393, Height: 873, DevicePixelRatio: 2.75},
+}
+
+// reducedAndroidModel is the placeholder model of Chrome's reduced user agent string. The real model
+// is then only available through navigator.userAgentData and the sec-ch-ua-model client hint.
+const reducedAndroidModel = "K"
+
+var androidModelPattern = regexp.MustCompile(`Android [\d.]+; ([^;)]+?)(?: Build/[^;)]*)?(?:; wv)?\)`)
+
+// androidUserAgentModel returns the device model embedded in an Android user agent.
+func androidUserAgentModel(userAgent string) (string, bool) {
+	match := androidModelPattern.FindStringSubmatch(userAgent)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// withAndroidModel returns userAgent with its device model replaced by model.
+func withAndroidModel(userAgent string, model string) string {
+	loc := androidModelPattern.FindStringSubmatchIndex(userAgent)
+	if loc == nil {
+		return userAgent
+	}
+	return userAgent[:loc[2]] + model + userAgent[loc[3]:]
+}
+
+func lookupAndroidModel(name string) *AndroidModel {
+	name = strings.TrimPrefix(name, "SAMSUNG ")
+	for i := range AndroidModels {
+		if AndroidModels[i].Name == name {
+			return &AndroidModels[i]
+		}
+	}
+	return nil
+}
+
+// pickAndroidModel picks a model of the catalog according to the weights. The pick is derived from
+// key, so that regenerating a fingerprint picks the same model.
+func pickAndroidModel(key string) string {
+	total := 0.0
+	for _, m := range AndroidModels {
+		total += m.Weight
+	}
+	if total <= 0 {
+		return ""
+	}
+	r := stableFraction(key) * total
+	for _, m := range AndroidModels {
+		r -= m.Weight
+		if r < 0 {
+			return m.Name
+		}
+	}
+	return AndroidModels[len(AndroidModels)-1].Name
+}
+
+func validateAndroidModel(model string) error {
+	if model != "" && lookupAndroidModel(model) == nil {
+		return fmt.Errorf("The Android model %q is not in the model catalog.", model)
+	}
+	return nil
+}
+
+// applyAndroidModel makes the device model of an Android fingerprint agree across the user agent,
+// navigator.userAgentData.model and the screen metrics. The requested model wins over the one in the
+// user agent; Chrome's reduced user agent keeps its placeholder. Headers are only changed when
+// rewriteHeaders is set.
+func applyAndroidModel(fp *Fingerprint, headers map[string]string, requested string, rewriteHeaders bool) {
+	userAgent := fp.Navigator.UserAgent
+	uaModel, ok := androidUserAgentModel(userAgent)
+	if !ok {
+		return
+	}
+
+	model := uaModel
+	switch {
+	case requested != "":
+		model = requested
+		if uaModel != reducedAndroidModel && uaModel != requested {
+			replaced := withAndroidModel(userAgent, requested)
+			replaceUserAgent(fp, userAgent, replaced)
+			if rewriteHeaders {
+				setHeader(headers, "user-agent", replaced)
+			}
+		}
+	case uaModel == reducedAndroidModel:
+		if hint := strings.Trim(getHeader(headers, "sec-ch-ua-model"), `"`); hint != "" {
+			model = hint
+		} else if fp.Navigator.UserAgentData.Model != "" {
+			model = fp.Navigator.UserAgentData.Model
+		} else {
+			model = pickAndroidModel(fmt.Sprintf("%s %vx%v", userAgent, fp.Screen.Width, fp.Screen.Height))
+		}
+	}
+
+	if len(fp.Navigator.UserAgentData.Brands) > 0 {
+		fp.Navigator.UserAgentData.Model = strings.TrimPrefix(model, "SAMSUNG ")
+		if rewriteHeaders {
+			for k := range headers {
+				if strings.EqualFold(k, "sec-ch-ua-model") {
+					headers[k] = `"` + fp.Navigator.UserAgentData.Model + `"`
+				}
+			}
+		}
+	}
+
+	if spec := lookupAndroidModel(model); spec != nil {
+		applyModelScreen(&fp.Screen, spec)
+	}
+}
+
+// applyModelScreen sets the screen size of the model, keeping the sampled browser chrome (the
+// differences between the screen and the available, window and viewport sizes).
+func applyModelScreen(screen *ScreenFingerprint, model *AndroidModel) {
+	if screen.Width <= 0 || screen.Height <= 0 {
+		return
+	}
+	dw, dh := model.Width-screen.Width, model.Height-screen.Height
+	for _, w := range []*float64{&screen.AvailWidth, &screen.InnerWidth, &screen.OuterWidth, &screen.ClientWidth} {
+		*w = max(*w+dw, 0)
+	}
+	for _, h := range []*float64{&screen.AvailHeight, &screen.InnerHeight, &screen.OuterHeight, &screen.ClientHeight} {
+		*h = max(*h+dh, 0)
+	}
+	screen.Width = model.Width
+	screen.Height = model.Height
+	screen.DevicePixelRatio = model.DevicePixelRatio
+}
+
+func getHeader(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package fingerprint
+
+import (
+	"fmt"
+	"strings"
+
+	"fingerprint-go/network"
+)
+
+// AuditFinding is a value of a generated fingerprint that is statistically associated with headless
+// browsers and bots, and so draws attention even though real browsers report it too.
+type AuditFinding struct {
+	Attribute string `json:"attribute"`
+	Reason    string `json:"reason"`
+}
+
+// AuditFingerprint returns the values of fp that are typical of headless and automated environments.
+func AuditFingerprint(fp *Fingerprint) []AuditFinding {
+	var findings []AuditFinding
+	add := func(attribute string, format string, args ...any) {
+		findings = append(findings, AuditFinding{Attribute: attribute, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	screen := fp.Screen
+	if screen.DevicePixelRatio == 1 && (screen.Width == 1024 && screen.Height == 768 || screen.Width == 800 && screen.Height == 600) {
+		add("screen", "%vx%v at device pixel ratio 1 is the default window of headless browsers", screen.Width, screen.Height)
+	}
+
+	memory := 0.0
+	if fp.Navigator.DeviceMemory != nil {
+		memory = *fp.Navigator.DeviceMemory
+	}
+	if fp.Navigator.HardwareConcurrency > 0 && fp.Navigator.HardwareConcurrency <= 2 && memory > 0 && memory <= 2 {
+		add("hardwareConcurrency", "%d cores with %vGB of memory is typical of cloud servers", fp.Navigator.HardwareConcurrency, memory)
+	}
+
+	for _, part := range network.VirtualWebGLRendererParts {
+		if strings.Contains(fp.VideoCard.Renderer, part) {
+			add("videoCard", "the %s renderer is used by virtual machines and headless browsers", part)
+			break
+		}
+	}
+
+	if strings.Contains(fp.Navigator.UserAgent, "Headless") {
+		add("userAgent", "the user agent identifies a headless browser")
+	}
+	if fp.Navigator.Webdriver == "true" {
+		add("webdriver", "navigator.webdriver is set by automated browsers")
+	}
+	if len(fp.Navigator.Languages) == 0 {
+		add("languages", "browsers always report at least one language")
+	}
+
+	return findings
+}
@@ -0,0 +1,90 @@
+package fingerprint
+
+import (
+	"fmt"
+
+	"fingerprint-go/header"
+)
+
+// ScreenInsets are the parts of the screen the operating system reserves for its own UI, such as the
+// Windows taskbar or the macOS menu bar, in CSS pixels. Windows can not cover them, so the available
+// screen area (screen.availTop, availLeft, availWidth and availHeight) leaves them out.
+type ScreenInsets struct {
+	Top    float64
+	Bottom float64
+	Left   float64
+	Right  float64
+}
+
+// defaultScreenInsets are the reserved areas of the default desktop layouts. Mobile browsers can use
+// the whole screen.
+var defaultScreenInsets = map[string]ScreenInsets{
+	"windows": {Bottom: 40},
+	"macos":   {Top: 25},
+	"android": {},
+	"ios":     {},
+}
+
+// DefaultScreenInsets returns the reserved screen area of the default layout of operatingSystem. ok is
+// false when the operating system has no single default, as Linux desktops.
+func DefaultScreenInsets(operatingSystem string) (insets ScreenInsets, ok bool) {
+	insets, ok = defaultScreenInsets[operatingSystem]
+	return insets, ok
+}
+
+// AvailAreaProblem describes why the available screen area of screen is impossible on
+// operatingSystem, or returns "" when it is plausible: it must lie within the screen, mobile browsers
+// report the whole screen and macOS always reserves the menu bar at the top.
+func AvailAreaProblem(screen *ScreenFingerprint, operatingSystem string) string {
+	if screen.Width <= 0 || screen.Height <= 0 {
+		return ""
+	}
+	switch {
+	case screen.AvailTop < 0 || screen.AvailLeft < 0:
+		return fmt.Sprintf("the available area starts at %v,%v outside the screen", screen.AvailLeft, screen.AvailTop)
+	case screen.AvailWidth <= 0 || screen.AvailHeight <= 0:
+		return fmt.Sprintf("the available area is %vx%v", screen.AvailWidth, screen.AvailHeight)
+	case screen.AvailLeft+screen.AvailWidth > screen.Width || screen.AvailTop+screen.AvailHeight > screen.Height:
+		return fmt.Sprintf("the available area %vx%v at %v,%v exceeds the %vx%v screen",
+			screen.AvailWidth, screen.AvailHeight, screen.AvailLeft, screen.AvailTop, screen.Width, screen.Height)
+	}
+
+	switch operatingSystem {
+	case "android", "ios":
+		if screen.AvailTop != 0 || screen.AvailLeft != 0 || screen.AvailWidth != screen.Width || screen.AvailHeight != screen.Height {
+			return fmt.Sprintf("a mobile screen of %vx%v reports the available area %vx%v at %v,%v",
+				screen.Width, screen.Height, screen.AvailWidth, screen.AvailHeight, screen.AvailLeft, screen.AvailTop)
+		}
+	case "macos":
+		if screen.AvailTop == 0 {
+			return "the available area on macOS starts at the top of the screen, without the menu bar"
+		}
+	}
+	return ""
+}
+
+// applyAvailArea replaces an impossible available screen area, see AvailAreaProblem, with the default
+// layout of the operating system and keeps the window within it.
+func applyAvailArea(fp *Fingerprint) {
+	screen := &fp.Screen
+	operatingSystem := header.GetOperatingSystem(fp.Navigator.UserAgent)
+	if AvailAreaProblem(screen, operatingSystem) == "" {
+		return
+	}
+	insets, _ := DefaultScreenInsets(operatingSystem)
+	screen.AvailTop, screen.AvailLeft = insets.Top, insets.Left
+	screen.AvailWidth = screen.Width - insets.Left - insets.Right
+	screen.AvailHeight = screen.Height - insets.Top - insets.Bottom
+
+	if excess := screen.OuterHeight - screen.AvailHeight; excess > 0 {
+		screen.OuterHeight -= excess
+		screen.InnerHeight = max(screen.InnerHeight-excess, 0)
+		screen.ClientHeight = max(screen.ClientHeight-excess, 0)
+	}
+	if excess := screen.OuterWidth - screen.AvailWidth; excess > 0 && operatingSystem != "windows" {
+		// Maximized Windows windows are wider than the available area by their hidden borders.
+		screen.OuterWidth -= excess
+		screen.InnerWidth = max(screen.InnerWidth-excess, 0)
+		screen.ClientWidth = max(screen.ClientWidth-excess, 0)
+	}
+}
@@ -0,0 +1,53 @@
+package fingerprint
+
+import (
+	"fmt"
+	"slices"
+
+	"fingerprint-go/header"
+)
+
+// firefoxBatteryRemovedVersion is the Firefox version that removed the Battery Status API from web
+// content.
+const firefoxBatteryRemovedVersion = 52
+
+// hasBatteryAPI reports whether the browser of userAgent implements navigator.getBattery. WebKit never
+// did and Firefox removed it.
+func hasBatteryAPI(userAgent string) bool {
+	if isWebKit(userAgent) {
+		return false
+	}
+	if header.GetBrowser(userAgent) == "firefox" {
+		version := header.GetBrowserVersion(userAgent)
+		return len(version) > 0 && version[0] < firefoxBatteryRemovedVersion
+	}
+	return true
+}
+
+// BatteryProblem describes why fp has battery values its browser can not report, or returns "" when
+// it is plausible: browsers without navigator.getBattery have no battery and list it as absent.
+func BatteryProblem(fp *Fingerprint) string {
+	if hasBatteryAPI(fp.Navigator.UserAgent) {
+		return ""
+	}
+	browser := header.GetBrowser(fp.Navigator.UserAgent)
+	switch {
+	case fp.Battery != nil:
+		return fmt.Sprintf("%s does not implement navigator.getBattery but battery values are set", browser)
+	case fp.AbsentAPIs != nil && !slices.Contains(fp.AbsentAPIs, "navigator.getBattery"):
+		return fmt.Sprintf("%s does not implement navigator.getBattery but it is not listed as absent", browser)
+	}
+	return ""
+}
+
+// applyBatteryAPI removes the battery of browsers without navigator.getBattery and lists the API as
+// absent, so that injectors delete it, see BatteryProblem.
+func applyBatteryAPI(fp *Fingerprint) {
+	if hasBatteryAPI(fp.Navigator.UserAgent) {
+		return
+	}
+	fp.Battery = nil
+	if !slices.Contains(fp.AbsentAPIs, "navigator.getBattery") {
+		fp.AbsentAPIs = append(fp.AbsentAPIs, "navigator.getBattery")
+	}
+}
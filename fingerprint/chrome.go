@@ -0,0 +1,74 @@
+package fingerprint
+
+import (
+	"fmt"
+	"slices"
+
+	"fingerprint-go/header"
+)
+
+// ChromeObject describes the window.chrome object of Chromium browsers by the members pages can probe.
+// Headless Chrome and automation frameworks lack some of them, so injectors recreate the ones set.
+type ChromeObject struct {
+	// App is set when window.chrome.app is present.
+	App bool `json:"app"`
+	// CSI is set when window.chrome.csi() is present.
+	CSI bool `json:"csi"`
+	// LoadTimes is set when window.chrome.loadTimes() is present.
+	LoadTimes bool `json:"loadTimes"`
+}
+
+// desktopChromeObject is the window.chrome of Chrome and Edge on the desktop.
+var desktopChromeObject = ChromeObject{App: true, CSI: true, LoadTimes: true}
+
+// vendorFlavorsRule returns the vendorFlavors and window.chrome of the browser of userAgent. ok is
+// false when the browser is not covered by the rules: Chromium on mobile devices, WebViews and Electron
+// apps keep their sampled values.
+func vendorFlavorsRule(userAgent string) (vendorFlavors []string, chrome *ChromeObject, ok bool) {
+	switch header.GetBrowser(userAgent) {
+	case "firefox", "safari":
+		return []string{}, nil, true
+	case "chrome", "edge":
+		if header.GetOperatingSystem(userAgent) == "ios" {
+			return []string{}, nil, true
+		}
+		if header.GetDevice(userAgent) == "mobile" || header.IsWebView(userAgent) || header.IsElectron(userAgent) {
+			return nil, nil, false
+		}
+		chrome := desktopChromeObject
+		return []string{"chrome"}, &chrome, true
+	}
+	return nil, nil, false
+}
+
+// VendorFlavorsProblem describes why navigator vendor flavors or window.chrome of fp do not match its
+// browser, or returns "" when they do: Chrome and Edge on the desktop have the "chrome" flavor and the
+// full window.chrome, Firefox, Safari and browsers on iOS have neither.
+func VendorFlavorsProblem(fp *Fingerprint) string {
+	vendorFlavors, chrome, ok := vendorFlavorsRule(fp.Navigator.UserAgent)
+	if !ok {
+		return ""
+	}
+	browser := header.GetBrowser(fp.Navigator.UserAgent)
+	if actual := fp.Navigator.ExtraProperties.VendorFlavors; !slices.Equal(actual, vendorFlavors) {
+		return fmt.Sprintf("%s has the vendor flavors %q, not %q", browser, vendorFlavors, actual)
+	}
+	switch {
+	case chrome == nil && fp.Chrome != nil:
+		return fmt.Sprintf("%s has no window.chrome", browser)
+	case chrome != nil && fp.Chrome != nil && *fp.Chrome != *chrome:
+		return fmt.Sprintf("window.chrome of %s has app, csi and loadTimes", browser)
+	}
+	return ""
+}
+
+// applyVendorFlavors sets the vendor flavors and window.chrome of fp from its browser, see
+// VendorFlavorsProblem.
+func applyVendorFlavors(fp *Fingerprint) {
+	vendorFlavors, chrome, ok := vendorFlavorsRule(fp.Navigator.UserAgent)
+	if !ok {
+		return
+	}
+	fp.Navigator.ExtraProperties.VendorFlavors = vendorFlavors
+	fp.Chrome = chrome
+}
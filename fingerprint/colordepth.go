@@ -0,0 +1,29 @@
+package fingerprint
+
+import (
+	"fingerprint-go/header"
+)
+
+// applyColorDepth makes the color depth and HDR support of the screen consistent with the platform,
+// as the dataset samples them independently: HDR displays report a color depth of 30, wide color
+// depths are otherwise only reported by high density Mac displays, Linux browsers do not report HDR
+// and the pixel depth always equals the color depth. noHDR turns HDR support off.
+func applyColorDepth(fp *Fingerprint, noHDR bool) {
+	screen := &fp.Screen
+	if screen.ColorDepth <= 0 {
+		return
+	}
+	operatingSystem := header.GetOperatingSystem(fp.Navigator.UserAgent)
+	if noHDR || operatingSystem == "linux" {
+		screen.HasHDR = false
+	}
+
+	switch {
+	case screen.HasHDR && (operatingSystem == "macos" || operatingSystem == "windows"):
+		screen.ColorDepth = 30
+	case operatingSystem == "macos" && screen.DevicePixelRatio >= 2:
+	default:
+		screen.ColorDepth = 24
+	}
+	screen.PixelDepth = screen.ColorDepth
+}
@@ -1,6 +1,11 @@
 package fingerprint
 
+import (
+	"fingerprint-go/internal/constants"
+)
+
+// The dataset constants are also available from the dataset package.
 const (
-	STRINGIFIED_PREFIX          string = "*STRINGIFIED*"
-	MISSING_VALUE_DATASET_TOKEN string = "*MISSING_VALUE*"
+	STRINGIFIED_PREFIX          string = constants.StringifiedPrefix
+	MISSING_VALUE_DATASET_TOKEN string = constants.MissingValueDatasetToken
 )
@@ -0,0 +1,30 @@
+package fingerprint
+
+import (
+	"fingerprint-go/header"
+)
+
+// electronWindowSizes are common default window sizes of Electron apps, the first being Electron's own
+// default for new windows.
+var electronWindowSizes = [][2]float64{{800, 600}, {1024, 768}, {1200, 800}, {1280, 800}, {1440, 900}}
+
+// applyElectron adapts a Chrome fingerprint to an Electron app: the app window has no browser UI, is
+// not maximized and Electron does not expose user agent client hints. Without a configured size, the
+// window size is derived from the app, like an app opens its windows at the same size.
+func applyElectron(fp *Fingerprint, app *header.ElectronApp) {
+	fp.Navigator.UserAgentData = UserAgentData{}
+
+	width, height := app.WindowWidth, app.WindowHeight
+	if width <= 0 || height <= 0 {
+		size := electronWindowSizes[int(stableFraction(app.Name+" "+app.Version)*float64(len(electronWindowSizes)))]
+		width, height = size[0], size[1]
+	}
+	if fp.Screen.Width > 0 {
+		width = min(width, fp.Screen.AvailWidth)
+		height = min(height, fp.Screen.AvailHeight)
+	}
+
+	fp.Screen.InnerWidth, fp.Screen.InnerHeight = width, height
+	fp.Screen.OuterWidth, fp.Screen.OuterHeight = width, height
+	fp.Screen.ClientWidth, fp.Screen.ClientHeight = width, height
+}
@@ -0,0 +1,241 @@
+package fingerprint
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+
+	"fingerprint-go/header"
+)
+
+// bundleManifestName is the name of the manifest of an export bundle.
+const bundleManifestName = "manifest.json"
+
+// BundleProfile is an identity of an export bundle: a fingerprint with its headers, the TLS and HTTP/2
+// profiles of its browser and its injector scripts, stored in a directory named after its ID.
+type BundleProfile struct {
+	ID          string                         `json:"id"`
+	Fingerprint *BrowserFingerprintWithHeaders `json:"-"`
+	// TLS and HTTP2 are nil when the browser has no known profile.
+	TLS          *header.TLSProfile    `json:"-"`
+	HTTP2        *header.HTTP2Settings `json:"-"`
+	InitScript   string                `json:"-"`
+	WorkerScript string                `json:"-"`
+}
+
+// bundleManifest lists the profiles of an export bundle.
+type bundleManifest struct {
+	SchemaVersion int                   `json:"schemaVersion"`
+	Profiles      []bundleManifestEntry `json:"profiles"`
+}
+
+type bundleManifestEntry struct {
+	ID              string `json:"id"`
+	Browser         string `json:"browser,omitempty"`
+	OperatingSystem string `json:"operatingSystem,omitempty"`
+	Device          string `json:"device,omitempty"`
+}
+
+// NewBundleProfile builds the profile of fp, with scripts built with scriptOptions.
+func NewBundleProfile(fp *BrowserFingerprintWithHeaders, scriptOptions *InitScriptOptions) (*BundleProfile, error) {
+	profile := &BundleProfile{ID: fp.Fingerprint.ID(), Fingerprint: fp}
+	var err error
+	if profile.InitScript, err = InitScript(&fp.Fingerprint, scriptOptions); err != nil {
+		return nil, err
+	}
+	if profile.WorkerScript, err = WorkerScript(&fp.Fingerprint, scriptOptions); err != nil {
+		return nil, err
+	}
+	if fp.Info != nil {
+		if tls, ok := fp.Info.TLSProfile(); ok {
+			profile.TLS = tls
+		}
+		if settings, ok := header.HTTP2SettingsFor(fp.Info.Browser); ok {
+			profile.HTTP2 = &settings
+		}
+	}
+	return profile, nil
+}
+
+// bundleSampleAttempts is how many fingerprints ExportBundle samples per profile at most, as identical
+// samples are skipped.
+const bundleSampleAttempts = 10
+
+// ExportBundle generates count distinct fingerprints with options and writes them to w as a zip archive,
+// see WriteBundle, for identities provisioned offline and distributed to worker nodes. Identical samples,
+// which are likely under tight constraints, are sampled again.
+func (g *FingerprintGenerator) ExportBundle(w io.Writer, count int, options *FingerprintGeneratorOptions, scriptOptions *InitScriptOptions) error {
+	profiles := make([]*BundleProfile, 0, count)
+	seen := make(map[string]bool, count)
+	for attempts := 0; len(profiles) < count; attempts++ {
+		if attempts == count*bundleSampleAttempts {
+			return fmt.Errorf("Only %d distinct fingerprints could be generated for the options, not %d.", len(profiles), count)
+		}
+		fp, err := g.GetFingerprint(options, nil)
+		if err != nil {
+			return err
+		}
+		id := fp.Fingerprint.ID()
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		profile, err := NewBundleProfile(fp, scriptOptions)
+		if err != nil {
+			return err
+		}
+		profiles = append(profiles, profile)
+	}
+	return WriteBundle(w, profiles)
+}
+
+// WriteBundle writes profiles to w as a zip archive holding a manifest.json listing them and, per
+// profile, a directory named after its ID with fingerprint.json, tls.json, http2.json, init.js and
+// worker.js. Read it with ReadBundle. The IDs of profiles must be distinct.
+func WriteBundle(w io.Writer, profiles []*BundleProfile) error {
+	ids := make(map[string]bool, len(profiles))
+	for _, profile := range profiles {
+		if ids[profile.ID] {
+			return fmt.Errorf("The bundle can not hold the profile %q twice.", profile.ID)
+		}
+		ids[profile.ID] = true
+	}
+
+	archive := zip.NewWriter(w)
+	writeFile := func(name string, content []byte) error {
+		f, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(content)
+		return err
+	}
+	writeJSON := func(name string, value any) error {
+		b, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFile(name, b)
+	}
+
+	manifest := bundleManifest{SchemaVersion: SchemaVersion, Profiles: make([]bundleManifestEntry, 0, len(profiles))}
+	for _, profile := range profiles {
+		entry := bundleManifestEntry{ID: profile.ID}
+		if info := profile.Fingerprint.Info; info != nil {
+			entry.Browser, entry.OperatingSystem, entry.Device = info.Browser, info.OperatingSystem, info.Device
+		}
+		manifest.Profiles = append(manifest.Profiles, entry)
+
+		if err := writeJSON(path.Join(profile.ID, "fingerprint.json"), profile.Fingerprint); err != nil {
+			return fmt.Errorf("The bundle could not be written: %w", err)
+		}
+		if profile.TLS != nil {
+			if err := writeJSON(path.Join(profile.ID, "tls.json"), profile.TLS); err != nil {
+				return fmt.Errorf("The bundle could not be written: %w", err)
+			}
+		}
+		if profile.HTTP2 != nil {
+			if err := writeJSON(path.Join(profile.ID, "http2.json"), profile.HTTP2); err != nil {
+				return fmt.Errorf("The bundle could not be written: %w", err)
+			}
+		}
+		if err := writeFile(path.Join(profile.ID, "init.js"), []byte(profile.InitScript)); err != nil {
+			return fmt.Errorf("The bundle could not be written: %w", err)
+		}
+		if err := writeFile(path.Join(profile.ID, "worker.js"), []byte(profile.WorkerScript)); err != nil {
+			return fmt.Errorf("The bundle could not be written: %w", err)
+		}
+	}
+	if err := writeJSON(bundleManifestName, manifest); err != nil {
+		return fmt.Errorf("The bundle could not be written: %w", err)
+	}
+	return archive.Close()
+}
+
+// ReadBundle reads the profiles of an archive written by WriteBundle. Fingerprints of older schema
+// versions are migrated, see UnmarshalFingerprint.
+func ReadBundle(r io.ReaderAt, size int64) ([]*BundleProfile, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("The bundle could not be read: %w", err)
+	}
+	readFile := func(name string) ([]byte, bool, error) {
+		f, err := archive.Open(name)
+		if err != nil {
+			return nil, false, nil
+		}
+		defer f.Close()
+		b, err := io.ReadAll(f)
+		return b, true, err
+	}
+
+	data, ok, err := readFile(bundleManifestName)
+	if err != nil || !ok {
+		return nil, fmt.Errorf("The bundle has no readable %s.", bundleManifestName)
+	}
+	var manifest bundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("The bundle manifest could not be parsed: %w", err)
+	}
+
+	profiles := make([]*BundleProfile, 0, len(manifest.Profiles))
+	for _, entry := range manifest.Profiles {
+		profile := &BundleProfile{ID: entry.ID}
+		data, ok, err := readFile(path.Join(entry.ID, "fingerprint.json"))
+		if err != nil || !ok {
+			return nil, fmt.Errorf("The bundle has no readable fingerprint for the profile %q.", entry.ID)
+		}
+		if profile.Fingerprint, err = UnmarshalFingerprint(data); err != nil {
+			return nil, err
+		}
+		if data, ok, err = readFile(path.Join(entry.ID, "tls.json")); err == nil && ok {
+			profile.TLS = &header.TLSProfile{}
+			err = json.Unmarshal(data, profile.TLS)
+		}
+		if err == nil {
+			if data, ok, err = readFile(path.Join(entry.ID, "http2.json")); err == nil && ok {
+				profile.HTTP2 = &header.HTTP2Settings{}
+				err = json.Unmarshal(data, profile.HTTP2)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("The network profiles of the profile %q could not be read: %w", entry.ID, err)
+		}
+		if data, _, err = readFile(path.Join(entry.ID, "init.js")); err == nil {
+			profile.InitScript = string(data)
+		}
+		if data, _, err = readFile(path.Join(entry.ID, "worker.js")); err == nil {
+			profile.WorkerScript = string(data)
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// WriteSealedBundle writes profiles to w as a bundle, see WriteBundle, encrypted with AES-GCM under the
+// key of provider, for bundles kept at rest or shipped through untrusted storage. Read it with
+// ReadSealedBundle.
+func WriteSealedBundle(w io.Writer, profiles []*BundleProfile, provider header.KeyProvider) error {
+	var bundle bytes.Buffer
+	if err := WriteBundle(&bundle, profiles); err != nil {
+		return err
+	}
+	sealed, err := header.SealWithKey(bundle.Bytes(), provider)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(sealed)
+	return err
+}
+
+// ReadSealedBundle reads the profiles of a bundle written by WriteSealedBundle with the key of provider.
+func ReadSealedBundle(sealed []byte, provider header.KeyProvider) ([]*BundleProfile, error) {
+	bundle, err := header.OpenWithKey(sealed, provider)
+	if err != nil {
+		return nil, err
+	}
+	return ReadBundle(bytes.NewReader(bundle), int64(len(bundle)))
+}
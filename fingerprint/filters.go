@@ -0,0 +1,104 @@
+package fingerprint
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fingerprint-go/header"
+	"fingerprint-go/network"
+)
+
+// ValueFilter reports whether a dataset value of a fingerprint attribute should never be generated.
+type ValueFilter func(value string) bool
+
+// ContainsAny returns a ValueFilter excluding values that contain one of parts, e.g. ContainsAny("SwiftShader", "llvmpipe")
+// for the videoCard attribute.
+func ContainsAny(parts ...string) ValueFilter {
+	return func(value string) bool {
+		for _, part := range parts {
+			if strings.Contains(value, part) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NumberBelow returns a ValueFilter excluding numeric values lower than min, e.g. NumberBelow(4) for hardwareConcurrency.
+// Non-numeric values are kept.
+func NumberBelow(min float64) ValueFilter {
+	return func(value string) bool {
+		number, err := strconv.ParseFloat(value, 64)
+		return err == nil && number < min
+	}
+}
+
+// platformVersionOutside returns a ValueFilter excluding userAgentData values whose platformVersion is
+// outside the version range of their operating system.
+func platformVersionOutside(operatingSystems []header.OperatingSystemSpecification) ValueFilter {
+	return func(value string) bool {
+		var data UserAgentData
+		if ParseStringified(value, &data) != nil {
+			return false
+		}
+		operatingSystem := header.GetOperatingSystemFromPlatform(data.Platform)
+		version, ok := header.GetPlatformOperatingSystemVersion(operatingSystem, data.PlatformVersion)
+		return ok && !header.OperatingSystemVersionAllowed(operatingSystems, operatingSystem, version)
+	}
+}
+
+// resolveBannedValues combines BannedValues with the values of each attribute excluded by ExcludeValues.
+func (g *FingerprintGenerator) resolveBannedValues(optToUse *FingerprintGeneratorOptions) (map[string][]string, error) {
+	resolved := g.HeaderGenerator.ResolveOptions(optToUse.HeaderGeneratorOptions)
+	operatingSystems := header.PrepareOperatingSystems(&resolved)
+	operatingSystemVersions := slices.ContainsFunc(operatingSystems, func(os header.OperatingSystemSpecification) bool {
+		return os.MinVersion != 0 || os.MaxVersion != 0
+	})
+	if len(optToUse.BannedValues) == 0 && len(optToUse.ExcludeValues) == 0 && !optToUse.ExcludeVirtualGPUs &&
+		optToUse.MinCores == 0 && optToUse.MinMemoryGB == 0 && !operatingSystemVersions {
+		return nil, nil
+	}
+
+	banned := make(map[string][]string)
+	for nodeName, values := range optToUse.BannedValues {
+		if _, ok := g.fingerprintGeneratorNetwork.NodesByName[nodeName]; !ok {
+			return nil, fmt.Errorf("The fingerprint network has no attribute named %q.", nodeName)
+		}
+		banned[nodeName] = append(banned[nodeName], values...)
+	}
+
+	excludeValues := make(map[string][]ValueFilter)
+	for nodeName, filter := range optToUse.ExcludeValues {
+		excludeValues[nodeName] = append(excludeValues[nodeName], filter)
+	}
+	if optToUse.ExcludeVirtualGPUs {
+		excludeValues["videoCard"] = append(excludeValues["videoCard"], ContainsAny(network.VirtualWebGLRendererParts...))
+	}
+	if optToUse.MinCores > 0 {
+		excludeValues["hardwareConcurrency"] = append(excludeValues["hardwareConcurrency"], NumberBelow(float64(optToUse.MinCores)))
+	}
+	if optToUse.MinMemoryGB > 0 {
+		excludeValues["deviceMemory"] = append(excludeValues["deviceMemory"], NumberBelow(optToUse.MinMemoryGB))
+	}
+	if operatingSystemVersions {
+		excludeValues["userAgentData"] = append(excludeValues["userAgentData"], platformVersionOutside(operatingSystems))
+	}
+
+	for nodeName, filters := range excludeValues {
+		node, ok := g.fingerprintGeneratorNetwork.NodesByName[nodeName]
+		if !ok {
+			return nil, fmt.Errorf("The fingerprint network has no attribute named %q.", nodeName)
+		}
+		for _, value := range node.Definition.PossibleValues {
+			for _, filter := range filters {
+				if filter(value) && !slices.Contains(banned[nodeName], value) {
+					banned[nodeName] = append(banned[nodeName], value)
+				}
+			}
+		}
+	}
+
+	return banned, nil
+}
@@ -0,0 +1,83 @@
+package fingerprint
+
+import (
+	"slices"
+	"strings"
+
+	"fingerprint-go/header"
+)
+
+// localeFonts lists the system fonts that come with the language support of each operating system,
+// keyed by locale or language and then by operating system. Systems set up for these languages have the
+// fonts installed, so font probing finds them.
+var localeFonts = map[string]map[string][]string{
+	"ja": {
+		"windows": {"Yu Gothic", "Yu Mincho", "Meiryo", "MS Gothic", "MS PGothic", "MS Mincho"},
+		"macos":   {"Hiragino Sans", "Hiragino Kaku Gothic ProN", "Hiragino Mincho ProN", "Osaka"},
+		"linux":   {"Noto Sans CJK JP", "Noto Serif CJK JP"},
+	},
+	"zh": {
+		"windows": {"Microsoft YaHei", "SimSun", "SimHei", "DengXian", "KaiTi"},
+		"macos":   {"PingFang SC", "Heiti SC", "STHeiti", "Songti SC"},
+		"linux":   {"Noto Sans CJK SC", "Noto Serif CJK SC"},
+	},
+	"zh-tw": {
+		"windows": {"Microsoft JhengHei", "PMingLiU", "MingLiU"},
+		"macos":   {"PingFang TC", "Heiti TC", "Songti TC"},
+		"linux":   {"Noto Sans CJK TC", "Noto Serif CJK TC"},
+	},
+	"zh-hk": {
+		"windows": {"Microsoft JhengHei", "PMingLiU", "MingLiU_HKSCS"},
+		"macos":   {"PingFang HK", "Heiti TC"},
+		"linux":   {"Noto Sans CJK HK"},
+	},
+	"ko": {
+		"windows": {"Malgun Gothic", "Gulim", "Dotum", "Batang"},
+		"macos":   {"Apple SD Gothic Neo", "AppleGothic", "AppleMyungjo"},
+		"linux":   {"Noto Sans CJK KR", "Noto Serif CJK KR"},
+	},
+	"th": {
+		"windows": {"Leelawadee UI", "Leelawadee", "Angsana New", "Cordia New"},
+		"macos":   {"Thonburi", "Sukhumvit Set"},
+	},
+	"ar": {
+		"windows": {"Arabic Typesetting", "Sakkal Majalla", "Simplified Arabic", "Traditional Arabic"},
+		"macos":   {"Geeza Pro", "Al Nile", "Baghdad"},
+	},
+	"he": {
+		"windows": {"David", "Miriam", "FrankRuehl"},
+		"macos":   {"Arial Hebrew", "New Peninim MT"},
+	},
+	"hi": {
+		"windows": {"Nirmala UI", "Mangal", "Aparajita"},
+		"macos":   {"Kohinoor Devanagari", "ITF Devanagari"},
+	},
+}
+
+// addLocaleFonts adds the system fonts of the fingerprint's languages to its font list, so that a
+// Japanese or Chinese browser also has the fonts a Japanese or Chinese system would.
+func addLocaleFonts(fp *Fingerprint) {
+	if fp.Fonts == nil {
+		return
+	}
+	operatingSystem := header.GetOperatingSystem(fp.Navigator.UserAgent)
+
+	for _, language := range fp.Navigator.Languages {
+		for _, font := range fontsForLocale(language, operatingSystem) {
+			if !slices.Contains(fp.Fonts, font) {
+				fp.Fonts = append(fp.Fonts, font)
+			}
+		}
+	}
+}
+
+// fontsForLocale looks locale up by its full tag first, so zh-TW gets traditional Chinese fonts, and
+// then by its language.
+func fontsForLocale(locale string, operatingSystem string) []string {
+	locale = strings.ToLower(locale)
+	if fonts, ok := localeFonts[locale]; ok {
+		return fonts[operatingSystem]
+	}
+	language, _, _ := strings.Cut(locale, "-")
+	return localeFonts[language][operatingSystem]
+}
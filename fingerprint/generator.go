@@ -3,9 +3,13 @@ package fingerprint
 import (
 	"encoding/json"
 	"fmt"
-	"path/filepath"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
 	"strconv"
-	"strings"
+	"sync"
+	"time"
 
 	"fingerprint-go/bayesian"
 	"fingerprint-go/header"
@@ -97,11 +101,47 @@ type Fingerprint struct {
 	Fonts             []string             `json:"fonts"`
 	MockWebRTC        bool                 `json:"mockWebRTC"`
 	Slim              bool                 `json:"slim,omitempty"`
+	Intl              *IntlFingerprint     `json:"intl,omitempty"`
+	// AbsentAPIs are the APIs of other engines the browser does not have, e.g. "window.chrome" on
+	// Safari, as "navigator.name" or "window.name". Injectors remove them.
+	AbsentAPIs []string `json:"absentAPIs,omitempty"`
+	// Storage is how the browser restricts cookies and storage, set for WebKit browsers.
+	Storage *StorageFingerprint `json:"storage,omitempty"`
+	// Chrome describes window.chrome, set for Chrome and Edge on the desktop.
+	Chrome *ChromeObject `json:"chrome,omitempty"`
+}
+
+// StorageFingerprint describes the storage restrictions of a browser, such as those of Safari's
+// Intelligent Tracking Prevention, which the cookie and storage handling of the identity should follow.
+type StorageFingerprint struct {
+	// ThirdPartyCookies reports whether cookies are sent in third-party contexts.
+	ThirdPartyCookies bool `json:"thirdPartyCookies"`
+	// Partitioned reports whether the storage of third-party frames is partitioned by top-level site.
+	Partitioned bool `json:"partitioned"`
+	// ScriptStorageDays caps the lifetime of cookies set by scripts and of script-writable storage,
+	// in days of browser use without interaction with the site. 0 means no cap.
+	ScriptStorageDays int `json:"scriptStorageDays,omitempty"`
+}
+
+// Relaxation describes a constraint that was loosened so that a fingerprint could be generated.
+type Relaxation struct {
+	Constraint string `json:"constraint"`
+	Reason     string `json:"reason"`
 }
 
 type BrowserFingerprintWithHeaders struct {
-	Headers     map[string]string `json:"headers"`
-	Fingerprint Fingerprint       `json:"fingerprint"`
+	// SchemaVersion is the version of the JSON format the value was written in, see MigrateJSON.
+	SchemaVersion int               `json:"schemaVersion,omitempty"`
+	Headers       map[string]string `json:"headers"`
+	Fingerprint   Fingerprint       `json:"fingerprint"`
+	Relaxations   []Relaxation      `json:"relaxations,omitempty"`
+	// Info describes the browser identity that was sampled.
+	Info *header.GenerationInfo `json:"info,omitempty"`
+	// Audit lists the values typical of headless and automated environments, see AuditFingerprint.
+	Audit []AuditFinding `json:"audit,omitempty"`
+	// Partial is set when the Timeout of the options passed and AllowPartial returned the best
+	// fingerprint found so far, or one sampled for the headers alone, instead of failing.
+	Partial bool `json:"partial,omitempty"`
 }
 
 type FingerprintScreenOptions struct {
@@ -116,21 +156,78 @@ type FingerprintGeneratorOptions struct {
 	Screen     *FingerprintScreenOptions
 	MockWebRTC bool
 	Slim       bool
+	// UserAgentFallback lets GetFingerprintForUserAgent and GetFingerprintForHeaders use the nearest
+	// version of the same browser major version when the requested user agent is not in the dataset.
+	UserAgentFallback bool
+	// Constraints restricts fingerprint attributes to the given values, keyed by the name of the node
+	// in the fingerprint network (e.g. "videoCard" or "hardwareConcurrency"). Values use the dataset
+	// encoding, so object values carry the STRINGIFIED_PREFIX.
+	Constraints map[string][]string
+	// SamplingMethod selects how the fingerprint network honours the constraints. LikelihoodWeighting
+	// follows the dataset distribution more closely under tight constraint sets.
+	SamplingMethod bayesian.SamplingMethod
+	// BannedValues lists, per fingerprint attribute, dataset values that are never generated.
+	// Banned "userAgent" values are excluded from the generated headers as well.
+	BannedValues map[string][]string
+	// ExcludeValues bans every dataset value of an attribute for which the filter returns true.
+	ExcludeValues map[string]ValueFilter
+	// ExcludeVirtualGPUs bans video cards of virtual machines, emulators and software renderers.
+	ExcludeVirtualGPUs bool
+	// MinCores excludes fingerprints with fewer logical cores (navigator.hardwareConcurrency).
+	MinCores int
+	// MinMemoryGB excludes fingerprints reporting less navigator.deviceMemory. Browsers that do not
+	// expose deviceMemory are not affected.
+	MinMemoryGB float64
+	// Model restricts generation to Android and reports the given device model from AndroidModels in
+	// the user agent, navigator.userAgentData.model and the screen metrics.
+	Model string
+	// TimeZone is the IANA time zone reported through Intl, e.g. "Europe/Berlin". By default it is
+	// derived from the region of the first locale.
+	TimeZone string
+	// AvoidSuspiciousValues resamples fingerprints that AuditFingerprint flags. When every attempt is
+	// flagged, the last one is returned with its findings.
+	AvoidSuspiciousValues bool
+	// ScreenJitter moves the screen offsets within the buckets of data files built with bucketed
+	// screens and resizes windows that are not maximized by a few pixels, so that fingerprints of the
+	// same screen record differ.
+	ScreenJitter bool
+	// ModelWindowChrome derives the outer window size of desktop browsers from the inner size and the
+	// browser's window chrome, see WindowChromeSize, instead of keeping the dataset's combination.
+	ModelWindowChrome bool
+	// NoHDR generates screens without HDR support (screen.hasHDR false and a color depth of 24 where
+	// the display would report 30 for HDR).
+	NoHDR bool
+
+	// screenSeed replays the screen jitter of a trace.
+	screenSeed uint64
 }
 
 type FingerprintGenerator struct {
 	*header.HeaderGenerator
+	// Debug keeps the sampling trace of the last generation, see LastTrace.
+	Debug bool
+
 	fingerprintGeneratorNetwork *bayesian.Network
 	fingerprintGlobalOptions    *FingerprintGeneratorOptions
+	networkAsset                header.DataAsset
+
+	traceMu   sync.Mutex
+	lastTrace *GenerationTrace
 }
 
 func NewFingerprintGenerator(options *FingerprintGeneratorOptions, dataFilesPath string) (*FingerprintGenerator, error) {
+	return NewFingerprintGeneratorFS(options, os.DirFS(dataFilesPath))
+}
+
+// NewFingerprintGeneratorFS creates a fingerprint generator reading its data files from fsys, e.g. an
+// embed.FS, so it can run without a file system such as under WebAssembly.
+func NewFingerprintGeneratorFS(options *FingerprintGeneratorOptions, dataFiles fs.FS) (*FingerprintGenerator, error) {
 	var headerOpts *header.HeaderGeneratorOptions
 	if options != nil {
 		headerOpts = options.HeaderGeneratorOptions
 	}
 
-	headerGen, err := header.NewHeaderGenerator(headerOpts, dataFilesPath)
+	headerGen, err := header.NewHeaderGeneratorFS(headerOpts, dataFiles)
 	if err != nil {
 		return nil, err
 	}
@@ -143,97 +240,184 @@ func NewFingerprintGenerator(options *FingerprintGeneratorOptions, dataFilesPath
 		gen.fingerprintGlobalOptions = &FingerprintGeneratorOptions{}
 	} else {
 		gen.fingerprintGlobalOptions = &FingerprintGeneratorOptions{
-			Screen:     options.Screen,
-			MockWebRTC: options.MockWebRTC,
-			Slim:       options.Slim,
+			Screen:                options.Screen,
+			MockWebRTC:            options.MockWebRTC,
+			Slim:                  options.Slim,
+			UserAgentFallback:     options.UserAgentFallback,
+			Constraints:           options.Constraints,
+			SamplingMethod:        options.SamplingMethod,
+			BannedValues:          options.BannedValues,
+			ExcludeValues:         options.ExcludeValues,
+			ExcludeVirtualGPUs:    options.ExcludeVirtualGPUs,
+			MinCores:              options.MinCores,
+			MinMemoryGB:           options.MinMemoryGB,
+			Model:                 options.Model,
+			TimeZone:              options.TimeZone,
+			AvoidSuspiciousValues: options.AvoidSuspiciousValues,
+			ScreenJitter:          options.ScreenJitter,
+			ModelWindowChrome:     options.ModelWindowChrome,
+			NoHDR:                 options.NoHDR,
 		}
 	}
 
-	gen.fingerprintGeneratorNetwork = bayesian.NewNetwork(filepath.Join(dataFilesPath, "fingerprint-network-definition.zip"))
+	gen.fingerprintGeneratorNetwork, gen.networkAsset = header.NetworkAsset(dataFiles, "fingerprint-network-definition.zip")
+	if err := (header.DataHealth{Assets: []header.DataAsset{gen.networkAsset}}).Check(headerGen.ResolveOptions(nil).DataIntegrity); err != nil {
+		return nil, err
+	}
 
 	return gen, nil
 }
 
+// Health reports which data files the generator loaded, including the fingerprint network.
+func (g *FingerprintGenerator) Health() header.DataHealth {
+	health := g.HeaderGenerator.Health()
+	health.Assets = append(health.Assets, g.networkAsset)
+	return health
+}
+
 func (g *FingerprintGenerator) GetFingerprint(options *FingerprintGeneratorOptions, requestDependentHeaders map[string]string) (*BrowserFingerprintWithHeaders, error) {
-	filteredValues := make(map[string][]string)
+	return g.generateFingerprint(options, requestDependentHeaders, nil, nil)
+}
 
-	optToUse := &FingerprintGeneratorOptions{
-		Screen:     g.fingerprintGlobalOptions.Screen,
-		MockWebRTC: g.fingerprintGlobalOptions.MockWebRTC,
-		Slim:       g.fingerprintGlobalOptions.Slim,
+// generateFingerprint samples a fingerprint, optionally constrained on the given user agents. When
+// fixedHeaders is set, no headers are generated and the fingerprint is sampled for those headers instead.
+// A panic while sampling is returned as a *bayesian.SamplingPanic, and exceeding the Timeout of the
+// options header.ErrTimeout unless AllowPartial is set.
+func (g *FingerprintGenerator) generateFingerprint(options *FingerprintGeneratorOptions, requestDependentHeaders map[string]string, userAgents []string, fixedHeaders map[string]string) (result *BrowserFingerprintWithHeaders, err error) {
+	var trace *GenerationTrace
+	if g.Debug {
+		trace = &GenerationTrace{}
+		defer func() {
+			if err != nil {
+				trace.Error = err.Error()
+			}
+			g.storeTrace(trace)
+		}()
 	}
-	optToUse.HeaderGeneratorOptions = &header.HeaderGeneratorOptions{} // need to merge properly, simplify for now
+	started := time.Now()
+	defer func() { g.Metrics.ObserveFingerprintGeneration(time.Since(started), err) }()
+	defer bayesian.RecoverSampling(&err)
 
-	if options != nil {
-		if options.Screen != nil {
-			optToUse.Screen = options.Screen
+	optToUse := g.mergeOptions(options)
+	filteredValues, partialCSP, relaxations, err := g.prepareConstraints(optToUse)
+	if err != nil {
+		return nil, err
+	}
+	bannedValues, err := g.resolveBannedValues(optToUse)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAndroidModel(optToUse.Model); err != nil {
+		return nil, err
+	}
+	var screenSeed uint64
+	if optToUse.ScreenJitter {
+		screenSeed = newScreenSeed(optToUse)
+		if trace != nil {
+			trace.ScreenSeed = screenSeed
 		}
-		optToUse.MockWebRTC = options.MockWebRTC
-		optToUse.Slim = options.Slim
-		// merge header options if needed
-		optToUse.HeaderGeneratorOptions = options.HeaderGeneratorOptions
 	}
 
-	var partialCSP map[string][]string
-	if optToUse.Screen != nil {
-		extensiveScreen := true
-		if extensiveScreen {
-			var possibleScreens []string
-			if screenNode, ok := g.fingerprintGeneratorNetwork.NodesByName["screen"]; ok {
-				for _, screenString := range screenNode.Definition.PossibleValues {
-					if !strings.Contains(screenString, STRINGIFIED_PREFIX) {
-						continue
-					}
-					parts := strings.SplitN(screenString, STRINGIFIED_PREFIX, 2)
-					if len(parts) < 2 {
-						continue
-					}
-
-					var screen ScreenFingerprint
-					if err := json.Unmarshal([]byte(parts[1]), &screen); err == nil {
-						minW, maxW, minH, maxH := 0.0, 1e5, 0.0, 1e5
-						if optToUse.Screen.MinWidth != nil {
-							minW = *optToUse.Screen.MinWidth
-						}
-						if optToUse.Screen.MaxWidth != nil {
-							maxW = *optToUse.Screen.MaxWidth
-						}
-						if optToUse.Screen.MinHeight != nil {
-							minH = *optToUse.Screen.MinHeight
-						}
-						if optToUse.Screen.MaxHeight != nil {
-							maxH = *optToUse.Screen.MaxHeight
-						}
+	resolvedHeaderOptions := g.HeaderGenerator.ResolveOptions(optToUse.HeaderGeneratorOptions)
+	var deadline time.Time
+	if resolvedHeaderOptions.Timeout > 0 {
+		deadline = time.Now().Add(resolvedHeaderOptions.Timeout)
+	}
+	// partial is set once the deadline passed with AllowPartial. The fingerprint is then sampled for the
+	// headers alone, and suspicious is the best candidate found before, a complete fingerprint that was
+	// only discarded for its suspicious values.
+	partial := false
+	var suspicious *BrowserFingerprintWithHeaders
 
-						if screen.Width >= minW && screen.Width <= maxW && screen.Height >= minH && screen.Height <= maxH {
-							possibleScreens = append(possibleScreens, screenString)
-						}
-					}
-				}
-				filteredValues["screen"] = possibleScreens
+	for generateRetries := 0; generateRetries < 10; generateRetries++ {
+		if !partial && !deadline.IsZero() && !time.Now().Before(deadline) {
+			if !resolvedHeaderOptions.AllowPartial {
+				return nil, header.ErrTimeout
+			}
+			if suspicious != nil {
+				suspicious.Partial = true
+				return suspicious, nil
 			}
+			partial = true
 		}
 
-		closure, err := bayesian.GetConstraintClosure(g.fingerprintGeneratorNetwork, filteredValues)
-		if err != nil {
-			if optToUse.HeaderGeneratorOptions != nil && optToUse.HeaderGeneratorOptions.Strict {
-				return nil, err
-			}
-			delete(filteredValues, "screen")
-		} else {
-			partialCSP = closure
+		var attempt *GenerationAttempt
+		if trace != nil {
+			trace.Attempts = append(trace.Attempts, GenerationAttempt{})
+			attempt = &trace.Attempts[len(trace.Attempts)-1]
 		}
-	}
 
-	for generateRetries := 0; generateRetries < 10; generateRetries++ {
 		var userAgentValues []string
 		if partialCSP != nil && partialCSP["userAgent"] != nil {
 			userAgentValues = partialCSP["userAgent"]
 		}
+		if userAgents != nil {
+			if userAgentValues != nil {
+				userAgentValues = bayesian.ArrayIntersection(userAgentValues, userAgents)
+			} else {
+				userAgentValues = userAgents
+			}
+			if len(userAgentValues) == 0 {
+				return nil, fmt.Errorf("The requested user agent is not compatible with the screen constraints.")
+			}
+		}
+		if bannedUserAgents := bannedValues["userAgent"]; len(bannedUserAgents) > 0 {
+			if userAgentValues == nil {
+				userAgentValues = g.PossibleValues("userAgent")
+			}
+			userAgentValues = slices.DeleteFunc(slices.Clone(userAgentValues), func(userAgent string) bool {
+				return slices.Contains(bannedUserAgents, userAgent)
+			})
+			if len(userAgentValues) == 0 {
+				return nil, fmt.Errorf("The current constraints are too restrictive. Every possible user agent is banned.")
+			}
+		}
+		if optToUse.Model != "" {
+			if userAgentValues == nil {
+				userAgentValues = g.PossibleValues("userAgent")
+			}
+			userAgentValues = slices.DeleteFunc(slices.Clone(userAgentValues), func(userAgent string) bool {
+				_, android := androidUserAgentModel(userAgent)
+				return !android
+			})
+			if len(userAgentValues) == 0 {
+				return nil, fmt.Errorf("The current constraints are too restrictive. No Android user agent is possible for the model %q.", optToUse.Model)
+			}
+		}
 
-		headers, err := g.HeaderGenerator.GetHeaders(optToUse.HeaderGeneratorOptions, requestDependentHeaders, userAgentValues)
-		if err != nil {
-			continue // retry or fallback
+		var headers map[string]string
+		var info *header.GenerationInfo
+		if fixedHeaders != nil {
+			headers = make(map[string]string, len(fixedHeaders))
+			for k, v := range fixedHeaders {
+				headers[k] = v
+			}
+			info = header.InfoFromHeaders(headers)
+		} else {
+			headerOptions := optToUse.HeaderGeneratorOptions
+			if attempt != nil || !deadline.IsZero() {
+				copied := header.HeaderGeneratorOptions{Strict: g.HeaderGenerator.ResolveOptions(nil).Strict}
+				if headerOptions != nil {
+					copied = *headerOptions
+				}
+				if attempt != nil {
+					attempt.Input = &bayesian.SamplingTrace{}
+					copied.Trace = attempt.Input
+				}
+				if !deadline.IsZero() {
+					// The headers share the deadline of the fingerprint, a passed one makes them partial at once.
+					copied.Timeout = max(time.Until(deadline), time.Nanosecond)
+				}
+				headerOptions = &copied
+			}
+
+			var err error
+			headers, info, err = g.HeaderGenerator.GetHeadersWithInfo(headerOptions, requestDependentHeaders, userAgentValues)
+			if err != nil {
+				attempt.discard("header generation failed: " + err.Error())
+				g.Metrics.AddRetry()
+				continue // retry or fallback
+			}
 		}
 
 		userAgent := ""
@@ -243,10 +427,33 @@ func (g *FingerprintGenerator) GetFingerprint(options *FingerprintGeneratorOptio
 			userAgent = ua
 		}
 
-		filteredValues["userAgent"] = []string{userAgent}
+		// WebView and Electron user agents are derived from Chrome ones, which the fingerprint network knows.
+		filteredValues["userAgent"] = []string{header.ChromeUserAgent(userAgent)}
 
-		fingerprint := g.fingerprintGeneratorNetwork.GenerateConsistentSampleWhenPossible(filteredValues)
+		var fingerprintTrace *bayesian.SamplingTrace
+		if attempt != nil {
+			fingerprintTrace = &bayesian.SamplingTrace{}
+			attempt.Fingerprint = fingerprintTrace
+		}
+		samplingOptions := &bayesian.SamplingOptions{
+			Method:       optToUse.SamplingMethod,
+			Temperature:  resolvedHeaderOptions.Temperature,
+			BannedValues: bannedValues,
+			Consistent:   consistentHardware,
+			Trace:        fingerprintTrace,
+			Deadline:     deadline,
+		}
+		constraints := filteredValues
+		if partial {
+			constraints = map[string][]string{"userAgent": filteredValues["userAgent"]}
+			samplingOptions.BannedValues = nil
+			samplingOptions.Consistent = nil
+			samplingOptions.Deadline = time.Time{}
+		}
+		fingerprint := g.fingerprintGeneratorNetwork.GenerateConsistentSample(constraints, samplingOptions)
 		if len(fingerprint) == 0 {
+			attempt.discard("no fingerprint is consistent with the headers and constraints")
+			g.Metrics.AddRetry()
 			continue
 		}
 
@@ -254,9 +461,9 @@ func (g *FingerprintGenerator) GetFingerprint(options *FingerprintGeneratorOptio
 		for attribute, val := range fingerprint {
 			if val == MISSING_VALUE_DATASET_TOKEN {
 				fingerprintRaw[attribute] = nil
-			} else if strings.HasPrefix(val, STRINGIFIED_PREFIX) {
+			} else if IsStringified(val) {
 				var parsed any
-				if err := json.Unmarshal([]byte(val[len(STRINGIFIED_PREFIX):]), &parsed); err == nil {
+				if err := ParseStringified(val, &parsed); err == nil {
 					fingerprintRaw[attribute] = parsed
 				} else {
 					fingerprintRaw[attribute] = val
@@ -267,38 +474,200 @@ func (g *FingerprintGenerator) GetFingerprint(options *FingerprintGeneratorOptio
 		}
 
 		if fingerprintRaw["screen"] == nil {
+			attempt.discard("the fingerprint has no screen")
+			g.Metrics.AddRetry()
 			continue
 		}
 
-		acceptLanguageHeaderValue := ""
-		if al, ok := headers["Accept-Language"]; ok {
-			acceptLanguageHeaderValue = al
-		} else if al, ok := headers["accept-language"]; ok {
-			acceptLanguageHeaderValue = al
-		}
+		fingerprintRaw["languages"] = info.Languages
 
-		var acceptedLanguages []string
-		for _, locale := range strings.Split(acceptLanguageHeaderValue, ",") {
-			localeParts := strings.Split(locale, ";")
-			if len(localeParts) > 0 {
-				acceptedLanguages = append(acceptedLanguages, strings.TrimSpace(localeParts[0]))
+		transformedFP := g.transformFingerprint(fingerprintRaw)
+		if chromeUserAgent := header.ChromeUserAgent(userAgent); chromeUserAgent != userAgent {
+			replaceUserAgent(&transformedFP, chromeUserAgent, userAgent)
+		}
+		if app := header.GetElectronApp(userAgent); app != nil {
+			if resolved := g.HeaderGenerator.ResolveOptions(optToUse.HeaderGeneratorOptions).Electron; resolved != nil {
+				app = resolved
 			}
+			applyElectron(&transformedFP, app)
 		}
-		fingerprintRaw["languages"] = acceptedLanguages
-
-		transformedFP := g.transformFingerprint(fingerprintRaw)
 		transformedFP.MockWebRTC = optToUse.MockWebRTC
 		transformedFP.Slim = optToUse.Slim
+		applyBrands(&transformedFP.Navigator.UserAgentData, transformedFP.Navigator.UserAgent)
+		applyAndroidModel(&transformedFP, headers, optToUse.Model, fixedHeaders == nil)
+		applyAvailArea(&transformedFP)
+		applyColorDepth(&transformedFP, optToUse.NoHDR)
+		applyWebKitQuirks(&transformedFP)
+		applyPDFViewer(&transformedFP)
+		applyVendorFlavors(&transformedFP)
+		applyBatteryAPI(&transformedFP)
+		if optToUse.ModelWindowChrome && header.GetElectronApp(userAgent) == nil {
+			applyWindowChrome(&transformedFP)
+		}
+		if optToUse.ScreenJitter {
+			jitterScreenOffsets(&transformedFP.Screen, screenSeed)
+			jitterWindow(&transformedFP.Screen, screenSeed)
+		}
+		addLocaleFonts(&transformedFP)
+		transformedFP.Intl = intlForLocale(transformedFP.Navigator.Language, optToUse.TimeZone)
+
+		audit := AuditFingerprint(&transformedFP)
+		result := &BrowserFingerprintWithHeaders{
+			SchemaVersion: SchemaVersion,
+			Headers:       headers,
+			Fingerprint:   transformedFP,
+			Relaxations:   relaxations,
+			Info:          info,
+			Audit:         audit,
+			Partial:       partial || info.Partial,
+		}
+		if optToUse.AvoidSuspiciousValues && len(audit) > 0 && generateRetries < 9 && !partial {
+			attempt.discard(fmt.Sprintf("the fingerprint has %d suspicious values", len(audit)))
+			g.Metrics.AddRetry()
+			if suspicious == nil || len(audit) < len(suspicious.Audit) {
+				suspicious = result
+			}
+			continue
+		}
 
-		return &BrowserFingerprintWithHeaders{
-			Headers:     headers,
-			Fingerprint: transformedFP,
-		}, nil
+		if trace != nil {
+			trace.Headers = maps.Clone(headers)
+		}
+		return result, nil
 	}
 
 	return nil, fmt.Errorf("Failed to generate a consistent fingerprint after 10 attempts")
 }
 
+// mergeOptions overlays the per-call options on the generator's global options.
+func (g *FingerprintGenerator) mergeOptions(options *FingerprintGeneratorOptions) *FingerprintGeneratorOptions {
+	optToUse := &FingerprintGeneratorOptions{
+		Screen:                g.fingerprintGlobalOptions.Screen,
+		MockWebRTC:            g.fingerprintGlobalOptions.MockWebRTC,
+		Slim:                  g.fingerprintGlobalOptions.Slim,
+		UserAgentFallback:     g.fingerprintGlobalOptions.UserAgentFallback,
+		Constraints:           g.fingerprintGlobalOptions.Constraints,
+		SamplingMethod:        g.fingerprintGlobalOptions.SamplingMethod,
+		BannedValues:          g.fingerprintGlobalOptions.BannedValues,
+		ExcludeValues:         g.fingerprintGlobalOptions.ExcludeValues,
+		ExcludeVirtualGPUs:    g.fingerprintGlobalOptions.ExcludeVirtualGPUs,
+		MinCores:              g.fingerprintGlobalOptions.MinCores,
+		MinMemoryGB:           g.fingerprintGlobalOptions.MinMemoryGB,
+		Model:                 g.fingerprintGlobalOptions.Model,
+		TimeZone:              g.fingerprintGlobalOptions.TimeZone,
+		AvoidSuspiciousValues: g.fingerprintGlobalOptions.AvoidSuspiciousValues,
+		ScreenJitter:          g.fingerprintGlobalOptions.ScreenJitter,
+		ModelWindowChrome:     g.fingerprintGlobalOptions.ModelWindowChrome,
+		NoHDR:                 g.fingerprintGlobalOptions.NoHDR,
+	}
+	optToUse.HeaderGeneratorOptions = &header.HeaderGeneratorOptions{} // need to merge properly, simplify for now
+
+	if options != nil {
+		if options.Screen != nil {
+			optToUse.Screen = options.Screen
+		}
+		optToUse.MockWebRTC = options.MockWebRTC
+		optToUse.Slim = options.Slim
+		optToUse.UserAgentFallback = options.UserAgentFallback
+		optToUse.ExcludeVirtualGPUs = options.ExcludeVirtualGPUs
+		optToUse.AvoidSuspiciousValues = options.AvoidSuspiciousValues
+		optToUse.ScreenJitter = options.ScreenJitter
+		optToUse.ModelWindowChrome = options.ModelWindowChrome
+		optToUse.NoHDR = options.NoHDR
+		optToUse.screenSeed = options.screenSeed
+		if options.MinCores != 0 {
+			optToUse.MinCores = options.MinCores
+		}
+		if options.MinMemoryGB != 0 {
+			optToUse.MinMemoryGB = options.MinMemoryGB
+		}
+		if options.Model != "" {
+			optToUse.Model = options.Model
+		}
+		if options.TimeZone != "" {
+			optToUse.TimeZone = options.TimeZone
+		}
+		if options.Constraints != nil {
+			optToUse.Constraints = options.Constraints
+		}
+		if options.SamplingMethod != bayesian.Backtracking {
+			optToUse.SamplingMethod = options.SamplingMethod
+		}
+		if options.BannedValues != nil {
+			optToUse.BannedValues = options.BannedValues
+		}
+		if options.ExcludeValues != nil {
+			optToUse.ExcludeValues = options.ExcludeValues
+		}
+		// merge header options if needed
+		optToUse.HeaderGeneratorOptions = options.HeaderGeneratorOptions
+	}
+
+	return optToUse
+}
+
+// prepareConstraints turns the screen and attribute constraints of options into fingerprint network
+// evidence and computes its closure over the network.
+func (g *FingerprintGenerator) prepareConstraints(optToUse *FingerprintGeneratorOptions) (map[string][]string, map[string][]string, []Relaxation, error) {
+	filteredValues := make(map[string][]string)
+
+	var partialCSP map[string][]string
+	var relaxations []Relaxation
+	for nodeName, values := range optToUse.Constraints {
+		if _, ok := g.fingerprintGeneratorNetwork.NodesByName[nodeName]; !ok {
+			return nil, nil, nil, fmt.Errorf("The fingerprint network has no attribute named %q.", nodeName)
+		}
+		filteredValues[nodeName] = values
+	}
+
+	if optToUse.Screen != nil {
+		extensiveScreen := true
+		if extensiveScreen {
+			var possibleScreens []string
+			if screenNode, ok := g.fingerprintGeneratorNetwork.NodesByName["screen"]; ok {
+				for _, screenString := range screenNode.Definition.PossibleValues {
+					if screen, err := ParseStringifiedScreen(screenString); err == nil {
+						minW, maxW, minH, maxH := 0.0, 1e5, 0.0, 1e5
+						if optToUse.Screen.MinWidth != nil {
+							minW = *optToUse.Screen.MinWidth
+						}
+						if optToUse.Screen.MaxWidth != nil {
+							maxW = *optToUse.Screen.MaxWidth
+						}
+						if optToUse.Screen.MinHeight != nil {
+							minH = *optToUse.Screen.MinHeight
+						}
+						if optToUse.Screen.MaxHeight != nil {
+							maxH = *optToUse.Screen.MaxHeight
+						}
+
+						if screen.Width >= minW && screen.Width <= maxW && screen.Height >= minH && screen.Height <= maxH {
+							possibleScreens = append(possibleScreens, screenString)
+						}
+					}
+				}
+				filteredValues["screen"] = possibleScreens
+			}
+		}
+	}
+
+	if len(filteredValues) > 0 {
+		closure, err := bayesian.GetConstraintClosure(g.fingerprintGeneratorNetwork, filteredValues)
+		strict := optToUse.HeaderGeneratorOptions != nil && optToUse.HeaderGeneratorOptions.Strict
+		if err != nil && optToUse.Screen != nil && !strict {
+			delete(filteredValues, "screen")
+			relaxations = append(relaxations, Relaxation{Constraint: "screen", Reason: err.Error()})
+			closure, err = bayesian.GetConstraintClosure(g.fingerprintGeneratorNetwork, filteredValues)
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		partialCSP = closure
+	}
+
+	return filteredValues, partialCSP, relaxations, nil
+}
+
 func (g *FingerprintGenerator) transformFingerprint(fingerprint map[string]any) Fingerprint {
 	var fp Fingerprint
 	b, err := json.Marshal(fingerprint)
@@ -357,3 +726,12 @@ func (g *FingerprintGenerator) transformFingerprint(fingerprint map[string]any)
 
 	return fp
 }
+
+// FingerprintProvider is the fingerprint generation API of FingerprintGenerator. Depend on it instead of
+// the concrete generator to substitute fingerprints in tests; fingerprinttest.Fake implements it.
+type FingerprintProvider interface {
+	GetFingerprint(options *FingerprintGeneratorOptions, requestDependentHeaders map[string]string) (*BrowserFingerprintWithHeaders, error)
+	GetHeadersForFingerprint(fp *Fingerprint, requestDependentHeaders map[string]string) (map[string]string, error)
+}
+
+var _ FingerprintProvider = (*FingerprintGenerator)(nil)
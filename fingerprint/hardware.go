@@ -0,0 +1,35 @@
+package fingerprint
+
+import (
+	"strconv"
+)
+
+// plausibleHardware reports whether a device with the given number of logical cores and deviceMemory
+// (in GB, as capped by browsers at 8) exists in practice. Low-memory devices are phones and budget
+// laptops, which do not come with workstation core counts.
+func plausibleHardware(cores int, memoryGB float64) bool {
+	switch {
+	case memoryGB <= 2:
+		return cores <= 8
+	case memoryGB <= 4:
+		return cores <= 16
+	}
+	return true
+}
+
+// consistentHardware is a bayesian.SamplingOptions.Consistent rule rejecting implausible
+// hardwareConcurrency and deviceMemory pairs as soon as both are sampled.
+func consistentHardware(sample map[string]string, node string) bool {
+	if node != "hardwareConcurrency" && node != "deviceMemory" {
+		return true
+	}
+	cores, err := strconv.Atoi(sample["hardwareConcurrency"])
+	if err != nil {
+		return true
+	}
+	memory, err := strconv.ParseFloat(sample["deviceMemory"], 64)
+	if err != nil {
+		return true
+	}
+	return plausibleHardware(cores, memory)
+}
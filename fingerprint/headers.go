@@ -0,0 +1,227 @@
+package fingerprint
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fingerprint-go/header"
+)
+
+// GetHeadersForFingerprint generates a fresh set of headers consistent with an existing fingerprint.
+// The header network is constrained on the fingerprint's user agent, Accept-Language is derived from
+// navigator.languages and the client hints are taken from navigator.userAgentData.
+func (g *FingerprintGenerator) GetHeadersForFingerprint(fp *Fingerprint, requestDependentHeaders map[string]string) (map[string]string, error) {
+	return g.GetHeadersForFingerprintRequest(fp, &header.Request{Headers: requestDependentHeaders})
+}
+
+// GetHeadersForFingerprintRequest is GetHeadersForFingerprint for a specific request. The user agent and,
+// unless set on the request, the high-entropy client hints are taken from the fingerprint.
+func (g *FingerprintGenerator) GetHeadersForFingerprintRequest(fp *Fingerprint, request *header.Request) (map[string]string, error) {
+	if fp == nil || fp.Navigator.UserAgent == "" {
+		return nil, fmt.Errorf("The fingerprint has no user agent to generate headers for.")
+	}
+
+	options := &header.HeaderGeneratorOptions{}
+	if browser := header.GetBrowser(fp.Navigator.UserAgent); browser != "" {
+		options.Browsers = []any{browser}
+	}
+	if len(fp.Navigator.Languages) > 0 {
+		options.Locales = fp.Navigator.Languages
+	}
+
+	forFingerprint := header.Request{}
+	if request != nil {
+		forFingerprint = *request
+	}
+	forFingerprint.UserAgent = fp.Navigator.UserAgent
+	if header.IsWebView(fp.Navigator.UserAgent) {
+		forFingerprint.UserAgent = header.ChromeUserAgent(fp.Navigator.UserAgent)
+		options.Devices = []string{header.DeviceWebView}
+		options.InAppBrowser = header.GetInAppBrowser(fp.Navigator.UserAgent)
+	}
+	if app := header.GetElectronApp(fp.Navigator.UserAgent); app != nil {
+		forFingerprint.UserAgent = header.ChromeUserAgent(fp.Navigator.UserAgent)
+		options.Electron = app
+	}
+	if forFingerprint.ClientHints == nil {
+		forFingerprint.ClientHints = fp.Navigator.UserAgentData.ClientHints()
+	}
+
+	headers, err := g.HeaderGenerator.GetHeadersForRequest(options, &forFingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	setHeader(headers, "user-agent", fp.Navigator.UserAgent)
+	applyClientHints(headers, &fp.Navigator.UserAgentData)
+
+	return headers, nil
+}
+
+// applyClientHints overwrites the low-entropy client hints already present in headers with values
+// derived from userAgentData, so the two never disagree.
+func applyClientHints(headers map[string]string, data *UserAgentData) {
+	if len(data.Brands) == 0 {
+		return
+	}
+	if getHeader(headers, "sec-ch-ua") == "" {
+		return
+	}
+
+	setHeader(headers, "sec-ch-ua", formatBrands(data.Brands))
+	if data.Mobile {
+		setHeader(headers, "sec-ch-ua-mobile", "?1")
+	} else {
+		setHeader(headers, "sec-ch-ua-mobile", "?0")
+	}
+	if data.Platform != "" {
+		setHeader(headers, "sec-ch-ua-platform", `"`+data.Platform+`"`)
+	}
+}
+
+// ClientHints returns the high-entropy client hint values of the user agent data.
+func (d *UserAgentData) ClientHints() *header.ClientHints {
+	if len(d.Brands) == 0 {
+		return nil
+	}
+	return &header.ClientHints{
+		Architecture:    d.Architecture,
+		Bitness:         d.Bitness,
+		Model:           d.Model,
+		PlatformVersion: d.PlatformVersion,
+		FullVersion:     d.UaFullVersion,
+		FullVersionList: formatBrands(d.FullVersionList),
+	}
+}
+
+func formatBrands(brands []Brand) string {
+	clientHintBrands := make([]header.ClientHintBrand, 0, len(brands))
+	for _, b := range brands {
+		clientHintBrands = append(clientHintBrands, header.ClientHintBrand{Brand: b.Brand, Version: b.Version})
+	}
+	return header.FormatClientHintBrands(clientHintBrands)
+}
+
+// applyBrands rebuilds userAgentData.brands and fullVersionList in the format the user agent's browser
+// version uses, the same one header generation uses for sec-ch-ua. Invented Chromium and Edge full
+// versions are replaced with published releases of the same major version.
+func applyBrands(data *UserAgentData, userAgent string) {
+	if len(data.Brands) == 0 {
+		return
+	}
+	browser := header.GetClientHintBrowser(userAgent)
+	version := header.GetBrowserVersion(userAgent)
+	chromium := header.GetChromiumVersion(userAgent)
+	if len(version) == 0 || len(chromium) == 0 {
+		return
+	}
+
+	brands := header.ClientHintBrands(browser, version[0], strconv.Itoa(chromium[0]), strconv.Itoa(version[0]))
+	if brands == nil {
+		return
+	}
+	data.Brands = data.Brands[:0]
+	for _, b := range brands {
+		data.Brands = append(data.Brands, Brand{Brand: b.Brand, Version: b.Version})
+	}
+
+	// Chrome and WebView report the Chromium build as their version, Edge has builds of its own. Full
+	// versions of another major version, e.g. those of the dataset record a user agent fell back to,
+	// are replaced with published releases of the user agent's major version.
+	ofMajor := func(fullVersion string, major int) string {
+		if strings.HasPrefix(fullVersion, strconv.Itoa(major)+".") {
+			return fullVersion
+		}
+		return strconv.Itoa(major) + ".0.0.0"
+	}
+	realChromium := func(fullVersion string) string {
+		return header.RealChromeFullVersion(ofMajor(fullVersion, chromium[0]))
+	}
+	realBrowser := realChromium
+	if browser == "edge" {
+		realBrowser = func(fullVersion string) string {
+			return header.RealEdgeFullVersion(ofMajor(fullVersion, version[0]))
+		}
+	}
+	if data.UaFullVersion != "" {
+		data.UaFullVersion = realBrowser(data.UaFullVersion)
+	}
+
+	if len(data.FullVersionList) == 0 {
+		return
+	}
+	fullVersions := make(map[string]string)
+	for _, b := range data.FullVersionList {
+		fullVersions[b.Brand] = b.Version
+	}
+	chromiumFull := cmp.Or(fullVersions["Chromium"], data.UaFullVersion)
+	browserFull := cmp.Or(fullVersions[header.ClientHintBrandName(browser)], data.UaFullVersion)
+	if chromiumFull == "" || browserFull == "" {
+		return
+	}
+	if browser != "edge" {
+		chromiumFull = cmp.Or(data.UaFullVersion, realChromium(chromiumFull))
+		browserFull = chromiumFull
+	} else {
+		chromiumFull = realChromium(chromiumFull)
+		browserFull = cmp.Or(data.UaFullVersion, realBrowser(browserFull))
+	}
+	data.FullVersionList = data.FullVersionList[:0]
+	for _, b := range header.ClientHintBrands(browser, version[0], chromiumFull, browserFull) {
+		data.FullVersionList = append(data.FullVersionList, Brand{Brand: b.Brand, Version: b.Version})
+	}
+}
+
+// applyFullVersionHints overwrites the full version client hints already present in headers with the
+// full versions of userAgentData.
+func applyFullVersionHints(headers map[string]string, data *UserAgentData) {
+	if data.UaFullVersion != "" && getHeader(headers, "sec-ch-ua-full-version") != "" {
+		setHeader(headers, "sec-ch-ua-full-version", `"`+data.UaFullVersion+`"`)
+	}
+	if len(data.FullVersionList) > 0 && getHeader(headers, "sec-ch-ua-full-version-list") != "" {
+		setHeader(headers, "sec-ch-ua-full-version-list", formatBrands(data.FullVersionList))
+	}
+}
+
+// applyHeaderClientHints sets the brands and full versions of userAgentData to the client hints sent in
+// headers, so that a fingerprint for existing headers never disagrees with them.
+func applyHeaderClientHints(data *UserAgentData, headers map[string]string, userAgent string) {
+	if len(data.Brands) == 0 {
+		return
+	}
+	toBrands := func(brands []header.ClientHintBrand) []Brand {
+		converted := make([]Brand, 0, len(brands))
+		for _, b := range brands {
+			converted = append(converted, Brand{Brand: b.Brand, Version: b.Version})
+		}
+		return converted
+	}
+	if brands := header.ParseClientHintBrands(getHeader(headers, "sec-ch-ua")); len(brands) > 0 {
+		data.Brands = toBrands(brands)
+	}
+	if brands := header.ParseClientHintBrands(getHeader(headers, "sec-ch-ua-full-version-list")); len(brands) > 0 {
+		data.FullVersionList = toBrands(brands)
+		brandName := header.ClientHintBrandName(header.GetClientHintBrowser(userAgent))
+		for _, b := range brands {
+			if b.Brand == brandName && data.UaFullVersion != "" {
+				data.UaFullVersion = b.Version
+			}
+		}
+	}
+	if fullVersion := strings.Trim(getHeader(headers, "sec-ch-ua-full-version"), `"`); fullVersion != "" {
+		data.UaFullVersion = fullVersion
+	}
+}
+
+// setHeader replaces the value of the header matching name case-insensitively, keeping its casing.
+func setHeader(headers map[string]string, name string, value string) {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			headers[k] = value
+			return
+		}
+	}
+	headers[name] = value
+}
@@ -0,0 +1,26 @@
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+)
+
+// ID returns a stable identifier of the fingerprint: a hash over its canonical JSON encoding. Fields that
+// only control how the fingerprint is injected (MockWebRTC, Slim) and the order of fonts do not affect it.
+func (fp *Fingerprint) ID() string {
+	normalized := *fp
+	normalized.MockWebRTC = false
+	normalized.Slim = false
+	normalized.Fonts = slices.Clone(fp.Fonts)
+	slices.Sort(normalized.Fonts)
+
+	// Maps are encoded with sorted keys, so the encoding is canonical.
+	b, err := json.Marshal(normalized)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:16])
+}
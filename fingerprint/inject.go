@@ -0,0 +1,340 @@
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// InitScriptOptions controls the script built by InitScript.
+type InitScriptOptions struct {
+	// Minify removes the comments and indentation of the script, which shortens the parsing of the
+	// script on every new document.
+	Minify bool
+	// Iframes patches same-origin iframes, such as about:blank and srcdoc frames, when the page first
+	// reaches their window. Init scripts are not evaluated in them before the page can probe them.
+	Iframes bool
+	// Workers patches the dedicated and shared workers the page starts: their script is loaded from a
+	// blob that patches the worker scope first. Scripts of blob workers resolve relative URLs and
+	// report self.location against the blob URL. Service workers can not be started from blobs, use
+	// WorkerScript for them.
+	Workers bool
+}
+
+// maxCachedInitScripts bounds the scripts InitScript keeps, so that crawlers rotating fingerprints do
+// not grow the cache without bound.
+const maxCachedInitScripts = 1024
+
+// initScriptSource defines the templates of the scripts. Their data is an initScriptData.
+const initScriptSource = `{{define "apply"}}(scope, fp) => {
+	// Getters are defined on the prototypes, so the properties of the instances stay absent as in an
+	// unmodified browser.
+	const patch = (target, values) => {
+		for (const [name, value] of Object.entries(values)) {
+			if (value === undefined || value === null) continue;
+			try {
+				Object.defineProperty(target, name, { get: () => value, configurable: true, enumerable: true });
+			} catch (e) {}
+		}
+	};
+	// Workers expose navigator through WorkerNavigator and have no screen or window sizes.
+	patch((scope.WorkerNavigator || scope.Navigator).prototype, fp.navigator);
+	if (scope.document) {
+		patch(scope.Screen.prototype, fp.screen);
+		// Frames have their own inner size.
+		const values = { ...fp.window };
+		if (scope !== scope.top) {
+			delete values.innerWidth;
+			delete values.innerHeight;
+		}
+		patch(scope, values);
+	}
+	// APIs of other engines, e.g. the Chromium ones on a Safari identity, are removed from their owner.
+	for (const api of fp.absent || []) {
+		const [owner, name] = api.split('.');
+		const target = owner === 'navigator' ? (scope.WorkerNavigator || scope.Navigator).prototype : scope;
+		try {
+			delete target[name];
+		} catch (e) {}
+	}
+	// Headless Chrome lacks members of window.chrome, which are recreated as on a page that is not an
+	// installed app.
+	if (fp.chrome && scope.document) {
+		const chrome = scope.chrome || {};
+		const timing = () => scope.performance.timing;
+		if (fp.chrome.app && !chrome.app) {
+			chrome.app = {
+				isInstalled: false,
+				InstallState: { DISABLED: 'disabled', INSTALLED: 'installed', NOT_INSTALLED: 'not_installed' },
+				RunningState: { CANNOT_RUN: 'cannot_run', READY_TO_RUN: 'ready_to_run', RUNNING: 'running' },
+				getDetails: () => null,
+				getIsInstalled: () => false,
+				runningState: () => 'cannot_run',
+			};
+		}
+		if (fp.chrome.csi && !chrome.csi) {
+			chrome.csi = () => ({ onloadT: timing().domContentLoadedEventEnd, startE: timing().navigationStart, pageT: scope.performance.now(), tran: 15 });
+		}
+		if (fp.chrome.loadTimes && !chrome.loadTimes) {
+			chrome.loadTimes = () => ({
+				requestTime: timing().navigationStart / 1000,
+				startLoadTime: timing().navigationStart / 1000,
+				commitLoadTime: timing().responseStart / 1000,
+				finishDocumentLoadTime: timing().domContentLoadedEventEnd / 1000,
+				finishLoadTime: timing().loadEventEnd / 1000,
+				firstPaintTime: timing().domContentLoadedEventEnd / 1000,
+				firstPaintAfterLoadTime: 0,
+				navigationType: 'Other',
+				wasFetchedViaSpdy: true,
+				wasNpnNegotiated: true,
+				npnNegotiatedProtocol: 'h2',
+				wasAlternateProtocolAvailable: false,
+				connectionInfo: 'h2',
+			});
+		}
+		if (!scope.chrome) {
+			Object.defineProperty(scope, 'chrome', { value: chrome, writable: true, configurable: true, enumerable: true });
+		}
+	}
+	if (fp.userAgentData && scope.navigator.userAgentData) {
+		const data = fp.userAgentData;
+		const proto = Object.getPrototypeOf(scope.navigator.userAgentData);
+		patch(proto, { brands: data.brands, mobile: data.mobile, platform: data.platform });
+		proto.getHighEntropyValues = function (hints) {
+			const result = { brands: data.brands, mobile: data.mobile, platform: data.platform };
+			for (const hint of hints || []) {
+				if (hint in data) result[hint] = data[hint];
+			}
+			return Promise.resolve(result);
+		};
+	}
+	if (fp.webgl) {
+		// UNMASKED_VENDOR_WEBGL and UNMASKED_RENDERER_WEBGL of WEBGL_debug_renderer_info.
+		for (const context of [scope.WebGLRenderingContext, scope.WebGL2RenderingContext]) {
+			if (!context) continue;
+			const getParameter = context.prototype.getParameter;
+			context.prototype.getParameter = function (parameter) {
+				if (parameter === 0x9245) return fp.webgl.vendor;
+				if (parameter === 0x9246) return fp.webgl.renderer;
+				return getParameter.call(this, parameter);
+			};
+		}
+	}
+	if (fp.timeZone) {
+		const resolvedOptions = scope.Intl.DateTimeFormat.prototype.resolvedOptions;
+		scope.Intl.DateTimeFormat.prototype.resolvedOptions = function () {
+			return { ...resolvedOptions.call(this), timeZone: fp.timeZone };
+		};
+	}
+}{{end}}
+
+{{define "worker"}}({{template "apply" .}})(self, {{.Values}});{{end}}
+
+{{define "page"}}(() => {
+	const fp = {{.Values}};
+	const apply = {{template "apply" .}};
+	apply(window, fp);
+{{- if .Iframes}}
+	const patched = new WeakSet([window]);
+	const patchFrame = (frame) => {
+		if (!frame || patched.has(frame)) return;
+		patched.add(frame);
+		try {
+			apply(frame, fp);
+		} catch (e) {}
+	};
+	for (const [proto, name] of [[HTMLIFrameElement.prototype, 'contentWindow'], [HTMLIFrameElement.prototype, 'contentDocument'], [HTMLFrameElement.prototype, 'contentWindow']]) {
+		const descriptor = Object.getOwnPropertyDescriptor(proto, name);
+		Object.defineProperty(proto, name, {
+			...descriptor,
+			get() {
+				const value = descriptor.get.call(this);
+				// Cross-origin frames throw on access to their globals and are left to their own init script.
+				try {
+					patchFrame(value && value.defaultView !== undefined ? value.defaultView : value);
+				} catch (e) {}
+				return value;
+			},
+		});
+	}
+{{- end}}
+{{- if .Workers}}
+	const workerSource = '(' + apply.toString() + ')(self, ' + JSON.stringify(fp) + ');';
+	for (const name of ['Worker', 'SharedWorker']) {
+		const Original = window[name];
+		if (!Original) continue;
+		const Patched = function (url, options) {
+			if (!new.target) return Original(url, options);
+			const absolute = new URL(url, location.href).href;
+			const module = options && typeof options === 'object' && options.type === 'module';
+			const load = module ? 'import(' + JSON.stringify(absolute) + ');' : 'importScripts(' + JSON.stringify(absolute) + ');';
+			const blob = URL.createObjectURL(new Blob([workerSource + load], { type: 'text/javascript' }));
+			return new Original(blob, options);
+		};
+		Patched.prototype = Original.prototype;
+		Object.defineProperty(Patched, 'name', { value: name });
+		Object.defineProperty(Patched, 'length', { value: Original.length });
+		window[name] = Patched;
+	}
+{{- end}}
+})();{{end}}`
+
+// initScriptTemplates are the templates of the full and the minified scripts, parsed once.
+var initScriptTemplates = map[bool]*template.Template{
+	false: template.Must(template.New("init").Parse(initScriptSource)),
+	true:  template.Must(template.New("init").Parse(minifyScript(initScriptSource))),
+}
+
+// initScriptData is the data of the script templates.
+type initScriptData struct {
+	InitScriptOptions
+	// Values are the JSON encoded injectedValues, which is a valid JavaScript expression.
+	Values string
+}
+
+var initScripts = struct {
+	sync.Mutex
+	scripts map[string]string
+}{scripts: make(map[string]string)}
+
+// injectedValues are the values of a fingerprint the init script patches.
+type injectedValues struct {
+	Navigator     map[string]any `json:"navigator"`
+	Screen        map[string]any `json:"screen"`
+	Window        map[string]any `json:"window"`
+	UserAgentData *UserAgentData `json:"userAgentData,omitempty"`
+	WebGL         *VideoCard     `json:"webgl,omitempty"`
+	TimeZone      string         `json:"timeZone,omitempty"`
+	Absent        []string       `json:"absent,omitempty"`
+	Chrome        *ChromeObject  `json:"chrome,omitempty"`
+}
+
+// InitScript returns a script that patches the browser values of fp, to be evaluated on every new
+// document before the page scripts, e.g. with Page.addScriptToEvaluateOnNewDocument. Scripts are
+// cached by the values they inject, so injecting the same fingerprint into many pages builds it once.
+// Slim fingerprints leave WebGL untouched. The AbsentAPIs of fp are removed and the missing members of
+// its window.chrome added.
+func InitScript(fp *Fingerprint, options *InitScriptOptions) (string, error) {
+	if options == nil {
+		options = &InitScriptOptions{}
+	}
+	return buildScript("page", fp, options)
+}
+
+// WorkerScript returns a script that patches the navigator values of fp in a worker scope. Evaluate
+// it in the worker targets a browser automation attaches to, e.g. with Target.setAutoAttach and
+// Runtime.evaluate, to cover service workers and workers of cross-origin frames, which the init
+// script can not reach. Only Minify of options applies.
+func WorkerScript(fp *Fingerprint, options *InitScriptOptions) (string, error) {
+	minify := options != nil && options.Minify
+	return buildScript("worker", fp, &InitScriptOptions{Minify: minify})
+}
+
+// buildScript executes the template name for fp, or returns the cached script.
+func buildScript(name string, fp *Fingerprint, options *InitScriptOptions) (string, error) {
+	// The key holds the injected values rather than the fingerprint ID, which ignores Slim.
+	values, err := json.Marshal(newInjectedValues(fp))
+	if err != nil {
+		return "", fmt.Errorf("The fingerprint could not be encoded: %w", err)
+	}
+	sum := sha256.Sum256(values)
+	key := fmt.Sprint(name, hex.EncodeToString(sum[:]), *options)
+
+	initScripts.Lock()
+	script, ok := initScripts.scripts[key]
+	initScripts.Unlock()
+	if ok {
+		return script, nil
+	}
+
+	var b strings.Builder
+	data := initScriptData{InitScriptOptions: *options, Values: string(values)}
+	if err := initScriptTemplates[options.Minify].ExecuteTemplate(&b, name, data); err != nil {
+		return "", fmt.Errorf("The init script could not be built: %w", err)
+	}
+	script = b.String()
+
+	initScripts.Lock()
+	if len(initScripts.scripts) >= maxCachedInitScripts {
+		clear(initScripts.scripts)
+	}
+	initScripts.scripts[key] = script
+	initScripts.Unlock()
+	return script, nil
+}
+
+func newInjectedValues(fp *Fingerprint) *injectedValues {
+	navigator := fp.Navigator
+	values := &injectedValues{
+		Navigator: map[string]any{
+			"userAgent":           navigator.UserAgent,
+			"language":            navigator.Language,
+			"languages":           navigator.Languages,
+			"platform":            navigator.Platform,
+			"deviceMemory":        navigator.DeviceMemory,
+			"hardwareConcurrency": navigator.HardwareConcurrency,
+			"maxTouchPoints":      navigator.MaxTouchPoints,
+			"product":             navigator.Product,
+			"productSub":          navigator.ProductSub,
+			"vendor":              navigator.Vendor,
+			"vendorSub":           navigator.VendorSub,
+			"appCodeName":         navigator.AppCodeName,
+			"appName":             navigator.AppName,
+			"appVersion":          navigator.AppVersion,
+		},
+		Screen: map[string]any{
+			"width":       fp.Screen.Width,
+			"height":      fp.Screen.Height,
+			"availWidth":  fp.Screen.AvailWidth,
+			"availHeight": fp.Screen.AvailHeight,
+			"availTop":    fp.Screen.AvailTop,
+			"availLeft":   fp.Screen.AvailLeft,
+			"colorDepth":  fp.Screen.ColorDepth,
+			"pixelDepth":  fp.Screen.PixelDepth,
+		},
+		Window: map[string]any{
+			"devicePixelRatio": fp.Screen.DevicePixelRatio,
+			"innerWidth":       fp.Screen.InnerWidth,
+			"innerHeight":      fp.Screen.InnerHeight,
+			"outerWidth":       fp.Screen.OuterWidth,
+			"outerHeight":      fp.Screen.OuterHeight,
+			"screenX":          fp.Screen.ScreenX,
+		},
+	}
+	if navigator.DoNotTrack != "" {
+		values.Navigator["doNotTrack"] = navigator.DoNotTrack
+	}
+	if navigator.Oscpu != "" {
+		values.Navigator["oscpu"] = navigator.Oscpu
+	}
+	if len(navigator.UserAgentData.Brands) > 0 {
+		values.UserAgentData = &navigator.UserAgentData
+	}
+	if !fp.Slim && fp.VideoCard.Renderer != "" {
+		values.WebGL = &fp.VideoCard
+	}
+	if fp.Intl != nil {
+		values.TimeZone = fp.Intl.TimeZone
+	}
+	values.Absent = fp.AbsentAPIs
+	values.Chrome = fp.Chrome
+	return values
+}
+
+// minifyScript removes the comment lines, indentation and empty lines of a script. Line breaks are
+// kept, so that automatic semicolon insertion still applies.
+func minifyScript(script string) string {
+	var lines []string
+	for line := range strings.Lines(script) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,130 @@
+package fingerprint
+
+import (
+	"strings"
+)
+
+// IntlFingerprint holds what Intl.DateTimeFormat().resolvedOptions() reports, so injectors can patch
+// Intl consistently with Accept-Language and navigator.languages.
+type IntlFingerprint struct {
+	Locale          string `json:"locale"`
+	TimeZone        string `json:"timeZone"`
+	Calendar        string `json:"calendar"`
+	NumberingSystem string `json:"numberingSystem"`
+}
+
+// regionTimeZones maps regions to the time zone most of their population uses.
+var regionTimeZones = map[string]string{
+	"US": "America/New_York",
+	"CA": "America/Toronto",
+	"MX": "America/Mexico_City",
+	"BR": "America/Sao_Paulo",
+	"AR": "America/Argentina/Buenos_Aires",
+	"GB": "Europe/London",
+	"IE": "Europe/Dublin",
+	"DE": "Europe/Berlin",
+	"AT": "Europe/Vienna",
+	"CH": "Europe/Zurich",
+	"FR": "Europe/Paris",
+	"BE": "Europe/Brussels",
+	"NL": "Europe/Amsterdam",
+	"ES": "Europe/Madrid",
+	"PT": "Europe/Lisbon",
+	"IT": "Europe/Rome",
+	"PL": "Europe/Warsaw",
+	"CZ": "Europe/Prague",
+	"SE": "Europe/Stockholm",
+	"NO": "Europe/Oslo",
+	"DK": "Europe/Copenhagen",
+	"FI": "Europe/Helsinki",
+	"GR": "Europe/Athens",
+	"TR": "Europe/Istanbul",
+	"UA": "Europe/Kyiv",
+	"RU": "Europe/Moscow",
+	"IL": "Asia/Jerusalem",
+	"SA": "Asia/Riyadh",
+	"AE": "Asia/Dubai",
+	"EG": "Africa/Cairo",
+	"ZA": "Africa/Johannesburg",
+	"IN": "Asia/Kolkata",
+	"TH": "Asia/Bangkok",
+	"VN": "Asia/Ho_Chi_Minh",
+	"ID": "Asia/Jakarta",
+	"SG": "Asia/Singapore",
+	"CN": "Asia/Shanghai",
+	"HK": "Asia/Hong_Kong",
+	"TW": "Asia/Taipei",
+	"KR": "Asia/Seoul",
+	"JP": "Asia/Tokyo",
+	"AU": "Australia/Sydney",
+	"NZ": "Pacific/Auckland",
+	"UZ": "Asia/Tashkent",
+	"KZ": "Asia/Almaty",
+}
+
+// languageRegions is the region assumed for locales without one.
+var languageRegions = map[string]string{
+	"en": "US", "de": "DE", "fr": "FR", "es": "ES", "pt": "BR", "it": "IT", "nl": "NL", "pl": "PL",
+	"cs": "CZ", "sv": "SE", "nb": "NO", "da": "DK", "fi": "FI", "el": "GR", "tr": "TR", "uk": "UA",
+	"ru": "RU", "he": "IL", "ar": "SA", "hi": "IN", "th": "TH", "vi": "VN", "id": "ID", "zh": "CN",
+	"ko": "KR", "ja": "JP", "uz": "UZ", "kk": "KZ",
+}
+
+// localeCalendars and localeNumberingSystems list the locales whose default calendar or numbering
+// system is not gregory or latn, keyed by locale or language.
+var localeCalendars = map[string]string{
+	"th":    "buddhist",
+	"ar-sa": "islamic-umalqura",
+	"fa":    "persian",
+}
+
+var localeNumberingSystems = map[string]string{
+	"ar":    "arab",
+	"ar-ae": "latn",
+	"ar-ma": "latn",
+	"ar-dz": "latn",
+	"ar-tn": "latn",
+	"fa":    "arabext",
+	"bn":    "beng",
+	"mr":    "deva",
+}
+
+// intlForLocale derives the resolved Intl options of a browser whose first language is locale. An
+// empty timeZone is derived from the locale's region.
+func intlForLocale(locale string, timeZone string) *IntlFingerprint {
+	if locale == "" {
+		locale = "en-US"
+	}
+	language, region, _ := strings.Cut(locale, "-")
+	region = strings.ToUpper(region)
+	if region == "" {
+		region = languageRegions[strings.ToLower(language)]
+	}
+
+	if timeZone == "" {
+		timeZone = regionTimeZones[region]
+	}
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+
+	return &IntlFingerprint{
+		Locale:          locale,
+		TimeZone:        timeZone,
+		Calendar:        lookupLocale(localeCalendars, locale, "gregory"),
+		NumberingSystem: lookupLocale(localeNumberingSystems, locale, "latn"),
+	}
+}
+
+// lookupLocale looks locale up by its full tag first and then by its language.
+func lookupLocale(table map[string]string, locale string, fallback string) string {
+	locale = strings.ToLower(locale)
+	if value, ok := table[locale]; ok {
+		return value
+	}
+	language, _, _ := strings.Cut(locale, "-")
+	if value, ok := table[language]; ok {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,57 @@
+package fingerprint
+
+import (
+	"fingerprint-go/bayesian"
+)
+
+// PossibleValues returns the values the generator can produce for the named node, looking at the
+// fingerprint network first and at the header networks otherwise.
+func (g *FingerprintGenerator) PossibleValues(node string) []string {
+	if n, ok := g.fingerprintGeneratorNetwork.NodesByName[node]; ok {
+		return n.Definition.PossibleValues
+	}
+	return g.HeaderGenerator.PossibleValues(node)
+}
+
+// AvailableUserAgents returns the user agents that can be generated with options: they match the
+// browser, operating system and device options and are compatible with the screen and attribute constraints.
+func (g *FingerprintGenerator) AvailableUserAgents(options *FingerprintGeneratorOptions) ([]string, error) {
+	optToUse := g.mergeOptions(options)
+	_, partialCSP, _, err := g.prepareConstraints(optToUse)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgents := g.HeaderGenerator.AvailableUserAgents(optToUse.HeaderGeneratorOptions)
+	if fingerprintUserAgents := g.PossibleValues("userAgent"); fingerprintUserAgents != nil {
+		userAgents = bayesian.ArrayIntersection(userAgents, fingerprintUserAgents)
+	}
+	if partialCSP != nil && partialCSP["userAgent"] != nil {
+		userAgents = bayesian.ArrayIntersection(userAgents, partialCSP["userAgent"])
+	}
+	return userAgents, nil
+}
+
+// AvailableScreens returns the screens that can be generated with options.
+func (g *FingerprintGenerator) AvailableScreens(options *FingerprintGeneratorOptions) ([]ScreenFingerprint, error) {
+	optToUse := g.mergeOptions(options)
+	filteredValues, partialCSP, _, err := g.prepareConstraints(optToUse)
+	if err != nil {
+		return nil, err
+	}
+
+	values := g.PossibleValues("screen")
+	if partialCSP != nil && partialCSP["screen"] != nil {
+		values = partialCSP["screen"]
+	} else if filteredValues["screen"] != nil {
+		values = filteredValues["screen"]
+	}
+
+	var screens []ScreenFingerprint
+	for _, value := range values {
+		if screen, err := ParseStringifiedScreen(value); err == nil {
+			screens = append(screens, screen)
+		}
+	}
+	return screens, nil
+}
@@ -0,0 +1,104 @@
+package fingerprint
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"fingerprint-go/header"
+)
+
+// schemaMigrations migrate a decoded BrowserFingerprintWithHeaders payload of a schema version to the
+// next version.
+var schemaMigrations = map[int]func(payload map[string]any) error{
+	0: migrateUnversioned,
+	1: noMigration, // Version 2 added partial to fingerprints and their info.
+	2: noMigration, // Version 3 added absentAPIs and storage.
+	3: noMigration, // Version 4 added chrome.
+}
+
+// noMigration migrates payloads of a version followed by one that only added optional fields, which
+// the older payloads decode to the zero values of.
+func noMigration(payload map[string]any) error {
+	return nil
+}
+
+// migrateUnversioned adds the info of payloads written before the generator returned it, derived from
+// their headers like InfoFromHeaders does.
+func migrateUnversioned(payload map[string]any) error {
+	if _, ok := payload["info"]; ok {
+		return nil
+	}
+	headers, ok := payload["headers"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	values := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if s, ok := value.(string); ok {
+			values[name] = s
+		}
+	}
+	payload["info"] = header.InfoFromHeaders(values)
+	return nil
+}
+
+// PayloadSchemaVersion returns the schema version of a BrowserFingerprintWithHeaders payload.
+// Payloads without schemaVersion were written before it was added and are version 0.
+func PayloadSchemaVersion(data []byte) (int, error) {
+	var payload struct {
+		SchemaVersion *int `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return 0, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if payload.SchemaVersion == nil {
+		return 0, nil
+	}
+	return *payload.SchemaVersion, nil
+}
+
+// MigrateJSON returns a BrowserFingerprintWithHeaders payload written by an older version of the
+// library in the current schema version, so that persisted fingerprints are not misread after an
+// upgrade. Payloads of a newer schema version are an error.
+func MigrateJSON(data []byte) ([]byte, error) {
+	version, err := PayloadSchemaVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version == SchemaVersion {
+		return data, nil
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("the payload has schema version %d, newer than the supported version %d; upgrade fingerprint-go",
+			version, SchemaVersion)
+	}
+	if _, ok := schemaMigrations[version]; !ok {
+		return nil, fmt.Errorf("schema version %d can not be migrated", version)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	for ; version < SchemaVersion; version++ {
+		if err := schemaMigrations[version](payload); err != nil {
+			return nil, fmt.Errorf("failed to migrate the payload from schema version %d: %w", version, err)
+		}
+	}
+	payload["schemaVersion"] = SchemaVersion
+	return json.Marshal(payload)
+}
+
+// UnmarshalFingerprint decodes a persisted BrowserFingerprintWithHeaders payload of any supported
+// schema version, migrating it with MigrateJSON.
+func UnmarshalFingerprint(data []byte) (*BrowserFingerprintWithHeaders, error) {
+	migrated, err := MigrateJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var fp BrowserFingerprintWithHeaders
+	if err := json.Unmarshal(migrated, &fp); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	return &fp, nil
+}
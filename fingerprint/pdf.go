@@ -0,0 +1,130 @@
+package fingerprint
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"fingerprint-go/header"
+)
+
+// pdfPluginNames are the plugins Chromium lists since version 94 while its PDF viewer is enabled,
+// whatever is installed. With the viewer disabled, navigator.plugins is empty.
+var pdfPluginNames = []string{"PDF Viewer", "Chrome PDF Viewer", "Chromium PDF Viewer", "Microsoft Edge PDF Viewer", "WebKit built-in PDF"}
+
+// fixedPDFPluginsVersion is the first Chromium version listing pdfPluginNames.
+const fixedPDFPluginsVersion = 94
+
+// pdfPluginsData returns the pluginsData of a Chromium browser with an enabled PDF viewer.
+func pdfPluginsData() map[string]string {
+	type mimeType struct {
+		Type          string `json:"type"`
+		Suffixes      string `json:"suffixes"`
+		Description   string `json:"description"`
+		EnabledPlugin string `json:"enabledPlugin"`
+	}
+	type plugin struct {
+		Name        string     `json:"name"`
+		Description string     `json:"description"`
+		Filename    string     `json:"filename"`
+		MimeTypes   []mimeType `json:"mimeTypes"`
+	}
+	plugins := make([]plugin, 0, len(pdfPluginNames))
+	for _, name := range pdfPluginNames {
+		plugins = append(plugins, plugin{
+			Name:        name,
+			Description: "Portable Document Format",
+			Filename:    "internal-pdf-viewer",
+			MimeTypes: []mimeType{
+				{Type: "application/pdf", Suffixes: "pdf", Description: "Portable Document Format", EnabledPlugin: name},
+				{Type: "text/pdf", Suffixes: "pdf", Description: "Portable Document Format", EnabledPlugin: name},
+			},
+		})
+	}
+	pluginsJSON, _ := json.Marshal(plugins)
+	mimeTypesJSON, _ := json.Marshal([]string{
+		"Portable Document Format~~application/pdf~~pdf",
+		"Portable Document Format~~text/pdf~~pdf",
+	})
+	return map[string]string{"plugins": string(pluginsJSON), "mimeTypes": string(mimeTypesJSON)}
+}
+
+// pluginNames returns the names of the plugins of pluginsData. ok is false when they are not known.
+func pluginNames(pluginsData map[string]string) (names []string, ok bool) {
+	var plugins []struct {
+		Name string `json:"name"`
+	}
+	value, ok := pluginsData["plugins"]
+	if !ok || json.Unmarshal([]byte(value), &plugins) != nil {
+		return nil, false
+	}
+	for _, p := range plugins {
+		names = append(names, p.Name)
+	}
+	return names, true
+}
+
+// fixedPDFPlugins reports whether the browser of userAgent lists pdfPluginNames: Chromium-based
+// browsers from version 94 on the desktop.
+func fixedPDFPlugins(userAgent string) bool {
+	browser := header.GetBrowser(userAgent)
+	version := header.GetChromiumVersion(userAgent)
+	return (browser == "chrome" || browser == "edge") && header.GetOperatingSystem(userAgent) != "ios" &&
+		header.GetDevice(userAgent) != "mobile" && len(version) > 0 && version[0] >= fixedPDFPluginsVersion
+}
+
+// PDFViewerProblem describes why navigator.pdfViewerEnabled and the plugins of fp disagree, or returns
+// "" when they agree or the plugins are not known: mobile browsers have no plugins and Chromium on the
+// desktop lists the five PDF plugins exactly when its PDF viewer is enabled since version 94.
+func PDFViewerProblem(fp *Fingerprint) string {
+	names, ok := pluginNames(fp.PluginsData)
+	if !ok {
+		return ""
+	}
+	userAgent := fp.Navigator.UserAgent
+	enabled := fp.Navigator.ExtraProperties.PdfViewerEnabled
+	switch {
+	case header.GetDevice(userAgent) == "mobile":
+		if len(names) > 0 {
+			return "mobile browsers have no plugins"
+		}
+		if enabled && header.GetOperatingSystem(userAgent) == "android" {
+			return "Chromium on Android reports no PDF viewer"
+		}
+	case fixedPDFPlugins(userAgent):
+		if enabled && !slices.Equal(names, pdfPluginNames) {
+			return "navigator.pdfViewerEnabled is true but the plugins are not the five PDF plugins of Chromium"
+		}
+		if !enabled && len(names) > 0 {
+			return "navigator.pdfViewerEnabled is false but plugins are listed"
+		}
+	}
+	return ""
+}
+
+// applyPDFViewer makes navigator.pdfViewerEnabled and the plugins of fp agree, see PDFViewerProblem.
+// Chromium keeps its PDF viewer when the sampled plugins include a PDF plugin.
+func applyPDFViewer(fp *Fingerprint) {
+	if PDFViewerProblem(fp) == "" {
+		return
+	}
+	userAgent := fp.Navigator.UserAgent
+	if header.GetDevice(userAgent) == "mobile" {
+		for attribute := range fp.PluginsData {
+			fp.PluginsData[attribute] = "[]"
+		}
+		if header.GetOperatingSystem(userAgent) == "android" {
+			fp.Navigator.ExtraProperties.PdfViewerEnabled = false
+		}
+		return
+	}
+
+	names, _ := pluginNames(fp.PluginsData)
+	enabled := slices.ContainsFunc(names, func(name string) bool { return strings.Contains(name, "PDF") })
+	fp.Navigator.ExtraProperties.PdfViewerEnabled = enabled
+	if enabled {
+		fp.PluginsData = pdfPluginsData()
+	} else {
+		fp.PluginsData = map[string]string{"plugins": "[]", "mimeTypes": "[]"}
+	}
+}
@@ -0,0 +1,64 @@
+package fingerprint
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// PlanEntry is an option set of a Plan, picked with a probability proportional to Weight.
+type PlanEntry struct {
+	Weight  float64
+	Options *FingerprintGeneratorOptions
+}
+
+// Plan shapes the traffic of a generator: every fingerprint is generated with the options of an entry
+// picked according to the weights, e.g. 50% Chrome on Windows, 30% Safari on iOS and 20% Firefox on Linux.
+type Plan []PlanEntry
+
+// Validate checks that the weights are not negative and that at least one is positive.
+func (p Plan) Validate() error {
+	total := 0.0
+	for i, entry := range p {
+		if entry.Weight < 0 {
+			return fmt.Errorf("The plan entry %d has a negative weight.", i)
+		}
+		total += entry.Weight
+	}
+	if total <= 0 {
+		return fmt.Errorf("The plan has no entry with a positive weight.")
+	}
+	return nil
+}
+
+// Pick returns the options of an entry picked according to the weights.
+func (p Plan) Pick() (*FingerprintGeneratorOptions, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	total := 0.0
+	for _, entry := range p {
+		total += entry.Weight
+	}
+	r := rand.Float64() * total
+	for _, entry := range p {
+		r -= entry.Weight
+		if r < 0 {
+			return entry.Options, nil
+		}
+	}
+	for i := len(p) - 1; ; i-- {
+		if p[i].Weight > 0 {
+			return p[i].Options, nil
+		}
+	}
+}
+
+// GetFingerprintFromPlan generates a fingerprint with the options of an entry of plan.
+func (g *FingerprintGenerator) GetFingerprintFromPlan(plan Plan, requestDependentHeaders map[string]string) (*BrowserFingerprintWithHeaders, error) {
+	options, err := plan.Pick()
+	if err != nil {
+		return nil, err
+	}
+	return g.GetFingerprint(options, requestDependentHeaders)
+}
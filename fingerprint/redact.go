@@ -0,0 +1,203 @@
+package fingerprint
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strings"
+
+	"fingerprint-go/header"
+)
+
+// Redacted is the value Redact and RedactingHandler put in place of identifying values.
+const Redacted = "[redacted]"
+
+// redactedHeaders are the headers Redact masks, by lowercase name: the user agent and the values that
+// narrow it down, and the cookies of the identity.
+var redactedHeaders = map[string]bool{
+	"user-agent":                  true,
+	"cookie":                      true,
+	"accept-language":             true,
+	"sec-ch-ua-full-version":      true,
+	"sec-ch-ua-full-version-list": true,
+	"sec-ch-ua-model":             true,
+	"sec-ch-ua-platform-version":  true,
+}
+
+// redactedLogKeys are the keys of log attributes RedactingHandler masks, lowercased and without "-"
+// and "_".
+var redactedLogKeys = map[string]bool{"useragent": true, "ua": true, "cookie": true, "cookies": true}
+
+// Redact returns a copy of fp whose identifying values are masked with Redacted, for logs that should
+// not hold the identities they describe: the user agent strings and headers, the full versions, model
+// and platform version of the client hints, cookies, languages, the WebGL renderer, fonts and media
+// devices, and the full version and TLS fingerprints of Info, see RedactInfo. The browser, major
+// version, operating system and device of Info are kept.
+func Redact(fp *BrowserFingerprintWithHeaders) *BrowserFingerprintWithHeaders {
+	if fp == nil {
+		return nil
+	}
+	// The JSON copy shares no maps or slices with fp.
+	var redacted BrowserFingerprintWithHeaders
+	b, err := json.Marshal(fp)
+	if err != nil || json.Unmarshal(b, &redacted) != nil {
+		return &BrowserFingerprintWithHeaders{SchemaVersion: fp.SchemaVersion}
+	}
+
+	redactHeaders(redacted.Headers)
+	redactFingerprint(&redacted.Fingerprint)
+	redacted.Info = RedactInfo(redacted.Info)
+	return &redacted
+}
+
+// RedactInfo returns a copy of info without the values that narrow the identity down: the full
+// version is cut to the major version, the languages are dropped and JA3 and JA4 are masked.
+func RedactInfo(info *header.GenerationInfo) *header.GenerationInfo {
+	if info == nil {
+		return nil
+	}
+	redacted := *info
+	redacted.Version, _, _ = strings.Cut(info.Version, ".")
+	redacted.Languages = nil
+	for _, value := range []*string{&redacted.JA3, &redacted.JA4} {
+		if *value != "" {
+			*value = Redacted
+		}
+	}
+	return &redacted
+}
+
+// redactHeaders masks the identifying values of headers in place, see redactedHeaders.
+func redactHeaders[V string | []string](headers map[string]V) {
+	for name := range headers {
+		if redactedHeaders[strings.ToLower(name)] {
+			var redacted V
+			switch v := any(&redacted).(type) {
+			case *string:
+				*v = Redacted
+			case *[]string:
+				*v = []string{Redacted}
+			}
+			headers[name] = redacted
+		}
+	}
+}
+
+// redactFingerprint masks the identifying values of fp in place, see Redact.
+func redactFingerprint(fp *Fingerprint) {
+	navigator := &fp.Navigator
+	navigator.UserAgent = Redacted
+	navigator.AppVersion = Redacted
+	if navigator.Language != "" {
+		navigator.Language = Redacted
+	}
+	navigator.Languages = nil
+	if navigator.Oscpu != "" {
+		navigator.Oscpu = Redacted
+	}
+	data := &navigator.UserAgentData
+	data.FullVersionList = nil
+	for _, value := range []*string{&data.UaFullVersion, &data.Model, &data.PlatformVersion} {
+		if *value != "" {
+			*value = Redacted
+		}
+	}
+	fp.VideoCard.Renderer = Redacted
+	fp.Fonts = nil
+	fp.MultimediaDevices = nil
+}
+
+// RedactingHandler is a slog.Handler that masks identities in the records it passes to another
+// handler. Records at or above its level have their fingerprints, header maps and GenerationInfo
+// replaced with redacted copies and the values of user agent and cookie attributes replaced with
+// Redacted, so that debug logs keep the full identities while info logs can be kept for operations.
+type RedactingHandler struct {
+	next  slog.Handler
+	level slog.Leveler
+}
+
+// NewRedactingHandler returns a handler passing records to next, redacted from level on. A nil level
+// is slog.LevelInfo. Attributes added with WithAttrs are redacted whatever the level of the records.
+func NewRedactingHandler(next slog.Handler, level slog.Leveler) *RedactingHandler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	return &RedactingHandler{next: next, level: level}
+}
+
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RedactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level < h.level.Level() {
+		return h.next.Handle(ctx, record)
+	}
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		redacted = append(redacted, redactAttr(attr))
+	}
+	return &RedactingHandler{next: h.next.WithAttrs(redacted), level: h.level}
+}
+
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{next: h.next.WithGroup(name), level: h.level}
+}
+
+// redactAttr returns attr with its fingerprints, headers, generation info and user agent and cookie
+// values masked.
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, 0, len(group))
+		for _, member := range group {
+			redacted = append(redacted, redactAttr(member))
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindString:
+		key := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(attr.Key))
+		if redactedLogKeys[key] {
+			return slog.String(attr.Key, Redacted)
+		}
+	case slog.KindAny:
+		switch v := value.Any().(type) {
+		case *BrowserFingerprintWithHeaders:
+			return slog.Any(attr.Key, Redact(v))
+		case BrowserFingerprintWithHeaders:
+			return slog.Any(attr.Key, Redact(&v))
+		case *Fingerprint:
+			if v == nil {
+				break
+			}
+			return slog.Any(attr.Key, &Redact(&BrowserFingerprintWithHeaders{Fingerprint: *v}).Fingerprint)
+		case Fingerprint:
+			return slog.Any(attr.Key, Redact(&BrowserFingerprintWithHeaders{Fingerprint: v}).Fingerprint)
+		case map[string]string:
+			redacted := maps.Clone(v)
+			redactHeaders(redacted)
+			return slog.Any(attr.Key, redacted)
+		case http.Header:
+			redacted := v.Clone()
+			redactHeaders(redacted)
+			return slog.Any(attr.Key, redacted)
+		case *header.GenerationInfo:
+			return slog.Any(attr.Key, RedactInfo(v))
+		case header.GenerationInfo:
+			return slog.Any(attr.Key, *RedactInfo(&v))
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
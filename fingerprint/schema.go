@@ -0,0 +1,185 @@
+package fingerprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaVersion is the version of the JSON format of BrowserFingerprintWithHeaders. It is increased
+// whenever fields are added, removed or change type, and payloads of older versions are migrated by
+// MigrateJSON. Payloads without schemaVersion were written before the format was versioned and are
+// version 0.
+const SchemaVersion = 4
+
+// Schema returns the JSON Schema of BrowserFingerprintWithHeaders, generated from the Go structs.
+func Schema() map[string]any {
+	schema := schemaForType(reflect.TypeOf(BrowserFingerprintWithHeaders{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = fmt.Sprintf("https://github.com/SardorShoh/fingerprint-go/schema/v%d/browser-fingerprint-with-headers.json", SchemaVersion)
+	schema["title"] = "BrowserFingerprintWithHeaders"
+	return schema
+}
+
+// SchemaJSON returns the indented JSON encoding of Schema.
+func SchemaJSON() ([]byte, error) {
+	return json.MarshalIndent(Schema(), "", "  ")
+}
+
+func schemaForType(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := schemaForType(t.Elem())
+		if typ, ok := schema["type"].(string); ok {
+			schema["type"] = []any{typ, "null"}
+		}
+		return schema
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": []any{"array", "null"}, "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []any{"object", "null"}, "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		var required []any
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, omitEmpty := jsonFieldName(field)
+			if name == "-" {
+				continue
+			}
+			properties[name] = schemaForType(field.Type)
+			if !omitEmpty {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]any{}
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "" {
+		return field.Name, false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" || opt == "omitzero" {
+			return name, true
+		}
+	}
+	return name, false
+}
+
+// ValidateJSON checks that data is a BrowserFingerprintWithHeaders payload matching Schema.
+func ValidateJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var problems []string
+	validateValue(Schema(), value, "$", &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("payload does not match the fingerprint schema v%d: %s", SchemaVersion, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func validateValue(schema map[string]any, value any, path string, problems *[]string) {
+	if !matchesType(schema["type"], value) {
+		*problems = append(*problems, fmt.Sprintf("%s: expected %v, got %s", path, schema["type"], jsonType(value)))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if propertySchema, ok := properties[k].(map[string]any); ok {
+				validateValue(propertySchema, v[k], path+"."+k, problems)
+			} else if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+				validateValue(additional, v[k], path+"."+k, problems)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	}
+}
+
+func matchesType(schemaType any, value any) bool {
+	switch t := schemaType.(type) {
+	case nil:
+		return true
+	case string:
+		actual := jsonType(value)
+		return actual == t || (t == "number" && actual == "integer")
+	case []any:
+		for _, option := range t {
+			if matchesType(option, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
@@ -0,0 +1,66 @@
+package fingerprint
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+
+	"fingerprint-go/internal/constants"
+)
+
+// maxWindowJitter is the most the height of a browser window is moved by ScreenJitter, in CSS pixels.
+const maxWindowJitter = 24
+
+// newScreenSeed returns the seed of the screen jitter of a generation, the seed of the replayed trace
+// when there is one.
+func newScreenSeed(options *FingerprintGeneratorOptions) uint64 {
+	if options.screenSeed != 0 {
+		return options.screenSeed
+	}
+	return rand.Uint64() | 1
+}
+
+// seedFraction maps seed and name to a number in [0, 1), the same for the same seed and name.
+func seedFraction(seed uint64, name string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	// The splitmix64 finalizer spreads seeds that differ in a few bits over the whole range.
+	x := seed ^ h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x>>11) / (1 << 53)
+}
+
+// jitterScreenOffsets moves screenX and the page offsets within the constants.ScreenOffsetStep bucket
+// the network builder rounded them down to. Zero offsets, a window at the screen edge or a page that is
+// not scrolled, are the most common values and stay zero.
+func jitterScreenOffsets(screen *ScreenFingerprint, seed uint64) {
+	offsets := map[string]*float64{"screenX": &screen.ScreenX, "pageXOffset": &screen.PageXOffset, "pageYOffset": &screen.PageYOffset}
+	for name, offset := range offsets {
+		if *offset == 0 {
+			continue
+		}
+		*offset += math.Floor(seedFraction(seed, name) * constants.ScreenOffsetStep)
+	}
+}
+
+// jitterWindow resizes the browser window of screen by up to maxWindowJitter pixels, so that the
+// fingerprints of the same screen record do not share the window size. The height of the window
+// decorations, outerHeight minus innerHeight, is kept and the window stays within the available screen
+// height. Maximized windows, as on mobile devices, keep their size.
+func jitterWindow(screen *ScreenFingerprint, seed uint64) {
+	if screen.InnerHeight <= 0 || screen.OuterHeight < screen.InnerHeight || screen.OuterHeight >= screen.AvailHeight {
+		return
+	}
+
+	delta := math.Floor(seedFraction(seed, "window")*(2*maxWindowJitter+1)) - maxWindowJitter
+	// Growing to the available height would make the window look maximized.
+	delta = min(delta, screen.AvailHeight-1-screen.OuterHeight)
+	delta = max(delta, 1-screen.InnerHeight)
+	screen.OuterHeight += delta
+	screen.InnerHeight += delta
+	if screen.ClientHeight > 0 {
+		screen.ClientHeight = max(screen.ClientHeight+delta, 0)
+	}
+}
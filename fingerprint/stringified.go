@@ -0,0 +1,39 @@
+package fingerprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// IsStringified reports whether value is a dataset value holding a JSON-encoded object, i.e. it
+// carries the STRINGIFIED_PREFIX.
+func IsStringified(value string) bool {
+	return strings.HasPrefix(value, STRINGIFIED_PREFIX)
+}
+
+// ParseStringified decodes a stringified dataset value, such as a value of the screen or
+// userAgentData node of the fingerprint network, into v.
+func ParseStringified(value string, v any) error {
+	if !IsStringified(value) {
+		return fmt.Errorf("The value %.32q is not a stringified dataset value.", value)
+	}
+	return json.Unmarshal([]byte(value[len(STRINGIFIED_PREFIX):]), v)
+}
+
+// ParseStringifiedScreen decodes a value of the screen node of the fingerprint network.
+func ParseStringifiedScreen(value string) (ScreenFingerprint, error) {
+	var screen ScreenFingerprint
+	err := ParseStringified(value, &screen)
+	return screen, err
+}
+
+// Stringify encodes v as a stringified dataset value, e.g. to constrain an object attribute with
+// FingerprintGeneratorOptions.Constraints.
+func Stringify(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return STRINGIFIED_PREFIX + string(b), nil
+}
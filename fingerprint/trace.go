@@ -0,0 +1,95 @@
+package fingerprint
+
+import (
+	"fmt"
+	"hash/fnv"
+	"maps"
+
+	"fingerprint-go/bayesian"
+)
+
+// GenerationAttempt traces one attempt of a fingerprint generation.
+type GenerationAttempt struct {
+	// Input traces the sampling of the browser, operating system and device the headers are generated
+	// for. It is nil when the headers were given.
+	Input *bayesian.SamplingTrace `json:"input,omitempty"`
+	// Fingerprint traces the sampling of the fingerprint attributes given the headers.
+	Fingerprint *bayesian.SamplingTrace `json:"fingerprint,omitempty"`
+	// Discarded tells why the attempt was retried, "" for the attempt that produced the fingerprint.
+	Discarded string `json:"discarded,omitempty"`
+}
+
+// GenerationTrace records how a fingerprint was generated, attempt by attempt. It is kept for the last
+// generation of a FingerprintGenerator with Debug set, see LastTrace, and can be marshalled to JSON
+// and replayed with Replay.
+type GenerationTrace struct {
+	Attempts []GenerationAttempt `json:"attempts"`
+	// Headers are the headers of the generated fingerprint.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Error is the error the generation failed with, "" when it succeeded.
+	Error string `json:"error,omitempty"`
+	// ScreenSeed is the seed of the screen jitter, 0 without ScreenJitter.
+	ScreenSeed uint64 `json:"screenSeed,omitempty"`
+}
+
+func (a *GenerationAttempt) discard(reason string) {
+	if a != nil {
+		a.Discarded = reason
+	}
+}
+
+// LastTrace returns the trace of the last fingerprint generated while Debug was set, or nil.
+func (g *FingerprintGenerator) LastTrace() *GenerationTrace {
+	g.traceMu.Lock()
+	defer g.traceMu.Unlock()
+	return g.lastTrace
+}
+
+func (g *FingerprintGenerator) storeTrace(trace *GenerationTrace) {
+	g.traceMu.Lock()
+	defer g.traceMu.Unlock()
+	g.lastTrace = trace
+}
+
+// Replay regenerates the fingerprint of a successful trace: the headers and every fingerprint network
+// value are those of the trace, and the remaining choices are derived from them. Given the same data
+// files and options as the traced generation, the result is the same fingerprint.
+func (g *FingerprintGenerator) Replay(trace *GenerationTrace, options *FingerprintGeneratorOptions) (*BrowserFingerprintWithHeaders, error) {
+	if trace == nil || trace.Error != "" || len(trace.Headers) == 0 {
+		return nil, fmt.Errorf("The trace is not the trace of a successful generation.")
+	}
+	var sample map[string]string
+	for _, attempt := range trace.Attempts {
+		if attempt.Discarded == "" && attempt.Fingerprint != nil && len(attempt.Fingerprint.Sample) > 0 {
+			sample = attempt.Fingerprint.Sample
+		}
+	}
+	if sample == nil {
+		return nil, fmt.Errorf("The trace has no fingerprint sample.")
+	}
+
+	replayed := FingerprintGeneratorOptions{}
+	if options != nil {
+		replayed = *options
+	}
+	replayed.Constraints = make(map[string][]string, len(sample))
+	for node, value := range sample {
+		if _, ok := g.fingerprintGeneratorNetwork.NodesByName[node]; !ok {
+			return nil, fmt.Errorf("The trace was recorded with other data files: the fingerprint network has no attribute named %q.", node)
+		}
+		replayed.Constraints[node] = []string{value}
+	}
+	replayed.AvoidSuspiciousValues = false
+	replayed.screenSeed = trace.ScreenSeed
+	replayed.ScreenJitter = trace.ScreenSeed != 0
+
+	return g.generateFingerprint(&replayed, nil, nil, maps.Clone(trace.Headers))
+}
+
+// stableFraction maps key to a number in [0, 1), the same for the same key. Choices made after
+// sampling use it instead of randomness, so that Replay reproduces them.
+func stableFraction(key string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return float64(h.Sum32()) / (1 << 32)
+}
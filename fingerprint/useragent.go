@@ -0,0 +1,160 @@
+package fingerprint
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"fingerprint-go/header"
+)
+
+// GetFingerprintForUserAgent generates a fingerprint and matching headers for exactly the given user agent.
+func (g *FingerprintGenerator) GetFingerprintForUserAgent(userAgent string, options *FingerprintGeneratorOptions, requestDependentHeaders map[string]string) (*BrowserFingerprintWithHeaders, error) {
+	datasetUserAgent, err := g.resolveUserAgent(userAgent, g.userAgentFallback(options))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := g.generateFingerprint(options, requestDependentHeaders, []string{datasetUserAgent}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if datasetUserAgent != userAgent {
+		setHeader(result.Headers, "user-agent", userAgent)
+		replaceUserAgent(&result.Fingerprint, datasetUserAgent, userAgent)
+		result.Relaxations = append(result.Relaxations, userAgentRelaxation(userAgent, datasetUserAgent))
+		applyBrands(&result.Fingerprint.Navigator.UserAgentData, userAgent)
+		applyClientHints(result.Headers, &result.Fingerprint.Navigator.UserAgentData)
+		applyFullVersionHints(result.Headers, &result.Fingerprint.Navigator.UserAgentData)
+		applyAndroidModel(&result.Fingerprint, result.Headers, "", true)
+	}
+	return result, nil
+}
+
+// GetFingerprintForHeaders generates a fingerprint consistent with an existing set of headers.
+// The headers are returned unchanged alongside the fingerprint, whose userAgentData reports the brands
+// and full versions of their client hints.
+func (g *FingerprintGenerator) GetFingerprintForHeaders(headers map[string]string, options *FingerprintGeneratorOptions) (*BrowserFingerprintWithHeaders, error) {
+	userAgent := header.GetUserAgent(headers)
+	if userAgent == "" {
+		return nil, fmt.Errorf("The provided headers do not contain a User-Agent.")
+	}
+
+	datasetUserAgent, err := g.resolveUserAgent(userAgent, g.userAgentFallback(options))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := g.generateFingerprint(options, nil, []string{datasetUserAgent}, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	if datasetUserAgent != userAgent {
+		replaceUserAgent(&result.Fingerprint, datasetUserAgent, userAgent)
+		result.Relaxations = append(result.Relaxations, userAgentRelaxation(userAgent, datasetUserAgent))
+		applyBrands(&result.Fingerprint.Navigator.UserAgentData, userAgent)
+		applyHeaderClientHints(&result.Fingerprint.Navigator.UserAgentData, headers, userAgent)
+		applyAndroidModel(&result.Fingerprint, result.Headers, "", false)
+	}
+	return result, nil
+}
+
+func (g *FingerprintGenerator) userAgentFallback(options *FingerprintGeneratorOptions) bool {
+	if options != nil {
+		return options.UserAgentFallback
+	}
+	return g.fingerprintGlobalOptions.UserAgentFallback
+}
+
+// resolveUserAgent returns the dataset user agent to constrain the fingerprint network on.
+func (g *FingerprintGenerator) resolveUserAgent(userAgent string, fallback bool) (string, error) {
+	if err := header.ValidateUserAgent(userAgent); err != nil {
+		return "", err
+	}
+	node, ok := g.fingerprintGeneratorNetwork.NodesByName["userAgent"]
+	if !ok {
+		return userAgent, nil
+	}
+
+	for _, value := range node.Definition.PossibleValues {
+		if value == userAgent {
+			return userAgent, nil
+		}
+	}
+
+	if !fallback {
+		return "", fmt.Errorf("The user agent %q is not present in the fingerprint dataset. Enable UserAgentFallback to use the nearest known version instead.", userAgent)
+	}
+
+	nearest := nearestUserAgents(userAgent, node.Definition.PossibleValues)
+	if len(nearest) == 0 {
+		return "", fmt.Errorf("The user agent %q is not present in the fingerprint dataset and no version of the same browser major version on the same operating system and device type is known.", userAgent)
+	}
+	return nearest[rand.Intn(len(nearest))], nil
+}
+
+// nearestUserAgents returns the candidates closest to userAgent. Candidates must be the same browser
+// major version on the same operating system and device type, as the rest of the fingerprint, the
+// brands of its client hints among them, is sampled for them; among those, the closest minor, build
+// and patch numbers are preferred.
+func nearestUserAgents(userAgent string, candidates []string) []string {
+	browser := header.GetBrowser(userAgent)
+	version := header.GetBrowserVersion(userAgent)
+	if browser == "" || version == nil {
+		return nil
+	}
+	operatingSystem := header.GetOperatingSystem(userAgent)
+	mobile := strings.Contains(strings.ToLower(userAgent), "mobile")
+
+	var nearest []string
+	var bestScore []int
+	for _, candidate := range candidates {
+		if header.GetBrowser(candidate) != browser || header.GetOperatingSystem(candidate) != operatingSystem ||
+			strings.Contains(strings.ToLower(candidate), "mobile") != mobile {
+			continue
+		}
+		candidateVersion := header.GetBrowserVersion(candidate)
+		if candidateVersion == nil || candidateVersion[0] != version[0] {
+			continue
+		}
+
+		score := make([]int, 0, 4)
+		for i := 0; i < 4; i++ {
+			a, b := 0, 0
+			if i < len(version) {
+				a = version[i]
+			}
+			if i < len(candidateVersion) {
+				b = candidateVersion[i]
+			}
+			score = append(score, max(a-b, b-a))
+		}
+
+		switch cmp := slices.Compare(score, bestScore); {
+		case bestScore == nil || cmp < 0:
+			bestScore = score
+			nearest = []string{candidate}
+		case cmp == 0:
+			nearest = append(nearest, candidate)
+		}
+	}
+	return nearest
+}
+
+func userAgentRelaxation(requested string, used string) Relaxation {
+	return Relaxation{
+		Constraint: "userAgent",
+		Reason:     fmt.Sprintf("%q is not in the dataset, the fingerprint was sampled for %q", requested, used),
+	}
+}
+
+// replaceUserAgent makes the fingerprint report userAgent instead of the dataset user agent it was sampled for.
+func replaceUserAgent(fp *Fingerprint, datasetUserAgent string, userAgent string) {
+	fp.Navigator.UserAgent = userAgent
+	if fp.Navigator.AppVersion == strings.TrimPrefix(datasetUserAgent, "Mozilla/") {
+		fp.Navigator.AppVersion = strings.TrimPrefix(userAgent, "Mozilla/")
+	}
+}
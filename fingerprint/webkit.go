@@ -0,0 +1,168 @@
+package fingerprint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"fingerprint-go/header"
+)
+
+// iosVideoCodecs and iosAudioCodecs are what HTMLMediaElement.canPlayType returns in iOS Safari for
+// the codecs of the dataset.
+var (
+	iosVideoCodecs = map[string]string{"ogg": "", "h264": "probably", "webm": ""}
+	iosAudioCodecs = map[string]string{"ogg": "", "mp3": "maybe", "wav": "maybe", "m4a": "maybe", "aac": "maybe"}
+)
+
+// webKitAbsentAPIs are the Chromium APIs WebKit does not implement, which a Chromium browser injected
+// with a WebKit fingerprint would otherwise still expose.
+var webKitAbsentAPIs = []string{
+	"window.chrome",
+	"window.webkitRequestFileSystem",
+	"window.webkitResolveLocalFileSystemURL",
+	"navigator.getBattery",
+	"navigator.deviceMemory",
+	"navigator.userAgentData",
+	"navigator.connection",
+	"navigator.webkitTemporaryStorage",
+	"navigator.webkitPersistentStorage",
+	"navigator.hid",
+	"navigator.serial",
+	"navigator.usb",
+}
+
+// webKitStorage is the storage of WebKit under Intelligent Tracking Prevention: no third-party
+// cookies, partitioned third-party storage and script-writable storage deleted after 7 days of use
+// without interaction.
+var webKitStorage = StorageFingerprint{ThirdPartyCookies: false, Partitioned: true, ScriptStorageDays: 7}
+
+// IOSSafariOptions returns the options of an iPhone running Safari. The fingerprints get the
+// WebKit quirks every iOS fingerprint gets, see WebKitProblem.
+func IOSSafariOptions() *FingerprintGeneratorOptions {
+	return &FingerprintGeneratorOptions{
+		HeaderGeneratorOptions: &header.HeaderGeneratorOptions{
+			Browsers:         []any{"safari"},
+			OperatingSystems: []string{"ios"},
+			Devices:          []string{"mobile"},
+		},
+	}
+}
+
+// isWebKit reports whether the browser of userAgent is built on WebKit: Safari, and every browser on
+// iOS.
+func isWebKit(userAgent string) bool {
+	return header.GetOperatingSystem(userAgent) == "ios" || header.GetBrowser(userAgent) == "safari"
+}
+
+// webKitHardwareConcurrency is what WebKit reports for cores logical cores: 4 below 8 cores and 8
+// otherwise, so that the number of cores does not tell devices apart.
+func webKitHardwareConcurrency(cores int) int {
+	if cores < 8 {
+		return 4
+	}
+	return 8
+}
+
+// iosPlatform returns the navigator.platform of an iOS user agent.
+func iosPlatform(userAgent string) string {
+	for _, platform := range []string{"iPad", "iPod"} {
+		if strings.Contains(userAgent, platform) {
+			return platform
+		}
+	}
+	return "iPhone"
+}
+
+// WebKitProblem describes why fp is impossible for a WebKit browser, or returns "" when it is
+// plausible or not a WebKit browser: WebKit does not implement navigator.deviceMemory,
+// navigator.userAgentData or the Battery Status API, reports 4 or 8 cores and blocks third-party
+// cookies. On iOS there are no plugins, navigator.platform names the device and the GPU is the
+// "Apple GPU".
+func WebKitProblem(fp *Fingerprint) string {
+	nav := &fp.Navigator
+	if !isWebKit(nav.UserAgent) {
+		return ""
+	}
+	switch {
+	case nav.DeviceMemory != nil:
+		return "WebKit does not expose navigator.deviceMemory"
+	case len(nav.UserAgentData.Brands) > 0:
+		return "WebKit does not expose navigator.userAgentData"
+	case len(fp.Battery) > 0:
+		return "WebKit does not implement navigator.getBattery"
+	case nav.HardwareConcurrency != 0 && nav.HardwareConcurrency != webKitHardwareConcurrency(nav.HardwareConcurrency):
+		return fmt.Sprintf("WebKit reports 4 or 8 cores, not %d", nav.HardwareConcurrency)
+	case nav.Vendor != "" && nav.Vendor != "Apple Computer, Inc.":
+		return fmt.Sprintf("navigator.vendor of WebKit is %q", nav.Vendor)
+	case fp.Storage != nil && fp.Storage.ThirdPartyCookies:
+		return "WebKit blocks third-party cookies"
+	}
+	if header.GetOperatingSystem(nav.UserAgent) != "ios" {
+		return ""
+	}
+
+	switch {
+	case nav.Platform != "" && nav.Platform != iosPlatform(nav.UserAgent):
+		return fmt.Sprintf("navigator.platform %q does not match the iOS device of the user agent", nav.Platform)
+	case nav.Platform == "iPhone" && nav.HardwareConcurrency > 4:
+		return fmt.Sprintf("an iPhone reports 4 cores, not %d", nav.HardwareConcurrency)
+	case nav.MaxTouchPoints == nil || *nav.MaxTouchPoints != 5:
+		return "iOS devices report 5 touch points"
+	case fp.VideoCard.Renderer != "" && fp.VideoCard.Renderer != "Apple GPU":
+		return fmt.Sprintf("iOS reports the WebGL renderer \"Apple GPU\", not %q", fp.VideoCard.Renderer)
+	}
+	for attribute, value := range fp.PluginsData {
+		if value != "" && value != "[]" {
+			return fmt.Sprintf("iOS has no %s", attribute)
+		}
+	}
+	return ""
+}
+
+// applyWebKitQuirks makes fp report what WebKit reports, see WebKitProblem, marks the Chromium APIs
+// WebKit lacks as absent, sets the storage restrictions of Intelligent Tracking Prevention and the codecs
+// of iOS Safari.
+func applyWebKitQuirks(fp *Fingerprint) {
+	nav := &fp.Navigator
+	if !isWebKit(nav.UserAgent) {
+		return
+	}
+	nav.DeviceMemory = nil
+	nav.UserAgentData = UserAgentData{}
+	fp.Battery = nil
+	if nav.HardwareConcurrency != 0 {
+		nav.HardwareConcurrency = webKitHardwareConcurrency(nav.HardwareConcurrency)
+	}
+	nav.Vendor = "Apple Computer, Inc."
+	fp.AbsentAPIs = slices.Clone(webKitAbsentAPIs)
+	storage := webKitStorage
+	fp.Storage = &storage
+	if header.GetOperatingSystem(nav.UserAgent) != "ios" {
+		return
+	}
+
+	nav.Platform = iosPlatform(nav.UserAgent)
+	if nav.Platform == "iPhone" {
+		// Every iPhone has fewer than 8 cores.
+		nav.HardwareConcurrency = 4
+	}
+	touchPoints := 5
+	nav.MaxTouchPoints = &touchPoints
+	fp.VideoCard = VideoCard{Vendor: "Apple Inc.", Renderer: "Apple GPU"}
+	for attribute := range fp.PluginsData {
+		fp.PluginsData[attribute] = "[]"
+	}
+	if header.GetBrowser(nav.UserAgent) == "safari" {
+		for codec := range fp.VideoCodecs {
+			if support, ok := iosVideoCodecs[codec]; ok {
+				fp.VideoCodecs[codec] = support
+			}
+		}
+		for codec := range fp.AudioCodecs {
+			if support, ok := iosAudioCodecs[codec]; ok {
+				fp.AudioCodecs[codec] = support
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package fingerprint
+
+import (
+	"fmt"
+
+	"fingerprint-go/header"
+)
+
+// WindowChrome is the size of the browser UI around the page of a window that is not maximized, in
+// CSS pixels: outerWidth minus innerWidth and outerHeight minus innerHeight.
+type WindowChrome struct {
+	// Width is the width of the window borders.
+	Width float64
+	// Height is the height of the title bar, tab strip and toolbar.
+	Height float64
+	// BookmarksBar is the height the bookmarks or favorites bar adds when it is shown.
+	BookmarksBar float64
+	// BookmarksBarShare is the share of windows showing the bookmarks bar.
+	BookmarksBarShare float64
+}
+
+// windowChromes are the window chrome sizes of the desktop browsers per operating system at the
+// default zoom and UI density.
+var windowChromes = map[string]map[string]WindowChrome{
+	"chrome": {
+		"windows": {Width: 16, Height: 86, BookmarksBar: 28, BookmarksBarShare: 0.3},
+		"macos":   {Width: 0, Height: 79, BookmarksBar: 28, BookmarksBarShare: 0.3},
+		"linux":   {Width: 0, Height: 85, BookmarksBar: 28, BookmarksBarShare: 0.3},
+	},
+	"edge": {
+		"windows": {Width: 16, Height: 87, BookmarksBar: 28, BookmarksBarShare: 0.35},
+		"macos":   {Width: 0, Height: 80, BookmarksBar: 28, BookmarksBarShare: 0.35},
+		"linux":   {Width: 0, Height: 86, BookmarksBar: 28, BookmarksBarShare: 0.35},
+	},
+	"firefox": {
+		"windows": {Width: 16, Height: 74, BookmarksBar: 29, BookmarksBarShare: 0.25},
+		"macos":   {Width: 0, Height: 80, BookmarksBar: 29, BookmarksBarShare: 0.25},
+		"linux":   {Width: 0, Height: 79, BookmarksBar: 29, BookmarksBarShare: 0.25},
+	},
+	"safari": {
+		"macos": {Width: 0, Height: 74, BookmarksBar: 29, BookmarksBarShare: 0.2},
+	},
+}
+
+// WindowChromeSize returns the window chrome of browser ("chrome", "edge", "firefox" or "safari") on
+// a desktop operatingSystem ("windows", "macos" or "linux"). ok is false for other combinations,
+// including mobile browsers, whose pages fill the window.
+func WindowChromeSize(browser string, operatingSystem string) (chrome WindowChrome, ok bool) {
+	chrome, ok = windowChromes[browser][operatingSystem]
+	return chrome, ok
+}
+
+// OuterWindowSize returns the outer size of a window that is not maximized from the size of its
+// page.
+func OuterWindowSize(innerWidth, innerHeight float64, chrome WindowChrome, bookmarksBar bool) (outerWidth, outerHeight float64) {
+	outerHeight = innerHeight + chrome.Height
+	if bookmarksBar {
+		outerHeight += chrome.BookmarksBar
+	}
+	return innerWidth + chrome.Width, outerHeight
+}
+
+// InnerWindowSize returns the size of the page of a window resized to the outer size, e.g. with
+// window.resizeTo, so that injected values stay consistent after a resize.
+func InnerWindowSize(outerWidth, outerHeight float64, chrome WindowChrome, bookmarksBar bool) (innerWidth, innerHeight float64) {
+	innerHeight = outerHeight - chrome.Height
+	if bookmarksBar {
+		innerHeight -= chrome.BookmarksBar
+	}
+	return max(outerWidth-chrome.Width, 0), max(innerHeight, 0)
+}
+
+// applyWindowChrome derives the outer size of the window from its inner size and the window chrome of
+// the browser, instead of keeping the dataset's combination. Maximized windows fill the available
+// screen, so their page gets the space the chrome leaves. Whether the bookmarks bar is shown is
+// derived from the sampled values, so that Replay reproduces it.
+func applyWindowChrome(fp *Fingerprint) {
+	userAgent := fp.Navigator.UserAgent
+	chrome, ok := WindowChromeSize(header.GetBrowser(userAgent), header.GetOperatingSystem(userAgent))
+	screen := &fp.Screen
+	if !ok || screen.InnerHeight <= 0 || screen.AvailHeight <= 0 {
+		return
+	}
+	bookmarksBar := stableFraction(fmt.Sprint(userAgent, *screen)) < chrome.BookmarksBarShare
+
+	innerHeight := screen.InnerHeight
+	if screen.OuterHeight >= screen.AvailHeight {
+		_, screen.InnerHeight = InnerWindowSize(screen.OuterWidth, screen.AvailHeight, chrome, bookmarksBar)
+		screen.OuterHeight = screen.AvailHeight
+	} else {
+		screen.OuterWidth, screen.OuterHeight = OuterWindowSize(screen.InnerWidth, screen.InnerHeight, chrome, bookmarksBar)
+		if screen.OuterHeight >= screen.AvailHeight {
+			screen.OuterHeight = screen.AvailHeight - 1
+			_, screen.InnerHeight = InnerWindowSize(screen.OuterWidth, screen.OuterHeight, chrome, bookmarksBar)
+		}
+	}
+	if screen.ClientHeight > 0 {
+		screen.ClientHeight = max(screen.ClientHeight+screen.InnerHeight-innerHeight, 0)
+	}
+}
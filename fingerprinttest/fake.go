@@ -0,0 +1,243 @@
+// Package fingerprinttest provides a deterministic fake of the generators for tests of code that
+// depends on fingerprint.FingerprintProvider or header.HeaderProvider, without any data files.
+package fingerprinttest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"fingerprint-go/fingerprint"
+	"fingerprint-go/header"
+)
+
+// Profile is a fixed identity returned by the Fake.
+type Profile struct {
+	Browser         string
+	OperatingSystem string
+	Device          string
+	Headers         map[string]string
+	Fingerprint     fingerprint.Fingerprint
+}
+
+// Fake returns fixed profiles: the first one matching the requested browsers, operating systems and
+// devices. It records the number of calls.
+type Fake struct {
+	Profiles []Profile
+
+	mu    sync.Mutex
+	calls int
+}
+
+var (
+	_ fingerprint.FingerprintProvider = (*Fake)(nil)
+	_ header.HeaderProvider           = (*Fake)(nil)
+)
+
+// NewFake returns a Fake with the DefaultProfiles.
+func NewFake() *Fake {
+	return &Fake{Profiles: DefaultProfiles()}
+}
+
+// Calls returns the number of generation calls made so far.
+func (f *Fake) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *Fake) pick(options *header.HeaderGeneratorOptions, userAgentValues []string) (*Profile, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+
+	for i := range f.Profiles {
+		p := &f.Profiles[i]
+		if len(userAgentValues) > 0 && !slices.Contains(userAgentValues, p.Fingerprint.Navigator.UserAgent) {
+			continue
+		}
+		if options != nil {
+			if len(options.Browsers) > 0 && !slices.ContainsFunc(options.Browsers, func(b any) bool {
+				switch v := b.(type) {
+				case string:
+					return v == p.Browser
+				case header.BrowserSpecification:
+					return v.Name == p.Browser
+				}
+				return false
+			}) {
+				continue
+			}
+			if operatingSystems := header.PrepareOperatingSystems(options); len(operatingSystems) > 0 {
+				if !slices.ContainsFunc(operatingSystems, func(os header.OperatingSystemSpecification) bool { return os.Name == p.OperatingSystem }) {
+					continue
+				}
+				if version, ok := header.GetOperatingSystemVersion(p.Fingerprint.Navigator.UserAgent); ok && !header.OperatingSystemVersionAllowed(operatingSystems, p.OperatingSystem, version) {
+					continue
+				}
+			}
+			if len(options.Devices) > 0 && !slices.Contains(options.Devices, p.Device) {
+				continue
+			}
+		}
+		return p, nil
+	}
+	return nil, fmt.Errorf("No headers based on this input can be generated. Please relax or change some of the requirements you specified.")
+}
+
+func (f *Fake) GetHeaders(options *header.HeaderGeneratorOptions, requestDependentHeaders map[string]string, userAgentValues []string) (map[string]string, error) {
+	p, err := f.pick(options, userAgentValues)
+	if err != nil {
+		return nil, err
+	}
+	headers := maps.Clone(p.Headers)
+	maps.Copy(headers, requestDependentHeaders)
+	return headers, nil
+}
+
+func (f *Fake) GetHeadersForRequest(options *header.HeaderGeneratorOptions, request *header.Request) (map[string]string, error) {
+	if request == nil {
+		request = &header.Request{}
+	}
+	var userAgentValues []string
+	if request.UserAgent != "" {
+		userAgentValues = []string{request.UserAgent}
+	}
+	return f.GetHeaders(options, request.Headers, userAgentValues)
+}
+
+func (f *Fake) GetFingerprint(options *fingerprint.FingerprintGeneratorOptions, requestDependentHeaders map[string]string) (*fingerprint.BrowserFingerprintWithHeaders, error) {
+	var headerOptions *header.HeaderGeneratorOptions
+	if options != nil {
+		headerOptions = options.HeaderGeneratorOptions
+	}
+	p, err := f.pick(headerOptions, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := maps.Clone(p.Headers)
+	maps.Copy(headers, requestDependentHeaders)
+	fp := p.Fingerprint
+	if options != nil {
+		fp.MockWebRTC = options.MockWebRTC
+		fp.Slim = options.Slim
+	}
+	return &fingerprint.BrowserFingerprintWithHeaders{
+		SchemaVersion: fingerprint.SchemaVersion,
+		Headers:       headers,
+		Fingerprint:   fp,
+		Info:          header.InfoFromHeaders(headers),
+	}, nil
+}
+
+func (f *Fake) GetHeadersForFingerprint(fp *fingerprint.Fingerprint, requestDependentHeaders map[string]string) (map[string]string, error) {
+	return f.GetHeaders(nil, requestDependentHeaders, []string{fp.Navigator.UserAgent})
+}
+
+// DefaultProfiles returns a Chrome on Windows, a Firefox on Linux and a Safari on macOS identity.
+func DefaultProfiles() []Profile {
+	return []Profile{
+		newProfile("chrome", "windows", "Win32",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			"Google Inc.", "ANGLE (NVIDIA, NVIDIA GeForce GTX 1650 Direct3D11 vs_5_0 ps_5_0, D3D11)", "Google Inc. (NVIDIA)",
+			map[string]string{
+				"sec-ch-ua":                 `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`,
+				"sec-ch-ua-mobile":          "?0",
+				"sec-ch-ua-platform":        `"Windows"`,
+				"upgrade-insecure-requests": "1",
+				"accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7",
+				"sec-fetch-site":            "same-site",
+				"sec-fetch-mode":            "navigate",
+				"sec-fetch-user":            "?1",
+				"sec-fetch-dest":            "document",
+				"accept-encoding":           "gzip, deflate, br, zstd",
+				"accept-language":           "en-US,en;q=0.9",
+			}),
+		newProfile("firefox", "linux", "Linux x86_64",
+			"Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			"", "Mesa Intel(R) UHD Graphics 620 (KBL GT2)", "Intel",
+			map[string]string{
+				"accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+				"accept-language":           "en-US,en;q=0.5",
+				"accept-encoding":           "gzip, deflate, br",
+				"upgrade-insecure-requests": "1",
+				"sec-fetch-dest":            "document",
+				"sec-fetch-mode":            "navigate",
+				"sec-fetch-site":            "same-site",
+				"sec-fetch-user":            "?1",
+				"te":                        "trailers",
+			}),
+		newProfile("safari", "macos", "MacIntel",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+			"Apple Computer, Inc.", "Apple GPU", "Apple Inc.",
+			map[string]string{
+				"accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+				"accept-language": "en-US,en;q=0.9",
+				"accept-encoding": "gzip, deflate, br",
+			}),
+	}
+}
+
+func newProfile(browser, operatingSystem, platform, userAgent, vendor, renderer, gpuVendor string, headers map[string]string) Profile {
+	headers["user-agent"] = userAgent
+	memory := 8.0
+	touchPoints := 0
+
+	fp := fingerprint.Fingerprint{
+		Screen: fingerprint.ScreenFingerprint{
+			AvailHeight: 1040, AvailWidth: 1920, ColorDepth: 24, PixelDepth: 24, Height: 1080, Width: 1920,
+			DevicePixelRatio: 1, InnerHeight: 955, InnerWidth: 1920, OuterHeight: 1040, OuterWidth: 1920,
+			ClientWidth: 1903, ClientHeight: 955,
+		},
+		Navigator: fingerprint.NavigatorFingerprint{
+			UserAgent:           userAgent,
+			Language:            "en-US",
+			Languages:           []string{"en-US", "en"},
+			Platform:            platform,
+			HardwareConcurrency: 8,
+			MaxTouchPoints:      &touchPoints,
+			Product:             "Gecko",
+			ProductSub:          "20030107",
+			Vendor:              vendor,
+			AppCodeName:         "Mozilla",
+			AppName:             "Netscape",
+			AppVersion:          userAgent[len("Mozilla/"):],
+			Webdriver:           "false",
+		},
+		VideoCard: fingerprint.VideoCard{Renderer: renderer, Vendor: gpuVendor},
+		Fonts:     []string{},
+	}
+
+	switch browser {
+	case "chrome":
+		fp.Navigator.DeviceMemory = &memory
+		fp.Navigator.UserAgentData = fingerprint.UserAgentData{
+			Brands: []fingerprint.Brand{
+				{Brand: "Chromium", Version: "124"}, {Brand: "Google Chrome", Version: "124"}, {Brand: "Not-A.Brand", Version: "99"},
+			},
+			Platform: "Windows", Architecture: "x86", Bitness: "64", PlatformVersion: "15.0.0", UaFullVersion: "124.0.6367.91",
+			FullVersionList: []fingerprint.Brand{
+				{Brand: "Chromium", Version: "124.0.6367.91"}, {Brand: "Google Chrome", Version: "124.0.6367.91"}, {Brand: "Not-A.Brand", Version: "99.0.0.0"},
+			},
+		}
+		fp.Navigator.ExtraProperties.VendorFlavors = []string{"chrome"}
+		fp.Navigator.ExtraProperties.PdfViewerEnabled = true
+	case "firefox":
+		fp.Navigator.ProductSub = "20100101"
+		fp.Navigator.AppVersion = "5.0 (X11)"
+		fp.Navigator.Oscpu = "Linux x86_64"
+		fp.Navigator.ExtraProperties.PdfViewerEnabled = true
+	case "safari":
+		fp.Navigator.ExtraProperties.PdfViewerEnabled = true
+	}
+
+	return Profile{
+		Browser:         browser,
+		OperatingSystem: operatingSystem,
+		Device:          "desktop",
+		Headers:         headers,
+		Fingerprint:     fp,
+	}
+}
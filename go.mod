@@ -1,3 +1,8 @@
 module fingerprint-go
 
 go 1.25.0
+
+require (
+	github.com/BurntSushi/toml v1.4.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,120 @@
+package header
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"fingerprint-go/uautil"
+)
+
+// ClientHintBrand is an entry of the sec-ch-ua brand list.
+type ClientHintBrand struct {
+	Brand   string
+	Version string
+}
+
+var clientHintBrandNames = map[string]string{
+	"chrome":  "Google Chrome",
+	"edge":    "Microsoft Edge",
+	"webview": "Android WebView",
+}
+
+// Since Chrome 105 the GREASE brand, its version and the brand order are derived from the major version.
+var (
+	greaseyChars     = []string{" ", "(", ":", "-", ".", "/", ")", ";", "=", "?", "_"}
+	greasedVersions  = []string{"8", "99", "24"}
+	brandPermutation = [][3]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+)
+
+// ClientHintBrandName returns the sec-ch-ua brand of browser, e.g. "Google Chrome", or "".
+func ClientHintBrandName(browser string) string {
+	return clientHintBrandNames[browser]
+}
+
+// ClientHintBrands returns the sec-ch-ua brand list a Chromium-based browser of majorVersion reports,
+// or nil for other browsers. chromiumVersion and browserVersion are the versions of the Chromium and
+// browser brands: major versions for sec-ch-ua, full versions for sec-ch-ua-full-version-list.
+func ClientHintBrands(browser string, majorVersion int, chromiumVersion string, browserVersion string) []ClientHintBrand {
+	name, ok := clientHintBrandNames[browser]
+	if !ok {
+		return nil
+	}
+	full := strings.Contains(chromiumVersion, ".")
+	chromium := ClientHintBrand{Brand: "Chromium", Version: chromiumVersion}
+	brand := ClientHintBrand{Brand: name, Version: browserVersion}
+
+	switch {
+	case majorVersion < 89:
+		return []ClientHintBrand{chromium, brand, {Brand: ";Not A Brand", Version: greaseVersion("99", full)}}
+	case majorVersion < 105:
+		return []ClientHintBrand{{Brand: " Not A;Brand", Version: greaseVersion("99", full)}, chromium, brand}
+	}
+
+	grease := ClientHintBrand{
+		Brand: "Not" + greaseyChars[majorVersion%len(greaseyChars)] + "A" +
+			greaseyChars[(majorVersion+1)%len(greaseyChars)] + "Brand",
+		Version: greaseVersion(greasedVersions[majorVersion%len(greasedVersions)], full),
+	}
+	order := brandPermutation[majorVersion%len(brandPermutation)]
+	brands := make([]ClientHintBrand, 3)
+	brands[order[0]] = grease
+	brands[order[1]] = chromium
+	brands[order[2]] = brand
+	return brands
+}
+
+func greaseVersion(version string, full bool) string {
+	if full {
+		return version + ".0.0.0"
+	}
+	return version
+}
+
+// FormatClientHintBrands formats brands as a sec-ch-ua header value.
+func FormatClientHintBrands(brands []ClientHintBrand) string {
+	parts := make([]string, 0, len(brands))
+	for _, b := range brands {
+		parts = append(parts, fmt.Sprintf(`"%s";v="%s"`, b.Brand, b.Version))
+	}
+	return strings.Join(parts, ", ")
+}
+
+var clientHintBrandPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"\s*;\s*v\s*=\s*"([^"]*)"`)
+
+// ParseClientHintBrands parses a sec-ch-ua or sec-ch-ua-full-version-list header value, the reverse of
+// FormatClientHintBrands. Malformed entries are skipped.
+func ParseClientHintBrands(value string) []ClientHintBrand {
+	var brands []ClientHintBrand
+	for _, match := range clientHintBrandPattern.FindAllStringSubmatch(value, -1) {
+		brands = append(brands, ClientHintBrand{Brand: match[1], Version: match[2]})
+	}
+	return brands
+}
+
+// GetChromiumVersion returns the version components of the Chromium engine in userAgent, or nil.
+func GetChromiumVersion(userAgent string) []int {
+	return uautil.ChromiumVersion(userAgent)
+}
+
+// GetClientHintBrowser returns the browser of userAgent as used by ClientHintBrands, which tells
+// Android WebViews ("webview") apart from Chrome.
+func GetClientHintBrowser(userAgent string) string {
+	if IsWebView(userAgent) {
+		return "webview"
+	}
+	return GetBrowser(userAgent)
+}
+
+// userAgentClientHintBrands returns the sec-ch-ua brand list of userAgent, or nil when it is not a
+// Chromium-based browser.
+func userAgentClientHintBrands(userAgent string) []ClientHintBrand {
+	browser := GetClientHintBrowser(userAgent)
+	version := GetBrowserVersion(userAgent)
+	chromium := GetChromiumVersion(userAgent)
+	if len(version) == 0 || len(chromium) == 0 {
+		return nil
+	}
+	return ClientHintBrands(browser, version[0], strconv.Itoa(chromium[0]), strconv.Itoa(version[0]))
+}
@@ -0,0 +1,53 @@
+package header
+
+import (
+	"strings"
+)
+
+// CacheMode selects the cache related headers of a request.
+type CacheMode int
+
+const (
+	// CacheModeDefault sends no cache headers, like a normal navigation.
+	CacheModeDefault CacheMode = iota
+	// CacheModeReload is a regular reload: Cache-Control: max-age=0.
+	CacheModeReload
+	// CacheModeNoCache is a hard reload: Cache-Control: no-cache and the legacy Pragma: no-cache.
+	CacheModeNoCache
+)
+
+// CacheValidators are the validators of a cached response, sent back as conditional request headers.
+type CacheValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// applyCacheHeaders sets the cache headers of mode and the conditional headers of validators. Hard reloads
+// bypass the cache, so they never send validators.
+func applyCacheHeaders(headers map[string]string, mode CacheMode, validators *CacheValidators) {
+	for k := range headers {
+		switch strings.ToLower(k) {
+		case "cache-control", "pragma", "if-none-match", "if-modified-since":
+			delete(headers, k)
+		}
+	}
+
+	switch mode {
+	case CacheModeReload:
+		headers[headerName(headers, "Cache-Control")] = "max-age=0"
+	case CacheModeNoCache:
+		headers[headerName(headers, "Pragma")] = "no-cache"
+		headers[headerName(headers, "Cache-Control")] = "no-cache"
+		return
+	}
+
+	if validators == nil {
+		return
+	}
+	if validators.ETag != "" {
+		headers[headerName(headers, "If-None-Match")] = validators.ETag
+	}
+	if validators.LastModified != "" {
+		headers[headerName(headers, "If-Modified-Since")] = validators.LastModified
+	}
+}
@@ -0,0 +1,147 @@
+package header
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CapabilitiesFormatVersion is the format version of capabilities.json, stored in its "formatVersion"
+// field.
+const CapabilitiesFormatVersion = 1
+
+// Capabilities records which browser versions send which headers and content codings, as the first
+// version of each browser, e.g. "16.4". Browsers missing from an entry are not restricted. Header
+// generation drops what the generated browser version does not send yet, and the verify package checks
+// headers against it, so both agree on the version thresholds.
+type Capabilities struct {
+	FormatVersion int `json:"formatVersion"`
+	// Headers maps lowercase header names to the first browser versions sending them.
+	Headers map[string]map[string]string `json:"headers"`
+	// Encodings maps Accept-Encoding content codings to the first browser versions accepting them.
+	Encodings map[string]map[string]string `json:"encodings"`
+}
+
+// DefaultCapabilities is the bundled capability matrix. Data files override it with a
+// capabilities.json of the same shape, which build-data writes from it.
+var DefaultCapabilities = &Capabilities{
+	FormatVersion: CapabilitiesFormatVersion,
+	Headers: map[string]map[string]string{
+		"sec-fetch-site":              {"chrome": "76", "edge": "79", "firefox": "90", "safari": "16.4"},
+		"sec-fetch-mode":              {"chrome": "76", "edge": "79", "firefox": "90", "safari": "16.4"},
+		"sec-fetch-user":              {"chrome": "76", "edge": "79", "firefox": "90", "safari": "16.4"},
+		"sec-fetch-dest":              {"chrome": "80", "edge": "80", "firefox": "90", "safari": "16.4"},
+		"sec-ch-ua":                   {"chrome": "89", "edge": "89"},
+		"sec-ch-ua-mobile":            {"chrome": "89", "edge": "89"},
+		"sec-ch-ua-platform":          {"chrome": "93", "edge": "93"},
+		"sec-ch-ua-arch":              {"chrome": "89", "edge": "89"},
+		"sec-ch-ua-model":             {"chrome": "89", "edge": "89"},
+		"sec-ch-ua-full-version":      {"chrome": "89", "edge": "89"},
+		"sec-ch-ua-platform-version":  {"chrome": "89", "edge": "89"},
+		"sec-ch-ua-bitness":           {"chrome": "93", "edge": "93"},
+		"sec-ch-ua-full-version-list": {"chrome": "98", "edge": "98"},
+		"sec-ch-ua-wow64":             {"chrome": "100", "edge": "100"},
+		"rtt":                         {"chrome": "67", "edge": "79"},
+		"ect":                         {"chrome": "67", "edge": "79"},
+		"downlink":                    {"chrome": "67", "edge": "79"},
+		"priority":                    {"chrome": "124", "edge": "124"},
+	},
+	Encodings: map[string]map[string]string{
+		"br":   {"chrome": "50", "edge": "79", "firefox": "44", "safari": "11"},
+		"zstd": {"chrome": "123", "edge": "123", "firefox": "126"},
+	},
+}
+
+// LoadCapabilities parses a capabilities.json data file. Files of a newer format version are rejected
+// instead of being misread.
+func LoadCapabilities(data []byte) (*Capabilities, error) {
+	var capabilities Capabilities
+	if err := json.Unmarshal(data, &capabilities); err != nil {
+		return nil, err
+	}
+	if capabilities.FormatVersion > CapabilitiesFormatVersion {
+		return nil, fmt.Errorf("The capabilities format version %d is newer than the supported version %d.",
+			capabilities.FormatVersion, CapabilitiesFormatVersion)
+	}
+	return &capabilities, nil
+}
+
+// SupportsHeader reports whether version of browser sends the header name.
+func (c *Capabilities) SupportsHeader(browser string, version []int, name string) bool {
+	return versionSince(c.Headers[strings.ToLower(name)][browser], version)
+}
+
+// SupportsEncoding reports whether version of browser accepts the content coding.
+func (c *Capabilities) SupportsEncoding(browser string, version []int, coding string) bool {
+	return versionSince(c.Encodings[strings.ToLower(coding)][browser], version)
+}
+
+// versionSince reports whether version is at least since. An empty since or version is not restricted.
+func versionSince(since string, version []int) bool {
+	if since == "" || len(version) == 0 {
+		return true
+	}
+	for i, part := range strings.Split(since, ".") {
+		required, _ := strconv.Atoi(part)
+		current := 0
+		if i < len(version) {
+			current = version[i]
+		}
+		if current != required {
+			return current > required
+		}
+	}
+	return true
+}
+
+// Problem describes the first header or content coding in headers that the browser of their user agent
+// does not send yet, or returns "" when there is none.
+func (c *Capabilities) Problem(headers map[string]string) string {
+	userAgent := GetUserAgent(headers)
+	browser, version := GetBrowser(userAgent), GetBrowserVersion(userAgent)
+	for name, value := range headers {
+		if !c.SupportsHeader(browser, version, name) {
+			return fmt.Sprintf("%s %s does not send the %s header", browser, versionString(version), strings.ToLower(name))
+		}
+		if !strings.EqualFold(name, "accept-encoding") {
+			continue
+		}
+		for _, coding := range strings.Split(value, ",") {
+			coding = strings.TrimSpace(strings.Split(coding, ";")[0])
+			if !c.SupportsEncoding(browser, version, coding) {
+				return fmt.Sprintf("%s %s does not accept the %s content coding", browser, versionString(version), coding)
+			}
+		}
+	}
+	return ""
+}
+
+// apply removes the headers and content codings the browser of the user agent in headers does not
+// send yet, see Problem.
+func (c *Capabilities) apply(headers map[string]string) {
+	userAgent := GetUserAgent(headers)
+	browser, version := GetBrowser(userAgent), GetBrowserVersion(userAgent)
+	for name, value := range headers {
+		if !c.SupportsHeader(browser, version, name) {
+			delete(headers, name)
+			continue
+		}
+		if !strings.EqualFold(name, "accept-encoding") {
+			continue
+		}
+		codings := strings.Split(value, ",")
+		kept := slices.DeleteFunc(slices.Clone(codings), func(coding string) bool {
+			return !c.SupportsEncoding(browser, version, strings.TrimSpace(strings.Split(coding, ";")[0]))
+		})
+		if len(kept) < len(codings) {
+			headers[name] = strings.Join(kept, ",")
+		}
+	}
+}
+
+// Capabilities returns the capability matrix of the data files, DefaultCapabilities without one.
+func (g *HeaderGenerator) Capabilities() *Capabilities {
+	return g.capabilities
+}
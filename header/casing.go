@@ -0,0 +1,82 @@
+package header
+
+import (
+	"net/textproto"
+	"strings"
+	"unicode"
+)
+
+// HeaderCasing selects how the names of generated headers are cased.
+type HeaderCasing int
+
+const (
+	// BrowserExact keeps the casing browsers put on the wire: lowercase over HTTP/2 and the browser's
+	// own casing over HTTP/1.
+	BrowserExact HeaderCasing = iota
+	// CanonicalMIME uses the canonical form of net/textproto, e.g. "Sec-Ch-Ua".
+	CanonicalMIME
+	// Lowercase lowercases every name.
+	Lowercase
+)
+
+// http1HeaderNames holds the HTTP/1 casing of headers that browsers send but do not case by simply
+// capitalizing every word. Names from headers-order.json take precedence.
+var http1HeaderNames = map[string]string{
+	"dnt":                         "DNT",
+	"rtt":                         "RTT",
+	"ect":                         "ECT",
+	"te":                          "TE",
+	"content-md5":                 "Content-MD5",
+	"www-authenticate":            "WWW-Authenticate",
+	"x-requested-with":            "X-Requested-With",
+	"sec-ch-ua":                   "sec-ch-ua",
+	"sec-ch-ua-mobile":            "sec-ch-ua-mobile",
+	"sec-ch-ua-platform":          "sec-ch-ua-platform",
+	"sec-ch-ua-arch":              "sec-ch-ua-arch",
+	"sec-ch-ua-bitness":           "sec-ch-ua-bitness",
+	"sec-ch-ua-full-version":      "sec-ch-ua-full-version",
+	"sec-ch-ua-full-version-list": "sec-ch-ua-full-version-list",
+	"sec-ch-ua-model":             "sec-ch-ua-model",
+	"sec-ch-ua-platform-version":  "sec-ch-ua-platform-version",
+	"sec-ch-ua-wow64":             "sec-ch-ua-wow64",
+}
+
+// http1HeaderName returns the name browser sends for the HTTP/2 header name over HTTP/1.
+func (g *HeaderGenerator) http1HeaderName(browser string, name string) string {
+	lower := strings.ToLower(name)
+	for _, orderedName := range g.headersOrder[browser] {
+		if strings.ToLower(orderedName) == lower && orderedName != lower {
+			return orderedName
+		}
+	}
+	if exact, ok := http1HeaderNames[lower]; ok {
+		return exact
+	}
+
+	parts := strings.Split(lower, "-")
+	for i, p := range parts {
+		if len(p) > 0 {
+			parts[i] = string(unicode.ToUpper(rune(p[0]))) + p[1:]
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// applyHeaderCasing renames headers according to casing. BrowserExact leaves them unchanged.
+func applyHeaderCasing(headers map[string]string, casing HeaderCasing) map[string]string {
+	var rename func(string) string
+	switch casing {
+	case CanonicalMIME:
+		rename = textproto.CanonicalMIMEHeaderKey
+	case Lowercase:
+		rename = strings.ToLower
+	default:
+		return headers
+	}
+
+	renamed := make(map[string]string, len(headers))
+	for name, value := range headers {
+		renamed[rename(name)] = value
+	}
+	return renamed
+}
@@ -0,0 +1,87 @@
+package header
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// chromeFullVersions are published stable Chrome releases per major version, used for the full
+// versions of userAgentData and the full version client hints instead of invented build numbers.
+var chromeFullVersions = map[int][]string{
+	100: {"100.0.4896.60", "100.0.4896.75", "100.0.4896.127"},
+	101: {"101.0.4951.41", "101.0.4951.54", "101.0.4951.64", "101.0.4951.67"},
+	102: {"102.0.5005.61", "102.0.5005.115"},
+	103: {"103.0.5060.53", "103.0.5060.114", "103.0.5060.134"},
+	104: {"104.0.5112.79", "104.0.5112.101", "104.0.5112.102"},
+	105: {"105.0.5195.102", "105.0.5195.125", "105.0.5195.127"},
+	106: {"106.0.5249.91", "106.0.5249.103", "106.0.5249.119"},
+	107: {"107.0.5304.87", "107.0.5304.107", "107.0.5304.121"},
+	108: {"108.0.5359.94", "108.0.5359.124", "108.0.5359.125"},
+	109: {"109.0.5414.74", "109.0.5414.119", "109.0.5414.120"},
+	110: {"110.0.5481.77", "110.0.5481.100", "110.0.5481.177"},
+	111: {"111.0.5563.64", "111.0.5563.110", "111.0.5563.146"},
+	112: {"112.0.5615.49", "112.0.5615.121", "112.0.5615.137"},
+	113: {"113.0.5672.63", "113.0.5672.126"},
+	114: {"114.0.5735.90", "114.0.5735.133", "114.0.5735.198"},
+	115: {"115.0.5790.102", "115.0.5790.110", "115.0.5790.170"},
+	116: {"116.0.5845.96", "116.0.5845.110", "116.0.5845.187"},
+	117: {"117.0.5938.62", "117.0.5938.92", "117.0.5938.149"},
+	118: {"118.0.5993.70", "118.0.5993.88", "118.0.5993.117"},
+	119: {"119.0.6045.105", "119.0.6045.159", "119.0.6045.199"},
+	120: {"120.0.6099.71", "120.0.6099.109", "120.0.6099.129"},
+	121: {"121.0.6167.85", "121.0.6167.139", "121.0.6167.184"},
+	122: {"122.0.6261.57", "122.0.6261.94", "122.0.6261.128"},
+	123: {"123.0.6312.58", "123.0.6312.86", "123.0.6312.122"},
+	124: {"124.0.6367.60", "124.0.6367.91", "124.0.6367.207"},
+	125: {"125.0.6422.60", "125.0.6422.112", "125.0.6422.141"},
+	126: {"126.0.6478.55", "126.0.6478.126", "126.0.6478.182"},
+	127: {"127.0.6533.72", "127.0.6533.99", "127.0.6533.119"},
+	128: {"128.0.6613.84", "128.0.6613.119", "128.0.6613.137"},
+	129: {"129.0.6668.58", "129.0.6668.89", "129.0.6668.100"},
+	130: {"130.0.6723.58", "130.0.6723.91", "130.0.6723.116"},
+	131: {"131.0.6778.69", "131.0.6778.108", "131.0.6778.204"},
+	132: {"132.0.6834.83", "132.0.6834.110", "132.0.6834.159"},
+	133: {"133.0.6943.53", "133.0.6943.126", "133.0.6943.141"},
+	134: {"134.0.6998.35", "134.0.6998.88", "134.0.6998.165"},
+	135: {"135.0.7049.42", "135.0.7049.84", "135.0.7049.114"},
+	136: {"136.0.7103.48", "136.0.7103.92", "136.0.7103.113"},
+	137: {"137.0.7151.55", "137.0.7151.68", "137.0.7151.119"},
+	138: {"138.0.7204.49", "138.0.7204.100", "138.0.7204.157"},
+}
+
+// ChromeFullVersion returns a published stable release of Chrome major, or "" when it is not known.
+func ChromeFullVersion(major int) string {
+	return randomFullVersion(chromeFullVersions, major)
+}
+
+// RealChromeFullVersion returns version when it is a published release of its Chrome major version or
+// the major version is not known, and a published release of the major version otherwise. The release
+// is derived from version, so that the same fingerprint always reports the same one.
+func RealChromeFullVersion(version string) string {
+	return realFullVersion(chromeFullVersions, version)
+}
+
+func randomFullVersion(releases map[int][]string, major int) string {
+	versions := releases[major]
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[rand.Intn(len(versions))]
+}
+
+func realFullVersion(releases map[int][]string, version string) string {
+	major, err := strconv.Atoi(strings.Split(version, ".")[0])
+	if err != nil {
+		return version
+	}
+	versions := releases[major]
+	if len(versions) == 0 || slices.Contains(versions, version) {
+		return version
+	}
+	h := fnv.New32a()
+	h.Write([]byte(version))
+	return versions[int(h.Sum32()%uint32(len(versions)))]
+}
@@ -0,0 +1,133 @@
+package header
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// HighEntropyClientHints are the user agent client hints browsers only send to origins that request
+// them through the Accept-CH response header.
+var HighEntropyClientHints = []string{
+	"sec-ch-ua-arch",
+	"sec-ch-ua-bitness",
+	"sec-ch-ua-full-version",
+	"sec-ch-ua-full-version-list",
+	"sec-ch-ua-model",
+	"sec-ch-ua-platform-version",
+	"sec-ch-ua-wow64",
+}
+
+// ClientHints holds the values of the high-entropy client hints of a browser identity, as exposed by
+// navigator.userAgentData.getHighEntropyValues().
+type ClientHints struct {
+	Architecture    string
+	Bitness         string
+	Model           string
+	PlatformVersion string
+	FullVersion     string
+	// FullVersionList is the formatted brand list, e.g. `"Chromium";v="124.0.6367.91", "Not-A.Brand";v="99.0.0.0"`.
+	FullVersionList string
+	WoW64           bool
+}
+
+func (c *ClientHints) value(hint string) (string, bool) {
+	quote := func(s string) string { return `"` + s + `"` }
+	switch hint {
+	case "sec-ch-ua-arch":
+		return quote(c.Architecture), true
+	case "sec-ch-ua-bitness":
+		return quote(c.Bitness), true
+	case "sec-ch-ua-full-version":
+		return quote(c.FullVersion), c.FullVersion != ""
+	case "sec-ch-ua-full-version-list":
+		return c.FullVersionList, c.FullVersionList != ""
+	case "sec-ch-ua-model":
+		return quote(c.Model), true
+	case "sec-ch-ua-platform-version":
+		return quote(c.PlatformVersion), true
+	case "sec-ch-ua-wow64":
+		if c.WoW64 {
+			return "?1", true
+		}
+		return "?0", true
+	}
+	return "", false
+}
+
+// ClientHintPolicy remembers which client hints each origin asked for through Accept-CH, so that
+// later requests to the origin carry exactly those hints, like Chrome does.
+type ClientHintPolicy struct {
+	mu    sync.RWMutex
+	hints map[string][]string
+}
+
+func NewClientHintPolicy() *ClientHintPolicy {
+	return &ClientHintPolicy{hints: make(map[string][]string)}
+}
+
+// Register records the value of an Accept-CH header received from origin, replacing earlier values.
+func (p *ClientHintPolicy) Register(origin string, acceptCH string) {
+	var hints []string
+	for _, hint := range strings.Split(acceptCH, ",") {
+		hint = strings.ToLower(strings.TrimSpace(hint))
+		if hint != "" {
+			hints = append(hints, hint)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hints[Origin(origin)] = hints
+}
+
+// RegisterResponse records the Accept-CH header of a response, if any.
+func (p *ClientHintPolicy) RegisterResponse(resp *http.Response) {
+	if resp == nil || resp.Request == nil || resp.Request.URL == nil {
+		return
+	}
+	if acceptCH := resp.Header.Get("Accept-CH"); acceptCH != "" {
+		p.Register(resp.Request.URL.String(), acceptCH)
+	}
+}
+
+// Hints returns the client hints requested by origin.
+func (p *ClientHintPolicy) Hints(origin string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.hints[Origin(origin)]
+}
+
+// Origin returns the scheme://host[:port] origin of rawURL, or rawURL itself if it cannot be parsed.
+func Origin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// applyClientHintPolicy adds the high-entropy hints requested by the origin of requestURL that the
+// browser version sends according to capabilities, and removes the ones it did not request. Headers of browsers
+// that do not send client hints are left unchanged.
+func applyClientHintPolicy(headers map[string]string, capabilities *Capabilities, policy *ClientHintPolicy, requestURL string, clientHints *ClientHints) {
+	if _, ok := headers["sec-ch-ua"]; !ok {
+		return
+	}
+
+	requested := policy.Hints(requestURL)
+	for _, hint := range HighEntropyClientHints {
+		delete(headers, hint)
+	}
+	if clientHints == nil {
+		return
+	}
+	userAgent := GetUserAgent(headers)
+	browser, version := GetBrowser(userAgent), GetBrowserVersion(userAgent)
+	for _, hint := range requested {
+		if value, ok := clientHints.value(hint); ok && capabilities.SupportsHeader(browser, version, hint) {
+			headers[hint] = value
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package header
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// BrowserOperatingSystems are the operating systems each browser is generated for. Combinations
+// outside of it, such as Safari on Windows, have no dataset records.
+var BrowserOperatingSystems = map[string][]string{
+	"chrome":  {"windows", "macos", "linux", "android", "ios"},
+	"edge":    {"windows", "macos", "linux", "android"},
+	"firefox": {"windows", "macos", "linux", "android", "ios"},
+	"safari":  {"macos", "ios"},
+}
+
+// operatingSystemDevices are the devices of each operating system.
+var operatingSystemDevices = map[string]string{
+	"windows": "desktop",
+	"macos":   "desktop",
+	"linux":   "desktop",
+	"android": "mobile",
+	"ios":     "mobile",
+}
+
+// CompatibilityError is returned when the browsers, operating systems and devices of the options
+// can not be combined.
+type CompatibilityError struct {
+	// Incompatible lists the values that have no compatible combination, e.g. "browser safari".
+	Incompatible []string
+}
+
+func (e *CompatibilityError) Error() string {
+	return fmt.Sprintf("The options can not be combined: no browser, operating system and device are compatible with %s.",
+		strings.Join(e.Incompatible, ", "))
+}
+
+// compatibleBrowser reports whether browser runs on one of operatingSystems with one of devices.
+// Browsers and operating systems outside of the matrix are not restricted.
+func compatibleBrowser(browser string, operatingSystems []string, devices []string) bool {
+	supported, ok := BrowserOperatingSystems[browser]
+	if !ok {
+		return true
+	}
+	return slices.ContainsFunc(operatingSystems, func(os string) bool {
+		device, known := operatingSystemDevices[os]
+		return slices.Contains(supported, os) && (!known || len(devices) == 0 || slices.Contains(devices, device))
+	})
+}
+
+// checkCompatibility finds the browsers and operating systems of options that can not be combined
+// with the others. In strict mode they are an error, otherwise they are removed from options with a
+// warning, once per combination. Values left at their defaults are removed silently, and default
+// devices are not checked. It is an error when no combination is left.
+func (g *HeaderGenerator) checkCompatibility(options *HeaderGeneratorOptions) error {
+	specifications := PrepareOperatingSystems(options)
+	if len(options.Browsers) == 0 || len(specifications) == 0 {
+		return nil
+	}
+	var operatingSystems, browsers []string
+	for _, os := range specifications {
+		operatingSystems = append(operatingSystems, os.Name)
+	}
+	browserName := func(browser any) string {
+		switch b := browser.(type) {
+		case string:
+			return b
+		case BrowserSpecification:
+			return b.Name
+		}
+		return ""
+	}
+	for _, browser := range options.Browsers {
+		browsers = append(browsers, browserName(browser))
+	}
+	defaults := DefaultHeaderGeneratorOptions()
+	var devices []string
+	if !slices.Equal(options.Devices, defaults.Devices) {
+		devices = inputDevices(options.Devices)
+	}
+	defaultBrowsers := reflect.DeepEqual(options.Browsers, defaults.Browsers)
+	defaultOperatingSystems := slices.Equal(options.OperatingSystems, defaults.OperatingSystems) && len(options.OperatingSystemSpecifications) == 0
+
+	var incompatible []string
+	keptBrowsers := slices.DeleteFunc(slices.Clone(options.Browsers), func(browser any) bool {
+		name := browserName(browser)
+		if name == "" || compatibleBrowser(name, operatingSystems, devices) {
+			return false
+		}
+		if !defaultBrowsers {
+			incompatible = append(incompatible, "browser "+name)
+		}
+		return true
+	})
+	incompatibleOperatingSystem := func(name string) bool {
+		if slices.ContainsFunc(browsers, func(browser string) bool { return compatibleBrowser(browser, []string{name}, nil) }) {
+			return false
+		}
+		if !defaultOperatingSystems {
+			incompatible = append(incompatible, "operating system "+name)
+		}
+		return true
+	}
+	keptOperatingSystems := slices.DeleteFunc(slices.Clone(options.OperatingSystems), incompatibleOperatingSystem)
+	keptSpecifications := slices.DeleteFunc(slices.Clone(options.OperatingSystemSpecifications), func(os OperatingSystemSpecification) bool {
+		return incompatibleOperatingSystem(os.Name)
+	})
+
+	if len(keptBrowsers) == 0 || len(keptOperatingSystems)+len(keptSpecifications) == 0 || (options.Strict && len(incompatible) > 0) {
+		return &CompatibilityError{Incompatible: incompatible}
+	}
+	options.Browsers = keptBrowsers
+	options.OperatingSystems = keptOperatingSystems
+	options.OperatingSystemSpecifications = keptSpecifications
+	if len(incompatible) == 0 {
+		return nil
+	}
+	if _, warned := g.incompatibleOptions.LoadOrStore(strings.Join(incompatible, ", "), struct{}{}); !warned {
+		fmt.Printf("Warning: ignoring %s, which can not be combined with the other options\n", strings.Join(incompatible, ", "))
+	}
+	return nil
+}
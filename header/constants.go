@@ -1,5 +1,9 @@
 package header
 
+import (
+	"fingerprint-go/internal/constants"
+)
+
 var SupportedBrowsers = []string{
 	"chrome",
 	"firefox",
@@ -18,6 +22,7 @@ var SupportedOperatingSystems = []string{
 var SupportedDevices = []string{
 	"desktop",
 	"mobile",
+	DeviceWebView,
 }
 
 var SupportedHttpVersions = []string{
@@ -25,11 +30,12 @@ var SupportedHttpVersions = []string{
 	"2",
 }
 
+// The dataset constants are also available from the dataset package.
 const (
-	BrowserHttpNodeName      string = "*BROWSER_HTTP"
-	OperatingSystemNodeName  string = "*OPERATING_SYSTEM"
-	DeviceNodeName           string = "*DEVICE"
-	MissingValueDatasetToken string = "*MISSING_VALUE*"
+	BrowserHttpNodeName      string = constants.BrowserHttpNodeName
+	OperatingSystemNodeName  string = constants.OperatingSystemNodeName
+	DeviceNodeName           string = constants.DeviceNodeName
+	MissingValueDatasetToken string = constants.MissingValueDatasetToken
 )
 
 var Http1SecFetchAttributes = map[string]string{
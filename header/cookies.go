@@ -0,0 +1,136 @@
+package header
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StoredCookie is a cookie of a CookieJar together with the URL of the response that set it.
+type StoredCookie struct {
+	URL      string        `json:"url"`
+	Name     string        `json:"name"`
+	Value    string        `json:"value"`
+	Domain   string        `json:"domain,omitempty"`
+	Path     string        `json:"path,omitempty"`
+	Expires  time.Time     `json:"expires,omitzero"`
+	Secure   bool          `json:"secure,omitempty"`
+	HttpOnly bool          `json:"httpOnly,omitempty"`
+	SameSite http.SameSite `json:"sameSite,omitempty"`
+}
+
+// CookieJar is the cookie jar of a Session. It is an http.CookieJar whose cookies can be exported and
+// imported, e.g. to persist them as JSON next to the fingerprint of the identity.
+type CookieJar struct {
+	mu     sync.Mutex
+	jar    *cookiejar.Jar
+	stored map[string]StoredCookie
+}
+
+// NewCookieJar returns an empty cookie jar.
+func NewCookieJar() *CookieJar {
+	j := &CookieJar{}
+	j.Clear()
+	return j
+}
+
+// SetCookies stores the cookies of a response from u.
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	for _, cookie := range cookies {
+		stored := StoredCookie{
+			URL:      u.String(),
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Expires:  cookie.Expires,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: cookie.SameSite,
+		}
+		// Max-Age is relative to the response, so it is kept as an expiry time.
+		switch {
+		case cookie.MaxAge < 0:
+			stored.Expires = now.Add(-time.Second)
+		case cookie.MaxAge > 0:
+			stored.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+		}
+		j.stored[strings.Join([]string{u.Hostname(), cookie.Domain, cookie.Path, cookie.Name}, "\x00")] = stored
+	}
+}
+
+// Cookies returns the cookies to send in a request to u.
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+// Export returns the cookies of the jar that have not expired.
+func (j *CookieJar) Export() []StoredCookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	cookies := make([]StoredCookie, 0, len(j.stored))
+	for key, cookie := range j.stored {
+		if !cookie.Expires.IsZero() && !cookie.Expires.After(now) {
+			delete(j.stored, key)
+			continue
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
+
+// Import stores cookies as if they had been set by responses from their URLs.
+func (j *CookieJar) Import(cookies []StoredCookie) {
+	for _, cookie := range cookies {
+		u, err := url.Parse(cookie.URL)
+		if err != nil {
+			continue
+		}
+		j.SetCookies(u, []*http.Cookie{{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Expires:  cookie.Expires,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: cookie.SameSite,
+		}})
+	}
+}
+
+// Clear removes all cookies.
+func (j *CookieJar) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar, _ = cookiejar.New(nil)
+	j.stored = make(map[string]StoredCookie)
+}
+
+// MarshalJSON encodes the cookies of Export.
+func (j *CookieJar) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Export())
+}
+
+// UnmarshalJSON replaces the cookies of the jar with encoded ones.
+func (j *CookieJar) UnmarshalJSON(data []byte) error {
+	var cookies []StoredCookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return err
+	}
+	j.Clear()
+	j.Import(cookies)
+	return nil
+}
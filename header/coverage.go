@@ -0,0 +1,75 @@
+package header
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MinVersionRangeRecords is the number of dataset records below which a requested browser version
+// range is reported as sparsely covered. Generations from such a range repeat the few recorded header
+// sets or fail to satisfy the other constraints.
+const MinVersionRangeRecords = 50
+
+// parseBrowserHelperFile reads browser-helper-file.json. The file maps every browser and HTTP version
+// ("chrome/120.0.0.0|2") to the number of dataset records it was seen in; files of older datasets list
+// the browsers without counts, and their Records are 0.
+func parseBrowserHelperFile(data []byte) ([]HttpBrowserObject, error) {
+	records := make(map[string]int)
+	if err := json.Unmarshal(data, &records); err != nil {
+		var browserStrings []string
+		if json.Unmarshal(data, &browserStrings) != nil {
+			return nil, err
+		}
+		clear(records)
+		for _, browserString := range browserStrings {
+			records[browserString] = 0
+		}
+	}
+
+	browsers := make([]HttpBrowserObject, 0, len(records))
+	for browserString, count := range records {
+		if browserString == MissingValueDatasetToken {
+			continue
+		}
+		browser := prepareHttpBrowserObject(browserString)
+		browser.Records = count
+		browsers = append(browsers, browser)
+	}
+	slices.SortFunc(browsers, func(a, b HttpBrowserObject) int {
+		return strings.Compare(a.CompleteString, b.CompleteString)
+	})
+	return browsers, nil
+}
+
+// BrowserRecords returns the number of dataset records of the browser versions matching browser. ok
+// is false when the data files do not carry record counts.
+func (g *HeaderGenerator) BrowserRecords(browser BrowserSpecification) (records int, ok bool) {
+	for _, browserOption := range g.uniqueBrowsers {
+		if browserOption.Records > 0 {
+			ok = true
+		}
+		if browser.matches(browserOption) {
+			records += browserOption.Records
+		}
+	}
+	return records, ok
+}
+
+// warnSparseRange prints a warning, once per specification, when browser restricts the versions to a
+// range backed by fewer than MinVersionRangeRecords dataset records.
+func (g *HeaderGenerator) warnSparseRange(browser BrowserSpecification) {
+	if browser.MinVersion == 0 && browser.MaxVersion == 0 {
+		return
+	}
+	records, ok := g.BrowserRecords(browser)
+	if !ok || records >= MinVersionRangeRecords {
+		return
+	}
+	if _, warned := g.sparseRanges.LoadOrStore(browser, struct{}{}); warned {
+		return
+	}
+	fmt.Printf("Warning: %s versions %d to %d are backed by only %d dataset records, generated headers will repeat or fail\n",
+		browser.Name, browser.MinVersion, browser.MaxVersion, records)
+}
@@ -0,0 +1,61 @@
+package header
+
+import "strings"
+
+// edgeFullVersions are published stable Edge releases per major version. Edge builds its own versions
+// on top of Chromium, so its full version differs from the Chromium one in the client hints.
+var edgeFullVersions = map[int][]string{
+	110: {"110.0.1587.41", "110.0.1587.57"},
+	111: {"111.0.1661.41", "111.0.1661.62"},
+	112: {"112.0.1722.34", "112.0.1722.64"},
+	113: {"113.0.1774.35", "113.0.1774.57"},
+	114: {"114.0.1823.37", "114.0.1823.67"},
+	115: {"115.0.1901.183", "115.0.1901.203"},
+	116: {"116.0.1938.54", "116.0.1938.81"},
+	117: {"117.0.2045.31", "117.0.2045.60"},
+	118: {"118.0.2088.46", "118.0.2088.76"},
+	119: {"119.0.2151.44", "119.0.2151.97"},
+	120: {"120.0.2210.61", "120.0.2210.91"},
+	121: {"121.0.2277.83", "121.0.2277.128"},
+	122: {"122.0.2365.52", "122.0.2365.92"},
+	123: {"123.0.2420.53", "123.0.2420.97"},
+	124: {"124.0.2478.51", "124.0.2478.80"},
+	125: {"125.0.2535.51", "125.0.2535.85"},
+	126: {"126.0.2592.56", "126.0.2592.87"},
+	127: {"127.0.2651.74", "127.0.2651.98"},
+	128: {"128.0.2739.42", "128.0.2739.79"},
+	129: {"129.0.2792.52", "129.0.2792.89"},
+	130: {"130.0.2849.46", "130.0.2849.80"},
+	131: {"131.0.2903.51", "131.0.2903.86"},
+	132: {"132.0.2957.115", "132.0.2957.140"},
+	133: {"133.0.3065.59", "133.0.3065.92"},
+	134: {"134.0.3124.66", "134.0.3124.93"},
+	135: {"135.0.3179.54", "135.0.3179.98"},
+	136: {"136.0.3240.50", "136.0.3240.76"},
+	137: {"137.0.3296.52", "137.0.3296.93"},
+	138: {"138.0.3351.55", "138.0.3351.83"},
+}
+
+// EdgeFullVersion returns a published stable release of Edge major, or "" when it is not known.
+func EdgeFullVersion(major int) string {
+	return randomFullVersion(edgeFullVersions, major)
+}
+
+// RealEdgeFullVersion is RealChromeFullVersion for Edge releases, which replaces the reduced version
+// of Edge user agents, e.g. "131.0.0.0", with a published build.
+func RealEdgeFullVersion(version string) string {
+	return realFullVersion(edgeFullVersions, version)
+}
+
+// addEdgeHeaderOrders gives Edge the header orders of Chrome when the data files have none of their
+// own: Edge sends the headers of the Chromium version it is built on in the same order.
+func addEdgeHeaderOrders(orders map[string][]string) {
+	if _, ok := orders["edge"]; ok {
+		return
+	}
+	for key, order := range orders {
+		if key == "chrome" || strings.HasPrefix(key, "chrome/") {
+			orders["edge"+strings.TrimPrefix(key, "chrome")] = order
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package header
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ElectronApp describes a desktop app built on Electron whose embedded Chromium is emulated. Electron
+// adds the app and Electron tokens to the Chrome user agent and does not send user agent client hints.
+type ElectronApp struct {
+	// Name and Version form the app token of the user agent, e.g. "Slack" and "4.36.140".
+	Name    string
+	Version string
+	// WindowWidth and WindowHeight are the size of the app window in CSS pixels. When zero, a common
+	// default window size is used.
+	WindowWidth  float64
+	WindowHeight float64
+}
+
+// electronVersions maps the Chrome major versions shipped by Electron to the Electron release.
+var electronVersions = map[int]string{
+	114: "25.9.8",
+	116: "26.6.10",
+	118: "27.3.11",
+	120: "28.3.3",
+	122: "29.4.6",
+	124: "30.5.1",
+	126: "31.7.7",
+	128: "32.3.3",
+	130: "33.4.11",
+	132: "34.5.8",
+	134: "35.7.5",
+}
+
+var electronTokensPattern = regexp.MustCompile(` ([^ /]+)/([^ ]+) (Chrome/[^ ]+) Electron/[^ ]+`)
+
+// IsElectron reports whether userAgent is the user agent of an Electron app.
+func IsElectron(userAgent string) bool {
+	return electronTokensPattern.MatchString(userAgent)
+}
+
+// GetElectronApp returns the Electron app of userAgent, or nil when it is not an Electron user agent.
+func GetElectronApp(userAgent string) *ElectronApp {
+	match := electronTokensPattern.FindStringSubmatch(userAgent)
+	if match == nil {
+		return nil
+	}
+	return &ElectronApp{Name: match[1], Version: match[2]}
+}
+
+// ElectronUserAgent returns the user agent of app running on the Chromium of chromeUserAgent, or ""
+// when no Electron release ships that Chromium.
+func ElectronUserAgent(chromeUserAgent string, app *ElectronApp) string {
+	version := GetChromiumVersion(chromeUserAgent)
+	if len(version) == 0 || IsElectron(chromeUserAgent) {
+		return ""
+	}
+	electronVersion, ok := electronVersions[version[0]]
+	if !ok {
+		return ""
+	}
+
+	i := strings.Index(chromeUserAgent, " Chrome/")
+	j := strings.Index(chromeUserAgent, " Safari/")
+	if i < 0 || j < i {
+		return ""
+	}
+	return chromeUserAgent[:i] + " " + app.Name + "/" + app.Version + chromeUserAgent[i:j] +
+		" Electron/" + electronVersion + chromeUserAgent[j:]
+}
+
+// electronChrome reports whether the *BROWSER_HTTP value browser is a Chrome release shipped by Electron.
+func electronChrome(browser string) bool {
+	name, version, _ := strings.Cut(strings.Split(browser, "|")[0], "/")
+	major, err := strconv.Atoi(strings.Split(version, ".")[0])
+	if name != "chrome" || err != nil {
+		return false
+	}
+	_, ok := electronVersions[major]
+	return ok
+}
+
+// applyElectron turns the headers of desktop Chrome into those of app. It reports false when no
+// Electron release ships the sampled Chrome version.
+func applyElectron(headers map[string]string, app *ElectronApp) bool {
+	for name, value := range headers {
+		if !strings.EqualFold(name, "user-agent") {
+			continue
+		}
+		userAgent := ElectronUserAgent(value, app)
+		if userAgent == "" {
+			return false
+		}
+		headers[name] = userAgent
+	}
+	for name := range headers {
+		if strings.HasPrefix(strings.ToLower(name), "sec-ch-ua") {
+			delete(headers, name)
+		}
+	}
+	return true
+}
+
+// electronBrowserOptions keeps the Chrome releases of browserHttpOptions shipped by Electron.
+func electronBrowserOptions(browserHttpOptions []string) []string {
+	return slices.DeleteFunc(browserHttpOptions, func(browser string) bool {
+		return !electronChrome(browser)
+	})
+}
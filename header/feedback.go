@@ -0,0 +1,131 @@
+package header
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultFeedbackHalfLife is the time after which half of a recorded block is forgotten.
+const DefaultFeedbackHalfLife = 30 * time.Minute
+
+// identityClass is the part of an identity targets block on: the browser, its major version and the
+// operating system.
+type identityClass struct {
+	browser         string
+	majorVersion    string
+	operatingSystem string
+}
+
+type blockScore struct {
+	score   float64
+	updated time.Time
+}
+
+// OutcomeFeedback learns which browser, version and operating system combinations get blocked on
+// each target and down-weights them when new identities are sampled for the target. Blocks decay over
+// time, so combinations are tried again later.
+type OutcomeFeedback struct {
+	// HalfLife is how quickly blocks are forgotten, DefaultFeedbackHalfLife when zero.
+	HalfLife time.Duration
+	// BlockedStatuses are the HTTP statuses counted as blocks, 403 and 429 when empty.
+	BlockedStatuses []int
+
+	mu     sync.Mutex
+	scores map[string]map[identityClass]*blockScore
+}
+
+func NewOutcomeFeedback() *OutcomeFeedback {
+	return &OutcomeFeedback{scores: make(map[string]map[identityClass]*blockScore)}
+}
+
+// Record records the outcome of a request of the identity info to the target key.
+func (f *OutcomeFeedback) Record(key string, info *GenerationInfo, status int) {
+	f.record(key, info, status, time.Now())
+}
+
+func (f *OutcomeFeedback) record(key string, info *GenerationInfo, status int, now time.Time) {
+	if info == nil {
+		return
+	}
+	blocked := []int{403, 429}
+	if len(f.BlockedStatuses) > 0 {
+		blocked = f.BlockedStatuses
+	}
+	if !slices.Contains(blocked, status) {
+		return
+	}
+
+	class := identityClass{browser: info.Browser, majorVersion: majorVersion(info.Version), operatingSystem: info.OperatingSystem}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.scores == nil {
+		f.scores = make(map[string]map[identityClass]*blockScore)
+	}
+	if f.scores[key] == nil {
+		f.scores[key] = make(map[identityClass]*blockScore)
+	}
+	s, ok := f.scores[key][class]
+	if !ok {
+		s = &blockScore{}
+		f.scores[key][class] = s
+	}
+	s.score = f.decayed(s, now) + 1
+	s.updated = now
+}
+
+// Weight returns the factor, between 0 and 1, by which the identity info is down-weighted on key.
+func (f *OutcomeFeedback) Weight(key string, info *GenerationInfo) float64 {
+	class := identityClass{browser: info.Browser, majorVersion: majorVersion(info.Version), operatingSystem: info.OperatingSystem}
+	return f.weight(key, class, time.Now())
+}
+
+func (f *OutcomeFeedback) weight(key string, class identityClass, now time.Time) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.scores[key][class]
+	if !ok {
+		return 1
+	}
+	return 1 / (1 + f.decayed(s, now))
+}
+
+func (f *OutcomeFeedback) decayed(s *blockScore, now time.Time) float64 {
+	halfLife := f.HalfLife
+	if halfLife <= 0 {
+		halfLife = DefaultFeedbackHalfLife
+	}
+	return s.score * math.Exp2(-float64(now.Sub(s.updated))/float64(halfLife))
+}
+
+// consistent returns a bayesian.SamplingOptions.Consistent rule for the input network that rejects
+// blocked combinations on key with a probability growing with their block score.
+func (f *OutcomeFeedback) consistent(key string) func(sample map[string]string, node string) bool {
+	return func(sample map[string]string, node string) bool {
+		if node != BrowserHttpNodeName && node != OperatingSystemNodeName {
+			return true
+		}
+		browserHttp, ok1 := sample[BrowserHttpNodeName]
+		operatingSystem, ok2 := sample[OperatingSystemNodeName]
+		if !ok1 || !ok2 {
+			return true
+		}
+
+		browser := prepareHttpBrowserObject(browserHttp)
+		class := identityClass{
+			browser:         browser.Name,
+			majorVersion:    majorVersion(versionString(browser.Version)),
+			operatingSystem: operatingSystem,
+		}
+		return rand.Float64() < f.weight(key, class, time.Now())
+	}
+}
+
+func majorVersion(version string) string {
+	major, _, _ := strings.Cut(version, ".")
+	return major
+}
@@ -1,17 +1,22 @@
+// Package header generates the HTTP headers of browsers from Bayesian networks. It does not depend on
+// the fingerprint package or its network, so programs that only need headers can ship the data files
+// built with "fingerprint build-data -headers-only".
 package header
 
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
-	"unicode"
+	"sync"
+	"time"
 
 	"fingerprint-go/bayesian"
-	"fingerprint-go/network"
+	"fingerprint-go/internal/constants"
 )
 
 type HttpBrowserObject struct {
@@ -19,6 +24,8 @@ type HttpBrowserObject struct {
 	Version        []int
 	CompleteString string
 	HttpVersion    string
+	// Records is the number of dataset records of the browser version, 0 when it is not known.
+	Records int
 }
 
 type BrowserSpecification struct {
@@ -28,6 +35,14 @@ type BrowserSpecification struct {
 	HttpVersion string
 }
 
+// OperatingSystemSpecification restricts an operating system to a range of major versions, e.g.
+// macOS 13 and later or Android 12 to 14. Windows versions are 7, 8, 10 and 11.
+type OperatingSystemSpecification struct {
+	Name       string
+	MinVersion int
+	MaxVersion int
+}
+
 type HeaderGeneratorOptions struct {
 	Browsers         []any // Can be string or BrowserSpecification
 	BrowserListQuery string
@@ -36,22 +51,116 @@ type HeaderGeneratorOptions struct {
 	Locales          []string
 	HttpVersion      string
 	Strict           bool
+	// OperatingSystemSpecifications are operating systems restricted to ranges of major versions, allowed
+	// in addition to OperatingSystems. Setting either of the two replaces both of the defaults.
+	OperatingSystemSpecifications []OperatingSystemSpecification
+	// Temperature flattens (> 1) or sharpens (< 1) the sampled distributions of browsers, operating
+	// systems, devices and fingerprint attributes. 0 keeps the dataset distribution.
+	Temperature float64
+	// ClientHintPolicy, when set, makes GetHeadersForRequest send the high-entropy client hints each
+	// origin requested through Accept-CH.
+	ClientHintPolicy *ClientHintPolicy
+	// Casing selects the casing of header names. The default BrowserExact matches the browser.
+	Casing HeaderCasing
+	// SecFetch sets the Sec-Fetch-* headers of browsers that send them. Nil sends NavigationSecFetch,
+	// the values of a top-level navigation typed by the user; empty fields are not sent.
+	SecFetch *SecFetchMetadata
+	// KeepConnectionClose keeps a sampled "Connection: close" header, which is removed by default.
+	KeepConnectionClose bool
+	// WebViewPackage is the package name of the app whose WebView DeviceWebView emulates,
+	// DefaultWebViewPackage when empty.
+	WebViewPackage string
+	// InAppBrowser generates the in-app browser of an app, e.g. InAppBrowserInstagram, instead of a
+	// browser. In-app browsers are Android WebViews with the app's user agent token.
+	InAppBrowser string
+	// Electron generates the desktop app built on Electron instead of Chrome.
+	Electron *ElectronApp
+
+	// DataIntegrity decides whether the generator constructors fail or degrade when data files are
+	// missing, see Health.
+	DataIntegrity DataIntegrity
+
+	// Trace, when set, is filled with the choices made sampling the browser, operating system and device
+	// of the headers, see bayesian.SamplingTrace.
+	Trace *bayesian.SamplingTrace
+
+	// LocaleBaseTags decides whether Accept-Language lists base languages like "en" after "en-US".
+	LocaleBaseTags LocaleBaseTags
+
+	// NoHttp1Fallback disables generating HTTP/1 headers by re-casing HTTP/2 headers when the dataset has
+	// no HTTP/1 headers for the constraints. Set it when the client only speaks HTTP/1.1 and must send
+	// headers recorded over HTTP/1.1.
+	NoHttp1Fallback bool
+
+	// Timeout bounds the time a call spends sampling under the constraints, 0 is no limit. When it
+	// passes, the call fails with ErrTimeout, unless AllowPartial is set.
+	Timeout time.Duration
+	// AllowPartial makes a call that exceeds Timeout return an identity sampled without the outcome
+	// feedback, and relaxed further if needed, flagged as Partial in GenerationInfo, instead of failing.
+	AllowPartial bool
+
+	// RealisticDistribution reweights the browsers and operating systems of the dataset to their market
+	// share, see DefaultMarketShare.
+	RealisticDistribution bool
+	// VersionRecency shapes how often older major versions of each browser are sampled, instead of
+	// the dataset distribution. DefaultVersionRecency follows real populations.
+	VersionRecency *VersionRecency
+
+	// consistent rejects identities while sampling the input network, see OutcomeFeedback.
+	consistent func(sample map[string]string, node string) bool
+	// deadline is when the Timeout of the call being generated passes.
+	deadline time.Time
+}
+
+// ErrTimeout is returned when a generation exceeds the Timeout of its options.
+var ErrTimeout = errors.New("The generation did not finish within the timeout.")
+
+// expired reports whether the Timeout of the call being generated has passed.
+func (o *HeaderGeneratorOptions) expired() bool {
+	return !o.deadline.IsZero() && !time.Now().Before(o.deadline)
+}
+
+// SecFetchMetadata holds the values of the Sec-Fetch-Site, Sec-Fetch-Mode, Sec-Fetch-User and
+// Sec-Fetch-Dest headers.
+type SecFetchMetadata struct {
+	Site string
+	Mode string
+	User string
+	Dest string
 }
 
+// NavigationSecFetch is the fetch metadata of a top-level navigation started by the user.
+var NavigationSecFetch = SecFetchMetadata{Site: "same-site", Mode: "navigate", User: "?1", Dest: "document"}
+
 type HeaderGenerator struct {
-	globalOptions          HeaderGeneratorOptions
-	browserListQuery       string
-	inputGeneratorNetwork  *bayesian.Network
-	headerGeneratorNetwork *bayesian.Network
-	uniqueBrowsers         []HttpBrowserObject
-	headersOrder           map[string][]string
-	relaxationOrder        []string
+	// Metrics, when set, counts generations, relaxations and session rotations, see Metrics.
+	Metrics *Metrics
+
+	globalOptions           HeaderGeneratorOptions
+	browserListQuery        string
+	inputGeneratorNetwork   *bayesian.Network
+	headerGeneratorNetwork  *bayesian.Network
+	uniqueBrowsers          []HttpBrowserObject
+	headersOrder            map[string][]string
+	headerRanks             map[string]map[string]int
+	relaxationOrder         []string
+	placements              headerPlacements
+	uaRanks                 userAgentRanks
+	assets                  []DataAsset
+	sparseRanges            sync.Map
+	incompatibleOptions     sync.Map
+	marketShare             MarketShare
+	capabilities            *Capabilities
+	marketShareOnce         sync.Once
+	marketShareWeights      map[inputClass]float64
+	inputDistributionOnce   sync.Once
+	inputClassProbabilities map[inputClass]float64
 }
 
 func DefaultHeaderGeneratorOptions() HeaderGeneratorOptions {
 	return HeaderGeneratorOptions{
 		Browsers:         []any{"chrome", "edge", "firefox", "safari"},
-		OperatingSystems: SupportedOperatingSystems,
+		OperatingSystems: []string{"windows", "macos", "linux", "android", "ios"},
 		Devices:          []string{"desktop"},
 		Locales:          []string{"en-US"},
 		HttpVersion:      "2",
@@ -100,6 +209,12 @@ func prepareBrowserObject(browserString string) HttpBrowserObject {
 }
 
 func NewHeaderGenerator(options *HeaderGeneratorOptions, dataFilesPath string) (*HeaderGenerator, error) {
+	return NewHeaderGeneratorFS(options, os.DirFS(dataFilesPath))
+}
+
+// NewHeaderGeneratorFS creates a header generator reading its data files from fsys, e.g. an embed.FS,
+// so it can run without a file system such as under WebAssembly.
+func NewHeaderGeneratorFS(options *HeaderGeneratorOptions, dataFiles fs.FS) (*HeaderGenerator, error) {
 	opts := DefaultHeaderGeneratorOptions()
 	if options != nil {
 		if options.Browsers != nil {
@@ -108,8 +223,9 @@ func NewHeaderGenerator(options *HeaderGeneratorOptions, dataFilesPath string) (
 		if options.BrowserListQuery != "" {
 			opts.BrowserListQuery = options.BrowserListQuery
 		}
-		if options.OperatingSystems != nil {
+		if options.OperatingSystems != nil || options.OperatingSystemSpecifications != nil {
 			opts.OperatingSystems = options.OperatingSystems
+			opts.OperatingSystemSpecifications = options.OperatingSystemSpecifications
 		}
 		if options.Devices != nil {
 			opts.Devices = options.Devices
@@ -121,6 +237,21 @@ func NewHeaderGenerator(options *HeaderGeneratorOptions, dataFilesPath string) (
 			opts.HttpVersion = options.HttpVersion
 		}
 		opts.Strict = options.Strict
+		opts.Temperature = options.Temperature
+		opts.ClientHintPolicy = options.ClientHintPolicy
+		opts.Casing = options.Casing
+		opts.SecFetch = options.SecFetch
+		opts.KeepConnectionClose = options.KeepConnectionClose
+		opts.WebViewPackage = options.WebViewPackage
+		opts.InAppBrowser = options.InAppBrowser
+		opts.Electron = options.Electron
+		opts.DataIntegrity = options.DataIntegrity
+		opts.LocaleBaseTags = options.LocaleBaseTags
+		opts.NoHttp1Fallback = options.NoHttp1Fallback
+		opts.Timeout = options.Timeout
+		opts.AllowPartial = options.AllowPartial
+		opts.RealisticDistribution = options.RealisticDistribution
+		opts.VersionRecency = options.VersionRecency
 	}
 
 	gen := &HeaderGenerator{
@@ -133,30 +264,53 @@ func NewHeaderGenerator(options *HeaderGeneratorOptions, dataFilesPath string) (
 	gen.globalOptions = opts
 	// Reassign with properly prepared structs if necessary, but we'll use preparedBrowsers below
 
-	gen.uniqueBrowsers = make([]HttpBrowserObject, 0)
-
 	// Load headers order
-	headersOrderData, err := os.ReadFile(filepath.Join(dataFilesPath, "headers-order.json"))
+	headersOrderData, err := fs.ReadFile(dataFiles, "headers-order.json")
 	if err == nil {
-		json.Unmarshal(headersOrderData, &gen.headersOrder)
-	} else {
+		err = json.Unmarshal(headersOrderData, &gen.headersOrder)
+	}
+	if err != nil || gen.headersOrder == nil {
 		gen.headersOrder = make(map[string][]string)
 	}
+	addEdgeHeaderOrders(gen.headersOrder)
+	gen.headerRanks = make(map[string]map[string]int, len(gen.headersOrder))
+	for browser, order := range gen.headersOrder {
+		gen.headerRanks[browser] = headerRanks(order)
+	}
+	gen.assets = append(gen.assets, loadedAsset("headers-order.json", err))
 
 	// Load browser helper file
-	browserHelperData, err := os.ReadFile(filepath.Join(dataFilesPath, "browser-helper-file.json"))
+	browserHelperData, err := fs.ReadFile(dataFiles, "browser-helper-file.json")
 	if err == nil {
-		var uniqueBrowserStrings []string
-		json.Unmarshal(browserHelperData, &uniqueBrowserStrings)
-		for _, browserString := range uniqueBrowserStrings {
-			if browserString != MissingValueDatasetToken {
-				gen.uniqueBrowsers = append(gen.uniqueBrowsers, prepareHttpBrowserObject(browserString))
-			}
+		gen.uniqueBrowsers, err = parseBrowserHelperFile(browserHelperData)
+	}
+	gen.assets = append(gen.assets, loadedAsset("browser-helper-file.json", err))
+
+	// The market share table is optional, the bundled one is used without it.
+	gen.marketShare = DefaultMarketShare
+	if marketShareData, err := fs.ReadFile(dataFiles, "market-share.json"); err == nil {
+		gen.marketShare, err = loadMarketShare(marketShareData)
+		gen.assets = append(gen.assets, loadedAsset("market-share.json", err))
+	}
+
+	// The capability matrix is optional as well, the bundled one is used without it.
+	gen.capabilities = DefaultCapabilities
+	if capabilitiesData, err := fs.ReadFile(dataFiles, "capabilities.json"); err == nil {
+		if gen.capabilities, err = LoadCapabilities(capabilitiesData); err != nil {
+			gen.capabilities = DefaultCapabilities
 		}
+		gen.assets = append(gen.assets, loadedAsset("capabilities.json", err))
 	}
 
-	gen.inputGeneratorNetwork = bayesian.NewNetwork(filepath.Join(dataFilesPath, "input-network-definition.zip"))
-	gen.headerGeneratorNetwork = bayesian.NewNetwork(filepath.Join(dataFilesPath, "header-network-definition.zip"))
+	var asset DataAsset
+	gen.inputGeneratorNetwork, asset = NetworkAsset(dataFiles, "input-network-definition.zip")
+	gen.assets = append(gen.assets, asset)
+	gen.headerGeneratorNetwork, asset = NetworkAsset(dataFiles, "header-network-definition.zip")
+	gen.assets = append(gen.assets, asset)
+
+	if err := gen.Health().Check(opts.DataIntegrity); err != nil {
+		return nil, err
+	}
 
 	// We only use preparedBrowsers logic to validate or configure later.
 	_ = preparedBrowsers
@@ -191,7 +345,41 @@ func (g *HeaderGenerator) prepareBrowsersConfig(browsers []any, browserListQuery
 	return results
 }
 
-func (g *HeaderGenerator) GetHeaders(options *HeaderGeneratorOptions, requestDependentHeaders map[string]string, userAgentValues []string) (map[string]string, error) {
+// PrepareOperatingSystems returns the OperatingSystems and OperatingSystemSpecifications options of
+// options as specifications.
+func PrepareOperatingSystems(options *HeaderGeneratorOptions) []OperatingSystemSpecification {
+	results := make([]OperatingSystemSpecification, 0, len(options.OperatingSystems)+len(options.OperatingSystemSpecifications))
+	for _, name := range options.OperatingSystems {
+		results = append(results, OperatingSystemSpecification{Name: name})
+	}
+	return append(results, options.OperatingSystemSpecifications...)
+}
+
+// hasVersionRange reports whether any of the specifications restricts the version.
+func hasVersionRange(operatingSystems []OperatingSystemSpecification) bool {
+	return slices.ContainsFunc(operatingSystems, func(os OperatingSystemSpecification) bool {
+		return os.MinVersion != 0 || os.MaxVersion != 0
+	})
+}
+
+// OperatingSystemVersionAllowed reports whether version of operatingSystem is within the range of a
+// specification for it. Operating systems without a specification are not restricted.
+func OperatingSystemVersionAllowed(operatingSystems []OperatingSystemSpecification, operatingSystem string, version int) bool {
+	specified := false
+	for _, os := range operatingSystems {
+		if os.Name != operatingSystem {
+			continue
+		}
+		specified = true
+		if (os.MinVersion == 0 || os.MinVersion <= version) && (os.MaxVersion == 0 || os.MaxVersion >= version) {
+			return true
+		}
+	}
+	return !specified
+}
+
+// ResolveOptions returns the generator's global options overlaid with options.
+func (g *HeaderGenerator) ResolveOptions(options *HeaderGeneratorOptions) HeaderGeneratorOptions {
 	headerOptions := g.globalOptions
 	if options != nil {
 		if options.Browsers != nil {
@@ -200,8 +388,9 @@ func (g *HeaderGenerator) GetHeaders(options *HeaderGeneratorOptions, requestDep
 		if options.BrowserListQuery != "" {
 			headerOptions.BrowserListQuery = options.BrowserListQuery
 		}
-		if options.OperatingSystems != nil {
+		if options.OperatingSystems != nil || options.OperatingSystemSpecifications != nil {
 			headerOptions.OperatingSystems = options.OperatingSystems
+			headerOptions.OperatingSystemSpecifications = options.OperatingSystemSpecifications
 		}
 		if options.Devices != nil {
 			headerOptions.Devices = options.Devices
@@ -213,19 +402,127 @@ func (g *HeaderGenerator) GetHeaders(options *HeaderGeneratorOptions, requestDep
 			headerOptions.HttpVersion = options.HttpVersion
 		}
 		headerOptions.Strict = options.Strict
+		if options.Temperature != 0 {
+			headerOptions.Temperature = options.Temperature
+		}
+		if options.ClientHintPolicy != nil {
+			headerOptions.ClientHintPolicy = options.ClientHintPolicy
+		}
+		if options.Casing != BrowserExact {
+			headerOptions.Casing = options.Casing
+		}
+		if options.SecFetch != nil {
+			headerOptions.SecFetch = options.SecFetch
+		}
+		if options.KeepConnectionClose {
+			headerOptions.KeepConnectionClose = true
+		}
+		if options.WebViewPackage != "" {
+			headerOptions.WebViewPackage = options.WebViewPackage
+		}
+		if options.InAppBrowser != "" {
+			headerOptions.InAppBrowser = options.InAppBrowser
+		}
+		if options.Electron != nil {
+			headerOptions.Electron = options.Electron
+		}
+		if options.Trace != nil {
+			headerOptions.Trace = options.Trace
+		}
+		if options.NoHttp1Fallback {
+			headerOptions.NoHttp1Fallback = true
+		}
+		if options.LocaleBaseTags != BaseTagsPerBrowser {
+			headerOptions.LocaleBaseTags = options.LocaleBaseTags
+		}
+		if options.consistent != nil {
+			headerOptions.consistent = options.consistent
+		}
+		if options.Timeout != 0 {
+			headerOptions.Timeout = options.Timeout
+		}
+		if options.AllowPartial {
+			headerOptions.AllowPartial = true
+		}
+		if options.RealisticDistribution {
+			headerOptions.RealisticDistribution = true
+		}
+		if options.VersionRecency != nil {
+			headerOptions.VersionRecency = options.VersionRecency
+		}
+		if !options.deadline.IsZero() {
+			headerOptions.deadline = options.deadline
+		}
+	}
+	return headerOptions
+}
+
+func (g *HeaderGenerator) GetHeaders(options *HeaderGeneratorOptions, requestDependentHeaders map[string]string, userAgentValues []string) (map[string]string, error) {
+	headers, _, err := g.GetHeadersWithInfo(options, requestDependentHeaders, userAgentValues)
+	return headers, err
+}
+
+// GetHeadersWithInfo is GetHeaders that also returns what was sampled for the headers.
+func (g *HeaderGenerator) GetHeadersWithInfo(options *HeaderGeneratorOptions, requestDependentHeaders map[string]string, userAgentValues []string) (map[string]string, *GenerationInfo, error) {
+	started := time.Now()
+	headers, info, err := g.generateHeaders(options, requestDependentHeaders, userAgentValues)
+	g.Metrics.ObserveGeneration(time.Since(started), err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return applyHeaderCasing(headers, g.ResolveOptions(options).Casing), info, nil
+}
+
+// generateHeaders samples headers with the names cased as the browser sends them.
+// A panic while sampling, e.g. on a malformed network definition, is returned as a
+// *bayesian.SamplingPanic.
+func (g *HeaderGenerator) generateHeaders(options *HeaderGeneratorOptions, requestDependentHeaders map[string]string, userAgentValues []string) (_ map[string]string, _ *GenerationInfo, err error) {
+	defer bayesian.RecoverSampling(&err)
+	headerOptions := g.ResolveOptions(options)
+	if headerOptions.deadline.IsZero() && headerOptions.Timeout > 0 {
+		headerOptions.deadline = time.Now().Add(headerOptions.Timeout)
+	}
+	if _, ok := inAppBrowsers[headerOptions.InAppBrowser]; headerOptions.InAppBrowser != "" && !ok {
+		return nil, nil, fmt.Errorf("unsupported in-app browser %q", headerOptions.InAppBrowser)
+	}
+	if err := validateOptions(&headerOptions, userAgentValues); err != nil {
+		return nil, nil, err
+	}
+	if err := g.checkCompatibility(&headerOptions); err != nil {
+		return nil, nil, err
+	}
+
+	// The input network only knows operating system names, so version ranges are enforced by
+	// restricting the user agents the header network may produce.
+	if hasVersionRange(PrepareOperatingSystems(&headerOptions)) {
+		allowed := g.AvailableUserAgents(&headerOptions)
+		if userAgentValues != nil {
+			allowed = bayesian.ArrayIntersection(userAgentValues, allowed)
+		}
+		if len(allowed) == 0 {
+			return nil, nil, errors.New("No user agent in the dataset matches the requested operating system versions.")
+		}
+		userAgentValues = allowed
 	}
 
 	possibleAttributeValues := g.getPossibleAttributeValues(&headerOptions)
 
 	var http1Constraints, http2Constraints map[string][]string
 	if len(userAgentValues) > 0 {
-		http1Constraints = network.GetConstraintClosure(g.headerGeneratorNetwork, map[string][]string{"User-Agent": userAgentValues})
-		http2Constraints = network.GetConstraintClosure(g.headerGeneratorNetwork, map[string][]string{"user-agent": userAgentValues})
+		var err error
+		http1Constraints, err = bayesian.GetConstraintClosure(g.headerGeneratorNetwork, map[string][]string{"User-Agent": userAgentValues})
+		if err != nil {
+			return nil, nil, err
+		}
+		http2Constraints, err = bayesian.GetConstraintClosure(g.headerGeneratorNetwork, map[string][]string{"user-agent": userAgentValues})
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	inputConstraints := make(map[string][]string)
 	for key, values := range possibleAttributeValues {
-		if key == "*BROWSER_HTTP" {
+		if key == BrowserHttpNodeName {
 			var filtered []string
 			for _, x := range values {
 				parts := strings.Split(x, "|")
@@ -240,7 +537,7 @@ func (g *HeaderGenerator) GetHeaders(options *HeaderGeneratorOptions, requestDep
 					httpValues = http1Constraints
 				}
 
-				if httpValues == nil || slices.Contains(httpValues["*BROWSER"], browserName) {
+				if httpValues == nil || slices.Contains(httpValues[constants.BrowserNodeName], browserName) {
 					filtered = append(filtered, x)
 				}
 			}
@@ -259,41 +556,52 @@ func (g *HeaderGenerator) GetHeaders(options *HeaderGeneratorOptions, requestDep
 		inputConstraints[key] = filtered
 	}
 
-	inputSample := g.inputGeneratorNetwork.GenerateConsistentSampleWhenPossible(inputConstraints)
+	inputSamplingOptions := &bayesian.SamplingOptions{
+		Temperature: headerOptions.Temperature,
+		Consistent:  headerOptions.consistent,
+		Trace:       headerOptions.Trace,
+		Deadline:    headerOptions.deadline,
+	}
+	inputSample := g.inputGeneratorNetwork.GenerateConsistentSample(inputConstraints, inputSamplingOptions)
+	if sampler := g.newReweightingSampler(&headerOptions, inputConstraints); sampler != nil && len(inputSample) > 0 {
+		for attempt := 1; attempt < reweightAttempts && !sampler.accepts(inputSample) && !headerOptions.expired(); attempt++ {
+			if sample := g.inputGeneratorNetwork.GenerateConsistentSample(inputConstraints, inputSamplingOptions); len(sample) > 0 {
+				inputSample = sample
+			}
+		}
+	}
+
+	partial := false
+	if len(inputSample) == 0 && headerOptions.expired() {
+		if !headerOptions.AllowPartial {
+			return nil, nil, ErrTimeout
+		}
+		// Without the outcome feedback the constraints are satisfied at once if they can be at all,
+		// otherwise they are relaxed below.
+		inputSample = g.inputGeneratorNetwork.GenerateConsistentSample(inputConstraints, &bayesian.SamplingOptions{
+			Temperature: headerOptions.Temperature,
+			Trace:       headerOptions.Trace,
+		})
+		partial = true
+	}
 
 	if len(inputSample) == 0 {
-		if headerOptions.HttpVersion == "1" {
+		if headerOptions.HttpVersion == "1" && !headerOptions.NoHttp1Fallback {
 			newOpts := headerOptions
 			newOpts.HttpVersion = "2"
-			headers2, err := g.GetHeaders(&newOpts, requestDependentHeaders, userAgentValues)
+			headers2, info, err := g.generateHeaders(&newOpts, requestDependentHeaders, userAgentValues)
 			if err != nil {
-				return nil, err
-			}
-
-			pascalize := func(name string) string {
-				parts := strings.Split(name, "-")
-				for i, p := range parts {
-					if len(p) > 0 {
-						parts[i] = string(unicode.ToUpper(rune(p[0]))) + strings.ToLower(p[1:])
-					}
-				}
-				return strings.Join(parts, "-")
+				return nil, nil, err
 			}
 
 			converted := make(map[string]string)
 			for name, value := range headers2 {
-				if strings.HasPrefix(name, "sec-ch-ua") {
-					converted[name] = value
-					continue
-				}
-				if name == "dnt" || name == "rtt" || name == "ect" {
-					converted[strings.ToUpper(name)] = value
-					continue
-				}
-				converted[pascalize(name)] = value
+				converted[g.http1HeaderName(info.Browser, name)] = value
 			}
+			info.HttpVersion = "1"
+			info.Http1Fallback = true
 
-			return g.OrderHeaders(converted, nil), nil
+			return converted, info, nil
 		}
 
 		relaxationIndex := -1
@@ -306,7 +614,7 @@ func (g *HeaderGenerator) GetHeaders(options *HeaderGeneratorOptions, requestDep
 				case "devices":
 					set = options.Devices != nil
 				case "operatingSystems":
-					set = options.OperatingSystems != nil
+					set = options.OperatingSystems != nil || options.OperatingSystemSpecifications != nil
 				case "browsers":
 					set = options.Browsers != nil
 				case "browserListQuery":
@@ -320,10 +628,11 @@ func (g *HeaderGenerator) GetHeaders(options *HeaderGeneratorOptions, requestDep
 		}
 
 		if headerOptions.Strict || relaxationIndex == -1 {
-			return nil, errors.New("No headers based on this input can be generated. Please relax or change some of the requirements you specified.")
+			return nil, nil, errors.New("No headers based on this input can be generated. Please relax or change some of the requirements you specified.")
 		}
 
 		relaxedOptions := *options
+		relaxedOptions.deadline = headerOptions.deadline
 		switch g.relaxationOrder[relaxationIndex] {
 		case "locales":
 			relaxedOptions.Locales = nil
@@ -331,12 +640,14 @@ func (g *HeaderGenerator) GetHeaders(options *HeaderGeneratorOptions, requestDep
 			relaxedOptions.Devices = nil
 		case "operatingSystems":
 			relaxedOptions.OperatingSystems = nil
+			relaxedOptions.OperatingSystemSpecifications = nil
 		case "browsers":
 			relaxedOptions.Browsers = nil
 		case "browserListQuery":
 			relaxedOptions.BrowserListQuery = ""
 		}
-		return g.GetHeaders(&relaxedOptions, requestDependentHeaders, userAgentValues)
+		g.Metrics.AddRelaxation()
+		return g.generateHeaders(&relaxedOptions, requestDependentHeaders, userAgentValues)
 	}
 
 	generatedSample := g.headerGeneratorNetwork.GenerateSample(inputSample)
@@ -350,28 +661,31 @@ func (g *HeaderGenerator) GetHeaders(options *HeaderGeneratorOptions, requestDep
 		secFetchAttributeNames = Http1SecFetchAttributes
 	}
 
-	generatedSample[acceptLanguageFieldName] = g.getAcceptLanguageField(headerOptions.Locales)
-
-	isChrome := generatedHttpAndBrowser.Name == "chrome"
-	isFirefox := generatedHttpAndBrowser.Name == "firefox"
-	isEdge := generatedHttpAndBrowser.Name == "edge"
-
-	genV0 := 0
-	if len(generatedHttpAndBrowser.Version) > 0 {
-		genV0 = generatedHttpAndBrowser.Version[0]
-	}
-
-	hasSecFetch := (isChrome && genV0 >= 76) || (isFirefox && genV0 >= 90) || (isEdge && genV0 >= 79)
+	// navigator.languages is built from the same list, see GenerationInfo.Languages.
+	languages := acceptLanguages(headerOptions.Locales, headerOptions.LocaleBaseTags.appendsBaseTags(generatedHttpAndBrowser.Name))
+	generatedSample[acceptLanguageFieldName] = formatAcceptLanguage(languages)
 
-	if hasSecFetch {
-		generatedSample[secFetchAttributeNames["site"]] = "same-site"
-		generatedSample[secFetchAttributeNames["mode"]] = "navigate"
-		generatedSample[secFetchAttributeNames["user"]] = "?1"
-		generatedSample[secFetchAttributeNames["dest"]] = "document"
+	if g.capabilities.SupportsHeader(generatedHttpAndBrowser.Name, generatedHttpAndBrowser.Version, "sec-fetch-site") {
+		secFetch := headerOptions.SecFetch
+		if secFetch == nil {
+			secFetch = &NavigationSecFetch
+		}
+		for attribute, value := range map[string]string{
+			"site": secFetch.Site,
+			"mode": secFetch.Mode,
+			"user": secFetch.User,
+			"dest": secFetch.Dest,
+		} {
+			if value == "" {
+				delete(generatedSample, secFetchAttributeNames[attribute])
+			} else {
+				generatedSample[secFetchAttributeNames[attribute]] = value
+			}
+		}
 	}
 
 	for attribute, val := range generatedSample {
-		if strings.ToLower(attribute) == "connection" && val == "close" {
+		if strings.ToLower(attribute) == "connection" && val == "close" && !headerOptions.KeepConnectionClose {
 			delete(generatedSample, attribute)
 		}
 		if strings.HasPrefix(attribute, "*") || val == MissingValueDatasetToken {
@@ -379,45 +693,59 @@ func (g *HeaderGenerator) GetHeaders(options *HeaderGeneratorOptions, requestDep
 		}
 	}
 
-	for k, v := range requestDependentHeaders {
-		generatedSample[k] = v
+	device := inputSample[DeviceNodeName]
+	if headerOptions.generatesWebView() && device == "mobile" && generatedHttpAndBrowser.Name == "chrome" {
+		if headerOptions.InAppBrowser != "" {
+			applyInAppBrowser(generatedSample, headerOptions.InAppBrowser)
+		} else {
+			applyWebView(generatedSample, headerOptions.WebViewPackage)
+		}
+		device = DeviceWebView
 	}
-
-	return g.OrderHeaders(generatedSample, g.headersOrder[generatedHttpAndBrowser.Name]), nil
-}
-
-func (g *HeaderGenerator) OrderHeaders(headers map[string]string, order []string) map[string]string {
-	if order == nil || len(order) == 0 {
-		order = g.getOrderFromUserAgent(headers)
+	if headerOptions.Electron != nil && generatedHttpAndBrowser.Name == "chrome" && !applyElectron(generatedSample, headerOptions.Electron) {
+		return nil, nil, errors.New("No Electron release ships the sampled Chrome version.")
 	}
 
-	orderedSample := make(map[string]string)
-	for _, attribute := range order {
-		if val, ok := headers[attribute]; ok {
-			// In Go, map iteration is unordered, but we can't enforce order natively in map[string]string
-			// Assuming consumer might sort or just expects map. We return the map directly.
-			// True ordered headers require []struct{Key, Value string} in Go
-			orderedSample[attribute] = val
+	// The dataset holds brand lists of mixed formats, so sec-ch-ua is derived from the user agent.
+	if _, ok := generatedSample["sec-ch-ua"]; ok {
+		if brands := userAgentClientHintBrands(GetUserAgent(generatedSample)); brands != nil {
+			generatedSample["sec-ch-ua"] = FormatClientHintBrands(brands)
 		}
 	}
 
-	for attribute, val := range headers {
-		if !slices.Contains(order, attribute) {
-			orderedSample[attribute] = val
+	g.capabilities.apply(generatedSample)
+
+	for k, v := range requestDependentHeaders {
+		generatedSample[k] = v
+		if strings.EqualFold(k, "accept-language") {
+			languages = ParseAcceptLanguage(v)
 		}
 	}
 
-	return orderedSample
-}
+	info := &GenerationInfo{
+		Browser:         generatedHttpAndBrowser.Name,
+		Version:         versionString(generatedHttpAndBrowser.Version),
+		OperatingSystem: inputSample[OperatingSystemNodeName],
+		Device:          device,
+		HttpVersion:     generatedHttpAndBrowser.HttpVersion,
+		Languages:       languages,
+		Partial:         partial,
+	}
+	info.setTLSFingerprints()
 
-func (g *HeaderGenerator) getOrderFromUserAgent(headers map[string]string) []string {
-	userAgent := GetUserAgent(headers)
-	browser := GetBrowser(userAgent)
+	return generatedSample, info, nil
+}
 
-	if browser == "" {
-		return nil
+// OrderHeaders returns a copy of headers.
+//
+// Deprecated: maps do not keep the order of their keys, so the copy is not ordered. Use
+// OrderedHeaders or HeaderOrder.
+func (g *HeaderGenerator) OrderHeaders(headers map[string]string, order []string) map[string]string {
+	ordered := make(map[string]string, len(headers))
+	for _, kv := range g.OrderedHeaders(headers, order) {
+		ordered[kv.Name] = kv.Value
 	}
-	return g.headersOrder[browser]
+	return ordered
 }
 
 func (g *HeaderGenerator) getPossibleAttributeValues(headerOptions *HeaderGeneratorOptions) map[string][]string {
@@ -427,10 +755,23 @@ func (g *HeaderGenerator) getPossibleAttributeValues(headerOptions *HeaderGenera
 
 	possibleAttributeValues := make(map[string][]string)
 	possibleAttributeValues[BrowserHttpNodeName] = browserHttpOptions
-	possibleAttributeValues[OperatingSystemNodeName] = headerOptions.OperatingSystems
+	for _, os := range PrepareOperatingSystems(headerOptions) {
+		possibleAttributeValues[OperatingSystemNodeName] = append(possibleAttributeValues[OperatingSystemNodeName], os.Name)
+	}
 
 	if len(headerOptions.Devices) > 0 {
-		possibleAttributeValues[DeviceNodeName] = headerOptions.Devices
+		possibleAttributeValues[DeviceNodeName] = inputDevices(headerOptions.Devices)
+	}
+	if headerOptions.Electron != nil {
+		possibleAttributeValues[DeviceNodeName] = []string{"desktop"}
+		possibleAttributeValues[BrowserHttpNodeName] = electronBrowserOptions(possibleAttributeValues[BrowserHttpNodeName])
+	}
+	if headerOptions.generatesWebView() {
+		possibleAttributeValues[DeviceNodeName] = []string{"mobile"}
+		possibleAttributeValues[OperatingSystemNodeName] = bayesian.ArrayIntersection(possibleAttributeValues[OperatingSystemNodeName], []string{"android"})
+		possibleAttributeValues[BrowserHttpNodeName] = slices.DeleteFunc(possibleAttributeValues[BrowserHttpNodeName], func(browser string) bool {
+			return !strings.HasPrefix(browser, "chrome/")
+		})
 	}
 
 	return possibleAttributeValues
@@ -439,72 +780,36 @@ func (g *HeaderGenerator) getPossibleAttributeValues(headerOptions *HeaderGenera
 func (g *HeaderGenerator) getBrowserHttpOptions(browsers []BrowserSpecification) []string {
 	var browserHttpOptions []string
 	for _, browser := range browsers {
+		g.warnSparseRange(browser)
 		for _, browserOption := range g.uniqueBrowsers {
-			if browser.Name == browserOption.Name {
-				browserMajorVersion := 0
-				if len(browserOption.Version) > 0 {
-					browserMajorVersion = browserOption.Version[0]
-				}
-
-				if (browser.MinVersion == 0 || browser.MinVersion <= browserMajorVersion) &&
-					(browser.MaxVersion == 0 || browser.MaxVersion >= browserMajorVersion) &&
-					(browser.HttpVersion == "0" || browser.HttpVersion == "" || browser.HttpVersion == browserOption.HttpVersion) {
-					browserHttpOptions = append(browserHttpOptions, browserOption.CompleteString)
-				}
+			if browser.matches(browserOption) {
+				browserHttpOptions = append(browserHttpOptions, browserOption.CompleteString)
 			}
 		}
 	}
 	return browserHttpOptions
 }
 
-func (g *HeaderGenerator) getAcceptLanguageField(localesFromOptions []string) string {
-	locales := make([]string, len(localesFromOptions))
-	copy(locales, localesFromOptions)
-
-	var highLevelLocales []string
-	for _, locale := range locales {
-		if !strings.Contains(locale, "-") {
-			highLevelLocales = append(highLevelLocales, locale)
-		}
+// matches reports whether browserOption is a version of the browser within the specification.
+func (browser BrowserSpecification) matches(browserOption HttpBrowserObject) bool {
+	if browser.Name != browserOption.Name {
+		return false
 	}
-
-	for _, locale := range locales {
-		if !slices.Contains(highLevelLocales, locale) {
-			highLevelEquivalentPresent := false
-			for _, highLevelLocale := range highLevelLocales {
-				if strings.Contains(locale, highLevelLocale) {
-					highLevelEquivalentPresent = true
-					break
-				}
-			}
-			if !highLevelEquivalentPresent {
-				highLevelLocales = append(highLevelLocales, locale)
-			}
-		}
+	browserMajorVersion := 0
+	if len(browserOption.Version) > 0 {
+		browserMajorVersion = browserOption.Version[0]
 	}
 
-	highLevelLocales = ShuffleArray(highLevelLocales)
-	locales = ShuffleArray(locales)
-
-	var localesInAddingOrder []string
-	for _, highLevelLocale := range highLevelLocales {
-		for _, locale := range locales {
-			if strings.Contains(locale, highLevelLocale) && !slices.Contains(highLevelLocales, locale) {
-				localesInAddingOrder = append(localesInAddingOrder, locale)
-			}
-		}
-		localesInAddingOrder = append(localesInAddingOrder, highLevelLocale)
-	}
-
-	if len(localesInAddingOrder) == 0 {
-		return ""
-	}
-
-	acceptLanguageFieldValue := localesInAddingOrder[0]
-	for x := 1; x < len(localesInAddingOrder); x++ {
-		q := 1.0 - (float64(x) * 0.1)
-		acceptLanguageFieldValue += "," + localesInAddingOrder[x] + ";q=" + strconv.FormatFloat(q, 'f', 1, 64)
-	}
+	return (browser.MinVersion == 0 || browser.MinVersion <= browserMajorVersion) &&
+		(browser.MaxVersion == 0 || browser.MaxVersion >= browserMajorVersion) &&
+		(browser.HttpVersion == "0" || browser.HttpVersion == "" || browser.HttpVersion == browserOption.HttpVersion)
+}
 
-	return acceptLanguageFieldValue
+// HeaderProvider is the header generation API of HeaderGenerator. Depend on it instead of the concrete
+// generator to substitute headers in tests.
+type HeaderProvider interface {
+	GetHeaders(options *HeaderGeneratorOptions, requestDependentHeaders map[string]string, userAgentValues []string) (map[string]string, error)
+	GetHeadersForRequest(options *HeaderGeneratorOptions, request *Request) (map[string]string, error)
 }
+
+var _ HeaderProvider = (*HeaderGenerator)(nil)
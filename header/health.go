@@ -0,0 +1,81 @@
+package header
+
+import (
+	"fmt"
+	"io/fs"
+
+	"fingerprint-go/bayesian"
+)
+
+// DataIntegrity decides what a generator does when some of its data files cannot be loaded.
+type DataIntegrity int
+
+const (
+	// DataIntegrityWarn prints a warning for each missing data file and degrades: without
+	// headers-order.json headers come in arbitrary order, without browser-helper-file.json browsers are
+	// not filtered, and without a network definition nothing can be generated.
+	DataIntegrityWarn DataIntegrity = iota
+	// DataIntegrityError makes the generator constructor fail when a data file cannot be loaded.
+	DataIntegrityError
+)
+
+// DataAsset is the load status of a data file.
+type DataAsset struct {
+	Name   string `json:"name"`
+	Loaded bool   `json:"loaded"`
+	Error  string `json:"error,omitempty"`
+}
+
+// DataHealth reports which data files a generator loaded.
+type DataHealth struct {
+	Assets []DataAsset `json:"assets"`
+}
+
+// Healthy reports whether every data file was loaded.
+func (h DataHealth) Healthy() bool {
+	for _, asset := range h.Assets {
+		if !asset.Loaded {
+			return false
+		}
+	}
+	return true
+}
+
+// Check applies level to the data files that were not loaded.
+func (h DataHealth) Check(level DataIntegrity) error {
+	for _, asset := range h.Assets {
+		if asset.Loaded {
+			continue
+		}
+		if level == DataIntegrityError {
+			return fmt.Errorf("The data file %s could not be loaded: %s.", asset.Name, asset.Error)
+		}
+		fmt.Printf("Warning: the data file %s could not be loaded, generation is degraded: %s\n", asset.Name, asset.Error)
+	}
+	return nil
+}
+
+// Health reports which data files the generator loaded.
+func (g *HeaderGenerator) Health() DataHealth {
+	return DataHealth{Assets: append([]DataAsset(nil), g.assets...)}
+}
+
+// loadedAsset returns the status of the data file name given the error reading or parsing it.
+func loadedAsset(name string, err error) DataAsset {
+	if err != nil {
+		return DataAsset{Name: name, Error: err.Error()}
+	}
+	return DataAsset{Name: name, Loaded: true}
+}
+
+// NetworkAsset loads the network definition name from fsys and returns its status with it.
+func NetworkAsset(fsys fs.FS, name string) (*bayesian.Network, DataAsset) {
+	network, err := bayesian.ReadNetworkFS(fsys, name)
+	if err != nil {
+		return network, loadedAsset(name, err)
+	}
+	if len(network.NodesByName) == 0 {
+		return network, loadedAsset(name, fmt.Errorf("the network definition has no nodes"))
+	}
+	return network, loadedAsset(name, nil)
+}
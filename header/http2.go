@@ -0,0 +1,48 @@
+package header
+
+import "strings"
+
+// HTTP2Settings are the values a browser announces in the SETTINGS frame of its HTTP/2 connections and
+// the connection window it grants with the first WINDOW_UPDATE. Zero values are not announced.
+type HTTP2Settings struct {
+	HeaderTableSize      uint32 `json:"headerTableSize,omitempty"`
+	EnablePush           bool   `json:"enablePush"`
+	MaxConcurrentStreams uint32 `json:"maxConcurrentStreams,omitempty"`
+	InitialWindowSize    uint32 `json:"initialWindowSize,omitempty"`
+	MaxFrameSize         uint32 `json:"maxFrameSize,omitempty"`
+	MaxHeaderListSize    uint32 `json:"maxHeaderListSize,omitempty"`
+	// ConnectionWindowSize is the receive window of the connection, 65535 plus the increment of the
+	// first WINDOW_UPDATE.
+	ConnectionWindowSize uint32 `json:"connectionWindowSize"`
+}
+
+var browserHTTP2Settings = map[string]HTTP2Settings{
+	"chrome": {
+		HeaderTableSize:      65536,
+		InitialWindowSize:    6291456,
+		MaxHeaderListSize:    262144,
+		ConnectionWindowSize: 15728640,
+	},
+	"firefox": {
+		HeaderTableSize:      65536,
+		InitialWindowSize:    131072,
+		MaxFrameSize:         16384,
+		ConnectionWindowSize: 12582912,
+	},
+	"safari": {
+		MaxConcurrentStreams: 100,
+		InitialWindowSize:    2097152,
+		ConnectionWindowSize: 10485760,
+	},
+}
+
+// HTTP2SettingsFor returns the HTTP/2 settings of browser ("chrome", "edge", "firefox" or "safari").
+// ok is false for other browsers.
+func HTTP2SettingsFor(browser string) (settings HTTP2Settings, ok bool) {
+	browser = strings.ToLower(browser)
+	if browser == "edge" {
+		browser = "chrome"
+	}
+	settings, ok = browserHTTP2Settings[browser]
+	return settings, ok
+}
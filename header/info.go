@@ -0,0 +1,56 @@
+package header
+
+import (
+	"strconv"
+	"strings"
+)
+
+// GenerationInfo describes the browser identity headers were generated for, so callers can route,
+// log or pick matching TLS and HTTP/2 profiles without parsing the user agent.
+type GenerationInfo struct {
+	Browser         string `json:"browser"`
+	Version         string `json:"version"`
+	OperatingSystem string `json:"operatingSystem"`
+	Device          string `json:"device"`
+	// HttpVersion is the protocol the headers are meant for.
+	HttpVersion string `json:"httpVersion"`
+	// Http1Fallback is set when no HTTP/1 headers matched and HTTP/2 headers were re-cased for HTTP/1.
+	Http1Fallback bool `json:"http1Fallback,omitempty"`
+	// Languages are the languages of Accept-Language in order and casing, which navigator.languages
+	// reports as well.
+	Languages []string `json:"languages,omitempty"`
+	// JA3 and JA4 are the TLS fingerprints the browser produces, see TLSProfile. A client claiming the
+	// identity should produce them at its egress.
+	JA3 string `json:"ja3,omitempty"`
+	JA4 string `json:"ja4,omitempty"`
+	// Partial is set when the Timeout of the options passed and AllowPartial returned an identity
+	// sampled without the outcome feedback or some of the constraints.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// InfoFromHeaders derives the GenerationInfo of headers that were not generated by this package.
+func InfoFromHeaders(headers map[string]string) *GenerationInfo {
+	userAgent := GetUserAgent(headers)
+	httpVersion := "1"
+	if _, ok := headers["user-agent"]; ok {
+		httpVersion = "2"
+	}
+	info := &GenerationInfo{
+		Browser:         GetBrowser(userAgent),
+		Version:         versionString(GetBrowserVersion(userAgent)),
+		OperatingSystem: GetOperatingSystem(userAgent),
+		Device:          GetDevice(userAgent),
+		HttpVersion:     httpVersion,
+		Languages:       ParseAcceptLanguage(getHeaderValue(headers, "accept-language")),
+	}
+	info.setTLSFingerprints()
+	return info
+}
+
+func versionString(version []int) string {
+	parts := make([]string, len(version))
+	for i, v := range version {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ".")
+}
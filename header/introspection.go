@@ -0,0 +1,70 @@
+package header
+
+import (
+	"slices"
+)
+
+// PossibleValues returns the values the header or input network can produce for the named node.
+func (g *HeaderGenerator) PossibleValues(node string) []string {
+	if n, ok := g.headerGeneratorNetwork.NodesByName[node]; ok {
+		return n.Definition.PossibleValues
+	}
+	if n, ok := g.inputGeneratorNetwork.NodesByName[node]; ok {
+		return n.Definition.PossibleValues
+	}
+	return nil
+}
+
+// AvailableUserAgents returns the user agents in the header dataset matching the browsers,
+// operating systems and devices of options.
+func (g *HeaderGenerator) AvailableUserAgents(options *HeaderGeneratorOptions) []string {
+	headerOptions := g.ResolveOptions(options)
+	browsers := g.prepareBrowsersConfig(headerOptions.Browsers, headerOptions.BrowserListQuery, headerOptions.HttpVersion)
+
+	var userAgents []string
+	for _, node := range []string{"user-agent", "User-Agent"} {
+		for _, userAgent := range g.PossibleValues(node) {
+			if userAgent == MissingValueDatasetToken || slices.Contains(userAgents, userAgent) {
+				continue
+			}
+			if matchesUserAgent(userAgent, browsers, &headerOptions) {
+				userAgents = append(userAgents, userAgent)
+			}
+		}
+	}
+	return userAgents
+}
+
+// matchesUserAgent reports whether userAgent satisfies one of browsers and the operating system and
+// device restrictions of options. Operating system versions the user agent does not reveal are accepted.
+func matchesUserAgent(userAgent string, browsers []BrowserSpecification, options *HeaderGeneratorOptions) bool {
+	if operatingSystems := PrepareOperatingSystems(options); len(operatingSystems) > 0 {
+		operatingSystem := GetOperatingSystem(userAgent)
+		if !slices.ContainsFunc(operatingSystems, func(os OperatingSystemSpecification) bool { return os.Name == operatingSystem }) {
+			return false
+		}
+		if version, ok := GetOperatingSystemVersion(userAgent); ok && !OperatingSystemVersionAllowed(operatingSystems, operatingSystem, version) {
+			return false
+		}
+	}
+	if len(options.Devices) > 0 && !slices.Contains(inputDevices(options.Devices), GetDevice(userAgent)) {
+		return false
+	}
+	if len(browsers) == 0 {
+		return true
+	}
+
+	browser := GetBrowser(userAgent)
+	majorVersion := 0
+	if version := GetBrowserVersion(userAgent); len(version) > 0 {
+		majorVersion = version[0]
+	}
+	for _, spec := range browsers {
+		if spec.Name == browser &&
+			(spec.MinVersion == 0 || spec.MinVersion <= majorVersion) &&
+			(spec.MaxVersion == 0 || spec.MaxVersion >= majorVersion) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package header
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// LocaleBaseTags decides whether Accept-Language lists the base language of regional locales, e.g. "en"
+// after "en-US".
+type LocaleBaseTags int
+
+const (
+	// BaseTagsPerBrowser appends base languages like the generated browser does by default: Chrome, Edge
+	// and Firefox list "en-US,en", Safari only lists the preferred languages.
+	BaseTagsPerBrowser LocaleBaseTags = iota
+	// BaseTagsAsGiven lists exactly the requested locales.
+	BaseTagsAsGiven
+	// BaseTagsAppend appends the base language after the regional locales of each language.
+	BaseTagsAppend
+)
+
+// appendsBaseTags reports whether browser lists base languages under the policy.
+func (b LocaleBaseTags) appendsBaseTags(browser string) bool {
+	switch b {
+	case BaseTagsAsGiven:
+		return false
+	case BaseTagsAppend:
+		return true
+	}
+	return browser != "safari"
+}
+
+// LanguageBase returns the primary language subtag of a BCP 47 language tag, e.g. "zh" for
+// "zh-Hant-TW" and "fil" for "fil-PH".
+func LanguageBase(tag string) string {
+	base, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	return strings.ToLower(base)
+}
+
+// acceptLanguages orders locales the way they are listed in Accept-Language and navigator.languages:
+// locales are grouped by language, the groups and the regional locales within a group are shuffled,
+// and the base language closes its group when it was requested or appendBase is set.
+func acceptLanguages(locales []string, appendBase bool) []string {
+	type group struct {
+		base      string
+		regionals []string
+		hasBase   bool
+	}
+	var groups []*group
+	for _, locale := range locales {
+		base := LanguageBase(locale)
+		i := slices.IndexFunc(groups, func(g *group) bool { return g.base == base })
+		if i < 0 {
+			groups = append(groups, &group{base: base})
+			i = len(groups) - 1
+		}
+		switch {
+		case strings.EqualFold(locale, base):
+			groups[i].hasBase = true
+		case !slices.Contains(groups[i].regionals, locale):
+			groups[i].regionals = append(groups[i].regionals, locale)
+		}
+	}
+
+	rand.Shuffle(len(groups), func(i, j int) { groups[i], groups[j] = groups[j], groups[i] })
+	var languages []string
+	for _, g := range groups {
+		languages = append(languages, ShuffleArray(g.regionals)...)
+		if g.hasBase || appendBase && len(g.regionals) > 0 {
+			languages = append(languages, g.base)
+		}
+	}
+	return languages
+}
+
+// formatAcceptLanguage formats languages as an Accept-Language value with decreasing quality values.
+func formatAcceptLanguage(languages []string) string {
+	if len(languages) == 0 {
+		return ""
+	}
+
+	value := languages[0]
+	for x := 1; x < len(languages); x++ {
+		q := max(1.0-(float64(x)*0.1), 0.1)
+		value += "," + languages[x] + ";q=" + strconv.FormatFloat(q, 'f', 1, 64)
+	}
+	return value
+}
+
+// ParseAcceptLanguage returns the languages of an Accept-Language value in the order they are listed,
+// without quality values and wildcards.
+func ParseAcceptLanguage(value string) []string {
+	var languages []string
+	for _, entry := range strings.Split(value, ",") {
+		language, _, _ := strings.Cut(entry, ";")
+		language = strings.TrimSpace(language)
+		if language != "" && language != "*" {
+			languages = append(languages, language)
+		}
+	}
+	return languages
+}
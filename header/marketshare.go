@@ -0,0 +1,142 @@
+package header
+
+import (
+	"encoding/json"
+	"math/rand"
+	"slices"
+)
+
+// MarketShare is the share of web traffic per browser and operating system, e.g.
+// MarketShare{"chrome": {"windows": 0.22}}. The shares do not need to sum to 1.
+type MarketShare map[string]map[string]float64
+
+// DefaultMarketShare is the bundled market share table used by RealisticDistribution, approximating
+// the share of page views of 2025 across desktop and mobile. Data files override it with a
+// market-share.json of the same shape, so it can be updated without a new release.
+var DefaultMarketShare = MarketShare{
+	"chrome":  {"windows": 0.22, "macos": 0.045, "linux": 0.012, "android": 0.3, "ios": 0.03},
+	"edge":    {"windows": 0.06, "macos": 0.005, "linux": 0.001, "android": 0.002},
+	"firefox": {"windows": 0.025, "macos": 0.005, "linux": 0.006, "android": 0.005, "ios": 0.002},
+	"safari":  {"macos": 0.035, "ios": 0.16},
+}
+
+// reweightAttempts bounds the samples drawn for one identity under RealisticDistribution or
+// VersionRecency. When all are rejected, the last one is kept, so the target distribution is only
+// approximated under tight constraints.
+const reweightAttempts = 50
+
+// inputClass is the browser version and operating system of an input network sample.
+type inputClass struct {
+	browserHttp     string
+	operatingSystem string
+}
+
+// loadMarketShare parses the market-share.json data file.
+func loadMarketShare(data []byte) (MarketShare, error) {
+	var share MarketShare
+	if err := json.Unmarshal(data, &share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// inputDistribution returns the probability of every browser version and operating system in the
+// input network. It is computed once per generator.
+func (g *HeaderGenerator) inputDistribution() map[inputClass]float64 {
+	g.inputDistributionOnce.Do(func() {
+		g.inputClassProbabilities = make(map[inputClass]float64)
+		g.inputGeneratorNetwork.Enumerate(func(sample map[string]string, probability float64) {
+			g.inputClassProbabilities[sampleInputClass(sample)] += probability
+		})
+	})
+	return g.inputClassProbabilities
+}
+
+// sampleInputClass returns the browser version and operating system of an input network sample.
+func sampleInputClass(sample map[string]string) inputClass {
+	return inputClass{browserHttp: sample[BrowserHttpNodeName], operatingSystem: sample[OperatingSystemNodeName]}
+}
+
+// marketShareFactors returns, per browser version and operating system, the factor the dataset share
+// of its browser and operating system is multiplied with to get their market share. Combinations
+// outside the table keep their dataset share, and the combinations of the table share the same total
+// as in the dataset.
+func (g *HeaderGenerator) marketShareFactors(distribution map[inputClass]float64) map[inputClass]float64 {
+	type browserOS struct{ browser, operatingSystem string }
+	probabilities := make(map[browserOS]float64)
+	for class, probability := range distribution {
+		probabilities[browserOS{prepareHttpBrowserObject(class.browserHttp).Name, class.operatingSystem}] += probability
+	}
+
+	var datasetTotal, shareTotal float64
+	for combination, probability := range probabilities {
+		if share := g.marketShare[combination.browser][combination.operatingSystem]; share > 0 {
+			datasetTotal += probability
+			shareTotal += share
+		}
+	}
+	factors := make(map[inputClass]float64, len(distribution))
+	for class := range distribution {
+		combination := browserOS{prepareHttpBrowserObject(class.browserHttp).Name, class.operatingSystem}
+		factors[class] = 1
+		if share := g.marketShare[combination.browser][combination.operatingSystem]; share > 0 {
+			factors[class] = share / shareTotal * datasetTotal / probabilities[combination]
+		}
+	}
+	return factors
+}
+
+// reweightingSampler accepts input network samples with a probability proportional to the weight of
+// their browser version and operating system, which turns the dataset distribution into the target
+// distribution by rejection sampling.
+type reweightingSampler struct {
+	weights   map[inputClass]float64
+	maxWeight float64
+}
+
+// newReweightingSampler returns the sampler of the reweightings headerOptions enable, or nil when
+// there are none. The largest weight is taken over the classes inputConstraints allow, so that
+// constrained calls do not reject more than needed.
+func (g *HeaderGenerator) newReweightingSampler(headerOptions *HeaderGeneratorOptions, inputConstraints map[string][]string) *reweightingSampler {
+	if !headerOptions.RealisticDistribution && headerOptions.VersionRecency == nil {
+		return nil
+	}
+	distribution := g.inputDistribution()
+	sampler := &reweightingSampler{weights: make(map[inputClass]float64, len(distribution))}
+	for class := range distribution {
+		sampler.weights[class] = 1
+	}
+	if headerOptions.RealisticDistribution {
+		g.marketShareOnce.Do(func() { g.marketShareWeights = g.marketShareFactors(distribution) })
+		for class, factor := range g.marketShareWeights {
+			sampler.weights[class] *= factor
+		}
+	}
+	if headerOptions.VersionRecency != nil {
+		for class, factor := range headerOptions.VersionRecency.factors(distribution) {
+			sampler.weights[class] *= factor
+		}
+	}
+
+	browsers := inputConstraints[BrowserHttpNodeName]
+	operatingSystems := inputConstraints[OperatingSystemNodeName]
+	for class, weight := range sampler.weights {
+		if len(browsers) > 0 && !slices.Contains(browsers, class.browserHttp) {
+			continue
+		}
+		if len(operatingSystems) > 0 && !slices.Contains(operatingSystems, class.operatingSystem) {
+			continue
+		}
+		sampler.maxWeight = max(sampler.maxWeight, weight)
+	}
+	return sampler
+}
+
+// accepts decides whether sample is kept.
+func (s *reweightingSampler) accepts(sample map[string]string) bool {
+	weight, ok := s.weights[sampleInputClass(sample)]
+	if !ok || s.maxWeight <= 0 {
+		return true
+	}
+	return rand.Float64()*s.maxWeight < weight
+}
@@ -0,0 +1,164 @@
+package header
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// generationLatencyBuckets are the upper bounds, in seconds, of the generation latency histograms.
+var generationLatencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// Metrics counts the work of header and fingerprint generators and session managers, and serves it
+// in the Prometheus text exposition format. Set it as the Metrics of a HeaderGenerator, which its
+// FingerprintGenerator and SessionManagers share, and mount it on an HTTP server, e.g.
+// http.Handle("/metrics", metrics). A nil *Metrics records nothing and serves zero values.
+//
+// The Observe and Add methods record the work of the generators and are called by them; they are
+// exported for the fingerprint package and for callers generating through wrappers of their own.
+type Metrics struct {
+	generations            atomic.Int64
+	failures               atomic.Int64
+	fingerprintGenerations atomic.Int64
+	fingerprintFailures    atomic.Int64
+	relaxations            atomic.Int64
+	retries                atomic.Int64
+	rotations              atomic.Int64
+	sessionsActive         atomic.Int64
+
+	mu                 sync.Mutex
+	headerLatency      latencyHistogram
+	fingerprintLatency latencyHistogram
+}
+
+// latencyHistogram counts latencies into generationLatencyBuckets.
+type latencyHistogram struct {
+	buckets []int64
+	sum     float64
+	count   int64
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	if h.buckets == nil {
+		h.buckets = make([]int64, len(generationLatencyBuckets))
+	}
+	seconds := d.Seconds()
+	for i, bound := range generationLatencyBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{}
+}
+
+// ObserveGeneration records a header generation that took d and failed when err is not nil.
+func (m *Metrics) ObserveGeneration(d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.generations.Add(1)
+	if err != nil {
+		m.failures.Add(1)
+	}
+	m.mu.Lock()
+	m.headerLatency.observe(d)
+	m.mu.Unlock()
+}
+
+// ObserveFingerprintGeneration records a fingerprint generation, its headers included, that took d and
+// failed when err is not nil.
+func (m *Metrics) ObserveFingerprintGeneration(d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.fingerprintGenerations.Add(1)
+	if err != nil {
+		m.fingerprintFailures.Add(1)
+	}
+	m.mu.Lock()
+	m.fingerprintLatency.observe(d)
+	m.mu.Unlock()
+}
+
+// AddRetry records that a generation attempt was discarded and sampled again.
+func (m *Metrics) AddRetry() {
+	if m != nil {
+		m.retries.Add(1)
+	}
+}
+
+// AddRelaxation records that the constraints of a generation were relaxed.
+func (m *Metrics) AddRelaxation() {
+	if m != nil {
+		m.relaxations.Add(1)
+	}
+}
+
+// AddRotation records that a session identity was replaced.
+func (m *Metrics) AddRotation() {
+	if m != nil {
+		m.rotations.Add(1)
+	}
+}
+
+// AddSessions records that n sessions were started, or -n ended when n is negative.
+func (m *Metrics) AddSessions(n int64) {
+	if m != nil {
+		m.sessionsActive.Add(n)
+	}
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	if m == nil {
+		m = &Metrics{}
+	}
+	counter := func(name, help string, value int64) string {
+		return fmt.Sprintf("# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+	}
+
+	out := counter("fingerprint_generations_total", "Number of header generations.", m.generations.Load()) +
+		counter("fingerprint_generation_failures_total", "Number of header generations that failed.", m.failures.Load()) +
+		counter("fingerprint_fingerprint_generations_total", "Number of fingerprint generations.", m.fingerprintGenerations.Load()) +
+		counter("fingerprint_fingerprint_generation_failures_total", "Number of fingerprint generations that failed.", m.fingerprintFailures.Load()) +
+		counter("fingerprint_relaxations_total", "Number of times the generation constraints were relaxed.", m.relaxations.Load()) +
+		counter("fingerprint_retries_total", "Number of fingerprint generation attempts sampled again.", m.retries.Load()) +
+		counter("fingerprint_session_rotations_total", "Number of session identities replaced.", m.rotations.Load()) +
+		fmt.Sprintf("# HELP fingerprint_sessions_active Number of sessions held by session managers.\n# TYPE fingerprint_sessions_active gauge\nfingerprint_sessions_active %d\n", m.sessionsActive.Load())
+
+	histogram := func(name, help string, h *latencyHistogram) string {
+		out := fmt.Sprintf("# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+		for i, bound := range generationLatencyBuckets {
+			count := int64(0)
+			if h.buckets != nil {
+				count = h.buckets[i]
+			}
+			out += fmt.Sprintf("%s_bucket{le=\"%g\"} %d\n", name, bound, count)
+		}
+		out += fmt.Sprintf("%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+		out += fmt.Sprintf("%s_sum %g\n", name, h.sum)
+		return out + fmt.Sprintf("%s_count %d\n", name, h.count)
+	}
+
+	m.mu.Lock()
+	out += histogram("fingerprint_generation_duration_seconds", "Latency of header generations.", &m.headerLatency) +
+		histogram("fingerprint_fingerprint_generation_duration_seconds", "Latency of fingerprint generations, their headers included.", &m.fingerprintLatency)
+	m.mu.Unlock()
+
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// ServeHTTP serves the metrics to Prometheus.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
@@ -0,0 +1,253 @@
+package header
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HeaderPlacement places a header that is not part of the browser's header order, such as a custom
+// request dependent header, before or after a header of that order. When the anchor is not sent or
+// is not part of the order, the header goes at the end.
+type HeaderPlacement struct {
+	Name   string
+	Before string
+	After  string
+}
+
+// defaultHeaderPlacements are the positions browsers send well-known request dependent headers at.
+var defaultHeaderPlacements = map[string]HeaderPlacement{
+	"authorization":    {Name: "authorization", Before: "user-agent"},
+	"x-requested-with": {Name: "x-requested-with", Before: "user-agent"},
+	"content-type":     {Name: "content-type", Before: "user-agent"},
+	"origin":           {Name: "origin", After: "accept"},
+	"referer":          {Name: "referer", After: "sec-fetch-dest"},
+	"cookie":           {Name: "cookie", After: "accept-language"},
+}
+
+type headerPlacements struct {
+	mu         sync.RWMutex
+	placements map[string]HeaderPlacement
+}
+
+// RegisterHeader sets where a header outside the browser's header order is placed by HeaderOrder,
+// overriding the browser default for well-known headers like Authorization.
+func (g *HeaderGenerator) RegisterHeader(placement HeaderPlacement) {
+	g.placements.mu.Lock()
+	defer g.placements.mu.Unlock()
+	if g.placements.placements == nil {
+		g.placements.placements = make(map[string]HeaderPlacement)
+	}
+	g.placements.placements[strings.ToLower(placement.Name)] = placement
+}
+
+func (g *HeaderGenerator) placement(name string) (HeaderPlacement, bool) {
+	g.placements.mu.RLock()
+	defer g.placements.mu.RUnlock()
+	if placement, ok := g.placements.placements[name]; ok {
+		return placement, true
+	}
+	placement, ok := defaultHeaderPlacements[name]
+	return placement, ok
+}
+
+// HeaderRanks is a header order prepared for ordering headers, see NewHeaderRanks.
+type HeaderRanks map[string]int
+
+// NewHeaderRanks prepares order for AppendOrderedHeaders. Prepare an order once and reuse it, as
+// preparing it costs more than ordering headers by it.
+func NewHeaderRanks(order []string) HeaderRanks {
+	return headerRanks(order)
+}
+
+// maxCachedUserAgentRanks bounds the user agents whose header order HeaderOrder keeps.
+const maxCachedUserAgentRanks = 1024
+
+// userAgentRanks caches the header order of user agents, as parsing a user agent costs far more than
+// ordering its headers.
+type userAgentRanks struct {
+	mu    sync.Mutex
+	ranks map[string]map[string]int
+}
+
+// userAgentRanks returns the header order of the browser of userAgent.
+func (g *HeaderGenerator) userAgentRanks(userAgent string) map[string]int {
+	g.uaRanks.mu.Lock()
+	ranks, ok := g.uaRanks.ranks[userAgent]
+	g.uaRanks.mu.Unlock()
+	if ok {
+		return ranks
+	}
+
+	ranks = g.headerRanks[headerOrderKey(GetBrowser(userAgent), GetBrowserVersion(userAgent), g.headerRanks)]
+	g.uaRanks.mu.Lock()
+	if g.uaRanks.ranks == nil || len(g.uaRanks.ranks) >= maxCachedUserAgentRanks {
+		g.uaRanks.ranks = make(map[string]map[string]int)
+	}
+	g.uaRanks.ranks[userAgent] = ranks
+	g.uaRanks.mu.Unlock()
+	return ranks
+}
+
+// HeaderOrder returns the names of headers in the order the browser of their user agent sends them.
+// Headers outside the browser's order are placed as registered with RegisterHeader or, without a
+// placement, appended in alphabetical order.
+func (g *HeaderGenerator) HeaderOrder(headers map[string]string) []string {
+	scratch := orderHeaderNames(headers, g.userAgentRanks(GetUserAgent(headers)), g.placement)
+	defer orderScratchPool.Put(scratch)
+	return slices.Clone(scratch.order)
+}
+
+// OrderedHeaders returns headers in order or, when order is empty, in the order of the browser of
+// their user agent, placing the other headers like HeaderOrder does. Use AppendOrderedHeaders with
+// prepared ranks to order many requests by the same order.
+func (g *HeaderGenerator) OrderedHeaders(headers map[string]string, order []string) []KV {
+	var ranks HeaderRanks
+	if len(order) > 0 {
+		ranks = headerRanks(order)
+	}
+	return g.AppendOrderedHeaders(make([]KV, 0, len(headers)), headers, ranks)
+}
+
+// AppendOrderedHeaders appends headers to dst in the order of ranks or, when ranks is nil, in the
+// order of the browser of their user agent, placing the other headers like HeaderOrder does. Ordering
+// takes time linear in the number of headers and, when dst has room for them, does not allocate.
+func (g *HeaderGenerator) AppendOrderedHeaders(dst []KV, headers map[string]string, ranks HeaderRanks) []KV {
+	if ranks == nil {
+		ranks = g.userAgentRanks(GetUserAgent(headers))
+	}
+	scratch := orderHeaderNames(headers, ranks, g.placement)
+	for _, name := range scratch.order {
+		dst = append(dst, KV{Name: name, Value: headers[name]})
+	}
+	orderScratchPool.Put(scratch)
+	return dst
+}
+
+// headerOrderKey returns the headers-order.json key of a browser version: "chrome/120" when the data
+// files order that major version separately, the browser name otherwise.
+func headerOrderKey[T any](browser string, version []int, orders map[string]T) string {
+	if len(version) > 0 {
+		key := browser + "/" + strconv.Itoa(version[0])
+		if _, ok := orders[key]; ok {
+			return key
+		}
+	}
+	return browser
+}
+
+// headerRanks maps the lowercased names of a header order to their position. Names repeated with
+// another casing keep their first position.
+func headerRanks(order []string) map[string]int {
+	ranks := make(map[string]int, len(order))
+	for _, name := range order {
+		name = strings.ToLower(name)
+		if _, ok := ranks[name]; !ok {
+			ranks[name] = len(ranks)
+		}
+	}
+	return ranks
+}
+
+// orderScratch holds the buffers of orderHeaderNames, which are pooled so that ordering does not
+// allocate.
+type orderScratch struct {
+	slots    []string
+	rest     []string
+	placed   []placedHeader
+	unplaced []string
+	order    []string
+	lower    []byte
+}
+
+// placedHeader is a header placed next to the header of the order at rank.
+type placedHeader struct {
+	name  string
+	rank  int
+	after bool
+}
+
+var orderScratchPool = sync.Pool{New: func() any { return new(orderScratch) }}
+
+// orderHeaderNames orders the names of headers by ranks, see headerRanks, with the other headers
+// placed according to placementOf, into the order of a scratch from orderScratchPool. The caller puts
+// the scratch back once it has copied the order. Ranked headers are put straight into their slot and
+// placed headers are merged in next to their anchors, so ordering is linear in the number of headers
+// but for sorting the headers outside the order.
+func orderHeaderNames(headers map[string]string, ranks map[string]int, placementOf func(name string) (HeaderPlacement, bool)) *orderScratch {
+	scratch := orderScratchPool.Get().(*orderScratch)
+	slots := slices.Grow(scratch.slots[:0], len(ranks))[:len(ranks)]
+	clear(slots)
+	rest, placed, unplaced := scratch.rest[:0], scratch.placed[:0], scratch.unplaced[:0]
+	for header := range headers {
+		// Indexing the map with the converted bytes does not allocate the lowercased name.
+		scratch.lower = appendLower(scratch.lower[:0], header)
+		rank, ok := ranks[string(scratch.lower)]
+		if ok && slots[rank] == "" {
+			slots[rank] = header
+		} else {
+			rest = append(rest, header)
+		}
+	}
+
+	// The headers outside the order are split into those placed next to a sent header of the order
+	// and those appended at the end.
+	for _, header := range rest {
+		// strings.ToLower returns lowercase names, such as those of HTTP/2, without allocating.
+		placement, ok := placementOf(strings.ToLower(header))
+		anchor := cmp.Or(placement.Before, placement.After)
+		if ok && anchor != "" {
+			scratch.lower = appendLower(scratch.lower[:0], anchor)
+			if rank, ok := ranks[string(scratch.lower)]; ok && slots[rank] != "" {
+				placed = append(placed, placedHeader{name: header, rank: rank, after: placement.Before == ""})
+				continue
+			}
+		}
+		unplaced = append(unplaced, header)
+	}
+	slices.SortFunc(placed, func(a, b placedHeader) int {
+		if c := cmp.Compare(a.rank, b.rank); c != 0 {
+			return c
+		}
+		if a.after != b.after {
+			if a.after {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	slices.Sort(unplaced)
+
+	order := slices.Grow(scratch.order[:0], len(headers))
+	next := 0
+	for rank, header := range slots {
+		for ; next < len(placed) && placed[next].rank == rank && !placed[next].after; next++ {
+			order = append(order, placed[next].name)
+		}
+		if header != "" {
+			order = append(order, header)
+		}
+		for ; next < len(placed) && placed[next].rank == rank; next++ {
+			order = append(order, placed[next].name)
+		}
+	}
+	order = append(order, unplaced...)
+
+	scratch.slots, scratch.rest, scratch.placed, scratch.unplaced, scratch.order = slots, rest, placed, unplaced, order
+	return scratch
+}
+
+// appendLower appends the ASCII lowercase of name to b.
+func appendLower(b []byte, name string) []byte {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		b = append(b, c)
+	}
+	return b
+}
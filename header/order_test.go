@@ -0,0 +1,79 @@
+package header
+
+import (
+	"slices"
+	"testing"
+)
+
+// orderHeadersContains is the implementation of OrderHeaders before it was ranked, kept to benchmark
+// against: it looks every header up in the order with slices.Contains.
+func orderHeadersContains(headers map[string]string, order []string) map[string]string {
+	orderedSample := make(map[string]string)
+	for _, attribute := range order {
+		if val, ok := headers[attribute]; ok {
+			orderedSample[attribute] = val
+		}
+	}
+	for attribute, val := range headers {
+		if !slices.Contains(order, attribute) {
+			orderedSample[attribute] = val
+		}
+	}
+	return orderedSample
+}
+
+func BenchmarkOrderHeaders(b *testing.B) {
+	order := browserHeaderOrders["chrome"]["2"]
+	headers := map[string]string{
+		"sec-ch-ua":                 `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`,
+		"sec-ch-ua-mobile":          "?0",
+		"sec-ch-ua-platform":        `"Windows"`,
+		"upgrade-insecure-requests": "1",
+		"user-agent":                "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		"accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		"sec-fetch-site":            "none",
+		"sec-fetch-mode":            "navigate",
+		"sec-fetch-user":            "?1",
+		"sec-fetch-dest":            "document",
+		"accept-encoding":           "gzip, deflate, br, zstd",
+		"accept-language":           "en-US,en;q=0.9",
+		"priority":                  "u=0, i",
+		"authorization":             "Bearer token",
+		"x-custom":                  "1",
+	}
+	g := &HeaderGenerator{headerRanks: map[string]map[string]int{"chrome": headerRanks(order)}}
+
+	b.Run("contains", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			orderHeadersContains(headers, order)
+		}
+	})
+	b.Run("ranks-per-call", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			g.OrderedHeaders(headers, order)
+		}
+	})
+	b.Run("ranks-prepared", func(b *testing.B) {
+		ranks := NewHeaderRanks(order)
+		dst := make([]KV, 0, len(headers))
+		b.ReportAllocs()
+		for range b.N {
+			dst = g.AppendOrderedHeaders(dst[:0], headers, ranks)
+		}
+	})
+	// The order is looked up from the user agent, whose parse is cached.
+	b.Run("ranks-user-agent", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			g.OrderedHeaders(headers, nil)
+		}
+	})
+	b.Run("table", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			OrderHeaders(headers, "chrome", "2")
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package header
+
+import "strings"
+
+// KV is a header name and value.
+type KV struct {
+	Name  string
+	Value string
+}
+
+// browserHeaderOrders are the header orders of top-level navigations per browser and HTTP version,
+// used when no generator with the dataset's headers-order.json is at hand.
+var browserHeaderOrders = map[string]map[string][]string{
+	"chrome": {
+		"1": {"host", "connection", "cache-control", "sec-ch-ua", "sec-ch-ua-mobile", "sec-ch-ua-platform", "upgrade-insecure-requests", "user-agent", "accept", "sec-fetch-site", "sec-fetch-mode", "sec-fetch-user", "sec-fetch-dest", "referer", "accept-encoding", "accept-language", "cookie"},
+		"2": {"cache-control", "sec-ch-ua", "sec-ch-ua-mobile", "sec-ch-ua-platform", "upgrade-insecure-requests", "user-agent", "accept", "sec-fetch-site", "sec-fetch-mode", "sec-fetch-user", "sec-fetch-dest", "referer", "accept-encoding", "accept-language", "cookie", "priority"},
+	},
+	"edge": {
+		"1": {"host", "connection", "cache-control", "sec-ch-ua", "sec-ch-ua-mobile", "sec-ch-ua-platform", "upgrade-insecure-requests", "user-agent", "accept", "sec-fetch-site", "sec-fetch-mode", "sec-fetch-user", "sec-fetch-dest", "referer", "accept-encoding", "accept-language", "cookie"},
+		"2": {"cache-control", "sec-ch-ua", "sec-ch-ua-mobile", "sec-ch-ua-platform", "upgrade-insecure-requests", "user-agent", "accept", "sec-fetch-site", "sec-fetch-mode", "sec-fetch-user", "sec-fetch-dest", "referer", "accept-encoding", "accept-language", "cookie", "priority"},
+	},
+	"firefox": {
+		"1": {"host", "user-agent", "accept", "accept-language", "accept-encoding", "referer", "connection", "cookie", "upgrade-insecure-requests", "sec-fetch-dest", "sec-fetch-mode", "sec-fetch-site", "sec-fetch-user", "priority"},
+		"2": {"user-agent", "accept", "accept-language", "accept-encoding", "referer", "cookie", "upgrade-insecure-requests", "sec-fetch-dest", "sec-fetch-mode", "sec-fetch-site", "sec-fetch-user", "priority", "te"},
+	},
+	"safari": {
+		"1": {"host", "sec-fetch-dest", "user-agent", "upgrade-insecure-requests", "accept", "sec-fetch-site", "sec-fetch-mode", "accept-language", "referer", "priority", "accept-encoding", "cookie", "connection"},
+		"2": {"sec-fetch-dest", "user-agent", "upgrade-insecure-requests", "accept", "sec-fetch-site", "sec-fetch-mode", "accept-language", "referer", "priority", "accept-encoding", "cookie"},
+	},
+}
+
+// browserHeaderRanks indexes browserHeaderOrders for ordering, see headerRanks.
+var browserHeaderRanks = func() map[string]map[string]map[string]int {
+	ranks := make(map[string]map[string]map[string]int, len(browserHeaderOrders))
+	for browser, orders := range browserHeaderOrders {
+		ranks[browser] = make(map[string]map[string]int, len(orders))
+		for httpVersion, order := range orders {
+			ranks[browser][httpVersion] = headerRanks(order)
+		}
+	}
+	return ranks
+}()
+
+func defaultHeaderPlacement(name string) (HeaderPlacement, bool) {
+	placement, ok := defaultHeaderPlacements[name]
+	return placement, ok
+}
+
+// OrderHeaders returns headers in the order browser ("chrome", "edge", "firefox" or "safari") sends
+// them over httpVersion ("1" or "2"), for headers that were not generated by a HeaderGenerator. The
+// names are kept as given. Headers the browser order does not know are placed like HeaderOrder does
+// without registered placements. When browser is empty, it is taken from the User-Agent header.
+func OrderHeaders(headers map[string]string, browser string, httpVersion string) []KV {
+	if browser == "" {
+		browser = GetBrowser(GetUserAgent(headers))
+	}
+	if httpVersion == "" {
+		httpVersion = "2"
+	}
+	ranks := browserHeaderRanks[strings.ToLower(browser)][httpVersion]
+
+	scratch := orderHeaderNames(headers, ranks, defaultHeaderPlacement)
+	ordered := make([]KV, 0, len(scratch.order))
+	for _, name := range scratch.order {
+		ordered = append(ordered, KV{Name: name, Value: headers[name]})
+	}
+	orderScratchPool.Put(scratch)
+	return ordered
+}
@@ -0,0 +1,68 @@
+package header
+
+// VersionRecency is a curve of how often the major versions of a browser are used by their age, the
+// number of major versions they are behind the newest one of the dataset.
+type VersionRecency struct {
+	// Shares are the shares of the newest major version and of the versions 1, 2, ... behind it.
+	Shares []float64
+	// Tail is the share of the older major versions together, split equally between them.
+	Tail float64
+}
+
+// DefaultVersionRecency spreads a browser over its last four major versions with a long tail, as
+// auto-updating browsers are.
+var DefaultVersionRecency = &VersionRecency{Shares: []float64{0.45, 0.3, 0.1, 0.05}, Tail: 0.1}
+
+// factors returns, per browser version and operating system, the factor the dataset share of its
+// major version within its browser is multiplied with to follow the curve. The share of every browser
+// is kept.
+func (r *VersionRecency) factors(distribution map[inputClass]float64) map[inputClass]float64 {
+	type browserMajor struct {
+		browser string
+		major   int
+	}
+	probabilities := make(map[browserMajor]float64)
+	newest := make(map[string]int)
+	for class, probability := range distribution {
+		browser := prepareHttpBrowserObject(class.browserHttp)
+		if len(browser.Version) == 0 {
+			continue
+		}
+		probabilities[browserMajor{browser.Name, browser.Version[0]}] += probability
+		newest[browser.Name] = max(newest[browser.Name], browser.Version[0])
+	}
+
+	tailMajors := make(map[string]int)
+	for version := range probabilities {
+		if newest[version.browser]-version.major >= len(r.Shares) {
+			tailMajors[version.browser]++
+		}
+	}
+	share := func(version browserMajor) float64 {
+		if age := newest[version.browser] - version.major; age < len(r.Shares) {
+			return r.Shares[age]
+		}
+		return r.Tail / float64(tailMajors[version.browser])
+	}
+
+	// The curve is normalized over the major versions of the dataset, so browsers keep their share.
+	browserProbabilities := make(map[string]float64)
+	browserShares := make(map[string]float64)
+	for version, probability := range probabilities {
+		browserProbabilities[version.browser] += probability
+		browserShares[version.browser] += share(version)
+	}
+	factors := make(map[inputClass]float64, len(distribution))
+	for class := range distribution {
+		factors[class] = 1
+		browser := prepareHttpBrowserObject(class.browserHttp)
+		if len(browser.Version) == 0 {
+			continue
+		}
+		version := browserMajor{browser.Name, browser.Version[0]}
+		if browserShares[version.browser] > 0 {
+			factors[class] = share(version) / browserShares[version.browser] * browserProbabilities[version.browser] / probabilities[version]
+		}
+	}
+	return factors
+}
@@ -0,0 +1,127 @@
+package header
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ReferrerPolicy is a Referrer-Policy value controlling what browsers send in the Referer header.
+type ReferrerPolicy string
+
+const (
+	ReferrerPolicyNoReferrer                  ReferrerPolicy = "no-referrer"
+	ReferrerPolicyNoReferrerWhenDowngrade     ReferrerPolicy = "no-referrer-when-downgrade"
+	ReferrerPolicyOrigin                      ReferrerPolicy = "origin"
+	ReferrerPolicyOriginWhenCrossOrigin       ReferrerPolicy = "origin-when-cross-origin"
+	ReferrerPolicySameOrigin                  ReferrerPolicy = "same-origin"
+	ReferrerPolicyStrictOrigin                ReferrerPolicy = "strict-origin"
+	ReferrerPolicyStrictOriginWhenCrossOrigin ReferrerPolicy = "strict-origin-when-cross-origin"
+	ReferrerPolicyUnsafeURL                   ReferrerPolicy = "unsafe-url"
+)
+
+// DefaultReferrerPolicy is the policy browsers apply when the page does not set one.
+const DefaultReferrerPolicy = ReferrerPolicyStrictOriginWhenCrossOrigin
+
+// Referer returns the Referer value a browser sends when navigating from the page at from to the URL to
+// under policy, or "" when no Referer is sent.
+func Referer(from string, to string, policy ReferrerPolicy) string {
+	if from == "" {
+		return ""
+	}
+	fromURL, err := url.Parse(from)
+	if err != nil || (fromURL.Scheme != "http" && fromURL.Scheme != "https") {
+		return ""
+	}
+	toURL, err := url.Parse(to)
+	if err != nil {
+		return ""
+	}
+	if policy == "" {
+		policy = DefaultReferrerPolicy
+	}
+
+	full := *fromURL
+	full.Fragment = ""
+	full.RawFragment = ""
+	full.User = nil
+	fullURL := full.String()
+	originURL := strings.ToLower(fromURL.Scheme+"://"+fromURL.Host) + "/"
+
+	sameOrigin := strings.EqualFold(fromURL.Scheme, toURL.Scheme) && strings.EqualFold(fromURL.Host, toURL.Host)
+	downgrade := fromURL.Scheme == "https" && toURL.Scheme != "https"
+
+	switch policy {
+	case ReferrerPolicyNoReferrer:
+		return ""
+	case ReferrerPolicyNoReferrerWhenDowngrade:
+		if downgrade {
+			return ""
+		}
+		return fullURL
+	case ReferrerPolicyOrigin:
+		return originURL
+	case ReferrerPolicyOriginWhenCrossOrigin:
+		if sameOrigin {
+			return fullURL
+		}
+		return originURL
+	case ReferrerPolicySameOrigin:
+		if sameOrigin {
+			return fullURL
+		}
+		return ""
+	case ReferrerPolicyStrictOrigin:
+		if downgrade {
+			return ""
+		}
+		return originURL
+	case ReferrerPolicyUnsafeURL:
+		return fullURL
+	default:
+		if sameOrigin {
+			return fullURL
+		}
+		if downgrade {
+			return ""
+		}
+		return originURL
+	}
+}
+
+// NavigationChain follows a sequence of navigations and computes the Referer of each hop.
+type NavigationChain struct {
+	// Policy is the referrer policy of the pages in the chain. DefaultReferrerPolicy is used when empty.
+	Policy  ReferrerPolicy
+	current string
+}
+
+// Navigate moves the chain to rawURL and returns the Referer sent for the navigation.
+func (c *NavigationChain) Navigate(rawURL string) string {
+	referer := Referer(c.current, rawURL, c.Policy)
+	c.current = rawURL
+	return referer
+}
+
+// Current returns the URL of the page the chain is on.
+func (c *NavigationChain) Current() string {
+	return c.current
+}
+
+// RefererChain returns the Referer sent for each navigation of urls in order under policy.
+func RefererChain(urls []string, policy ReferrerPolicy) []string {
+	chain := &NavigationChain{Policy: policy}
+	referers := make([]string, len(urls))
+	for i, u := range urls {
+		referers[i] = chain.Navigate(u)
+	}
+	return referers
+}
+
+// headerName returns name in the casing used by headers: lower case for HTTP/2 headers and the given
+// canonical form for HTTP/1 headers.
+func headerName(headers map[string]string, name string) string {
+	if _, ok := headers["user-agent"]; ok {
+		return strings.ToLower(name)
+	}
+	return name
+}
@@ -0,0 +1,78 @@
+package header
+
+import "time"
+
+// Request describes a single request headers are generated for.
+type Request struct {
+	// URL is the URL being requested. It selects the per-origin client hints of the ClientHintPolicy.
+	URL string
+	// UserAgent pins the generated headers to a user agent, keeping the identity stable across requests.
+	UserAgent string
+	// Headers are request dependent headers added to the generated ones.
+	Headers map[string]string
+	// ClientHints provides the high-entropy client hint values of the identity.
+	ClientHints *ClientHints
+	// Referrer is the URL of the page the request is made from. The Referer header is derived from it
+	// according to ReferrerPolicy.
+	Referrer string
+	// ReferrerPolicy is the policy of the referring page, DefaultReferrerPolicy when empty.
+	ReferrerPolicy ReferrerPolicy
+	// CacheMode adds the cache headers of reloads.
+	CacheMode CacheMode
+	// Validators of a cached copy of the resource are sent as If-None-Match and If-Modified-Since.
+	Validators *CacheValidators
+	// ResourceType selects the header profile of the request, ResourceTypeDocument when empty.
+	ResourceType ResourceType
+	// Range is sent as the Range header. Video and audio requests default to "bytes=0-".
+	Range string
+}
+
+// GetHeadersForRequest generates headers for the given request.
+func (g *HeaderGenerator) GetHeadersForRequest(options *HeaderGeneratorOptions, request *Request) (map[string]string, error) {
+	headers, casing, err := g.requestHeaders(options, request)
+	if err != nil {
+		return nil, err
+	}
+	return applyHeaderCasing(headers, casing), nil
+}
+
+// requestHeaders generates the headers of GetHeadersForRequest before they are renamed according to
+// the returned casing, for callers that still match client hints by their lowercase names.
+func (g *HeaderGenerator) requestHeaders(options *HeaderGeneratorOptions, request *Request) (map[string]string, HeaderCasing, error) {
+	if request == nil {
+		request = &Request{}
+	}
+
+	var userAgentValues []string
+	if request.UserAgent != "" {
+		userAgentValues = []string{request.UserAgent}
+	}
+
+	started := time.Now()
+	headers, _, err := g.generateHeaders(options, request.Headers, userAgentValues)
+	g.Metrics.ObserveGeneration(time.Since(started), err)
+	if err != nil {
+		return nil, BrowserExact, err
+	}
+
+	if request.Referrer != "" {
+		if referer := Referer(request.Referrer, request.URL, request.ReferrerPolicy); referer != "" {
+			headers[headerName(headers, "Referer")] = referer
+		}
+	}
+
+	if !request.ResourceType.IsNavigation() || request.Range != "" {
+		applyResourceType(headers, request.ResourceType, request.Range)
+	}
+
+	if request.CacheMode != CacheModeDefault || request.Validators != nil {
+		applyCacheHeaders(headers, request.CacheMode, request.Validators)
+	}
+
+	headerOptions := g.ResolveOptions(options)
+	if headerOptions.ClientHintPolicy != nil && request.URL != "" && headerOptions.InAppBrowser == "" {
+		applyClientHintPolicy(headers, g.capabilities, headerOptions.ClientHintPolicy, request.URL, request.ClientHints)
+	}
+
+	return headers, headerOptions.Casing, nil
+}
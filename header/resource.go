@@ -0,0 +1,101 @@
+package header
+
+import (
+	"strings"
+)
+
+// ResourceType is the kind of resource a request fetches. It selects the Accept, Sec-Fetch-* and Range
+// headers the browser would send.
+type ResourceType string
+
+const (
+	ResourceTypeDocument ResourceType = "document"
+	ResourceTypeImage    ResourceType = "image"
+	ResourceTypeScript   ResourceType = "script"
+	ResourceTypeStyle    ResourceType = "style"
+	ResourceTypeFont     ResourceType = "font"
+	ResourceTypeFetch    ResourceType = "fetch"
+	// ResourceTypeXHR is an XMLHttpRequest sent the way libraries like jQuery send it, with
+	// X-Requested-With: XMLHttpRequest.
+	ResourceTypeXHR   ResourceType = "xhr"
+	ResourceTypeVideo ResourceType = "video"
+	ResourceTypeAudio ResourceType = "audio"
+	// ResourceTypeDownload is a navigation that results in a file download.
+	ResourceTypeDownload ResourceType = "download"
+)
+
+// IsNavigation reports whether requests of the resource type are top-level navigations.
+func (t ResourceType) IsNavigation() bool {
+	return t == "" || t == ResourceTypeDocument || t == ResourceTypeDownload
+}
+
+type resourceProfile struct {
+	dest   string
+	mode   string
+	accept map[string]string // by browser, "" is the default
+}
+
+var resourceProfiles = map[ResourceType]resourceProfile{
+	ResourceTypeImage: {dest: "image", mode: "no-cors", accept: map[string]string{
+		"":        "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8",
+		"firefox": "image/avif,image/webp,*/*",
+		"safari":  "image/webp,image/avif,image/jxl,image/heic,image/heic-sequence,video/*;q=0.8,image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5",
+	}},
+	ResourceTypeScript: {dest: "script", mode: "no-cors", accept: map[string]string{"": "*/*"}},
+	ResourceTypeStyle:  {dest: "style", mode: "no-cors", accept: map[string]string{"": "text/css,*/*;q=0.1"}},
+	ResourceTypeFont:   {dest: "font", mode: "cors", accept: map[string]string{"": "*/*", "firefox": "application/font-woff2;q=1.0,application/font-woff;q=0.9,*/*;q=0.8"}},
+	ResourceTypeFetch:  {dest: "empty", mode: "cors", accept: map[string]string{"": "*/*"}},
+	ResourceTypeXHR:    {dest: "empty", mode: "cors", accept: map[string]string{"": "application/json, text/javascript, */*; q=0.01"}},
+	ResourceTypeVideo: {dest: "video", mode: "no-cors", accept: map[string]string{
+		"":        "*/*",
+		"firefox": "video/webm,video/ogg,video/*;q=0.9,application/ogg;q=0.7,audio/*;q=0.6,*/*;q=0.5",
+	}},
+	ResourceTypeAudio: {dest: "audio", mode: "no-cors", accept: map[string]string{
+		"":        "*/*",
+		"firefox": "audio/webm,audio/ogg,audio/wav,audio/*;q=0.9,application/ogg;q=0.7,video/*;q=0.6,*/*;q=0.5",
+	}},
+}
+
+// applyResourceType turns navigation headers into the headers of a request for a resource of the given
+// type. rangeValue is sent as the Range header; media requests default to "bytes=0-".
+func applyResourceType(headers map[string]string, resourceType ResourceType, rangeValue string) {
+	browser := GetBrowser(GetUserAgent(headers))
+	if resourceType == ResourceTypeVideo || resourceType == ResourceTypeAudio {
+		if rangeValue == "" {
+			rangeValue = "bytes=0-"
+		}
+		if browser != "firefox" {
+			headers[headerName(headers, "Accept-Encoding")] = "identity;q=1, *;q=0"
+		}
+	}
+	if rangeValue != "" {
+		headers[headerName(headers, "Range")] = rangeValue
+	}
+
+	profile, ok := resourceProfiles[resourceType]
+	if !ok {
+		return
+	}
+
+	accept, ok := profile.accept[browser]
+	if !ok {
+		accept = profile.accept[""]
+	}
+
+	// Subresource requests are not user activated and never ask for an upgrade to HTTPS.
+	for k := range headers {
+		switch strings.ToLower(k) {
+		case "accept", "upgrade-insecure-requests", "sec-fetch-user":
+			delete(headers, k)
+		}
+	}
+	headers[headerName(headers, "Accept")] = accept
+
+	if _, ok := headers[headerName(headers, "Sec-Fetch-Mode")]; ok {
+		headers[headerName(headers, "Sec-Fetch-Mode")] = profile.mode
+		headers[headerName(headers, "Sec-Fetch-Dest")] = profile.dest
+	}
+	if resourceType == ResourceTypeXHR {
+		headers[headerName(headers, "X-Requested-With")] = "XMLHttpRequest"
+	}
+}
@@ -0,0 +1,224 @@
+package header
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// SessionStats describes the use of a session identity so far.
+type SessionStats struct {
+	// Requests is the number of requests the identity generated headers for.
+	Requests int
+	// Started is when the identity was created.
+	Started time.Time
+	// LastStatus is the HTTP status of the last outcome recorded with RecordOutcome, 0 if none.
+	LastStatus int
+	// Failures is the number of outcomes with a 4xx or 5xx status recorded in a row.
+	Failures int
+}
+
+// RotationPolicy decides when a SessionManager replaces the identity of a session with a new one.
+type RotationPolicy interface {
+	ShouldRotate(stats SessionStats, now time.Time) bool
+}
+
+// RotationPolicyFunc adapts a function to a RotationPolicy.
+type RotationPolicyFunc func(stats SessionStats, now time.Time) bool
+
+func (f RotationPolicyFunc) ShouldRotate(stats SessionStats, now time.Time) bool {
+	return f(stats, now)
+}
+
+// RotateAfterRequests rotates identities once they made the given number of requests.
+type RotateAfterRequests int
+
+func (n RotateAfterRequests) ShouldRotate(stats SessionStats, now time.Time) bool {
+	return n > 0 && stats.Requests >= int(n)
+}
+
+// RotateAfter rotates identities once they are older than the duration.
+type RotateAfter time.Duration
+
+func (d RotateAfter) ShouldRotate(stats SessionStats, now time.Time) bool {
+	return d > 0 && now.Sub(stats.Started) >= time.Duration(d)
+}
+
+// RotateOnStatus rotates identities whose last recorded outcome had one of the statuses, e.g.
+// RotateOnStatus{403, 429} for identities that were blocked or rate limited.
+type RotateOnStatus []int
+
+func (statuses RotateOnStatus) ShouldRotate(stats SessionStats, now time.Time) bool {
+	return stats.LastStatus != 0 && slices.Contains(statuses, stats.LastStatus)
+}
+
+type managedSession struct {
+	session *Session
+	stats   SessionStats
+}
+
+// SessionManager keeps one Session per key, e.g. per target site or proxy, and replaces a session with
+// a fresh identity as soon as one of its rotation policies says so.
+type SessionManager struct {
+	// SimulateCache and KeepCookies are passed on to the sessions, see Session. The cookies belong to
+	// an identity and are dropped with it when the session is rotated.
+	SimulateCache bool
+	KeepCookies   bool
+	// Feedback, when set, learns from RecordOutcome which identities get blocked on each key and
+	// down-weights them for the key's new identities.
+	Feedback *OutcomeFeedback
+
+	generator   *HeaderGenerator
+	options     *HeaderGeneratorOptions
+	clientHints *ClientHints
+	policies    []RotationPolicy
+	now         func() time.Time
+
+	mu        sync.Mutex
+	sessions  map[string]*managedSession
+	rotations int
+}
+
+// NewSessionManager creates a session manager generating identities according to options.
+func (g *HeaderGenerator) NewSessionManager(options *HeaderGeneratorOptions, clientHints *ClientHints, policies ...RotationPolicy) *SessionManager {
+	return &SessionManager{
+		generator:   g,
+		options:     options,
+		clientHints: clientHints,
+		policies:    policies,
+		now:         time.Now,
+		sessions:    make(map[string]*managedSession),
+	}
+}
+
+// Headers returns the headers of the next request of the session of key, rotating its identity first
+// when a policy says so.
+func (m *SessionManager) Headers(key string, request *Request) (map[string]string, error) {
+	_, headers, err := m.SessionHeaders(key, request)
+	return headers, err
+}
+
+// SessionHeaders is Headers that also returns the session the headers belong to, so that callers can
+// tell when the identity of key was rotated.
+func (m *SessionManager) SessionHeaders(key string, request *Request) (*Session, map[string]string, error) {
+	m.mu.Lock()
+	managed := m.sessionLocked(key)
+	managed.stats.Requests++
+	session := managed.session
+	m.mu.Unlock()
+
+	headers, err := session.Headers(request)
+	return session, headers, err
+}
+
+// Session returns the current session of key, rotating its identity first when a policy says so.
+func (m *SessionManager) Session(key string) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessionLocked(key).session
+}
+
+// CurrentSession returns the session key holds, without rotating its identity or starting one. ok is
+// false when key has no session.
+func (m *SessionManager) CurrentSession(key string) (session *Session, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	managed, ok := m.sessions[key]
+	if !ok {
+		return nil, false
+	}
+	return managed.session, true
+}
+
+// RecordOutcome records the HTTP status of a response received by the session of key. Policies see it
+// in SessionStats, so RotateOnStatus rotates blocked identities before their next request.
+func (m *SessionManager) RecordOutcome(key string, status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	managed, ok := m.sessions[key]
+	if !ok {
+		return
+	}
+	if m.Feedback != nil {
+		m.Feedback.Record(key, managed.session.info(), status)
+	}
+	managed.stats.LastStatus = status
+	if status >= 400 {
+		managed.stats.Failures++
+	} else {
+		managed.stats.Failures = 0
+	}
+}
+
+// Rotate replaces the identity of the session of key.
+func (m *SessionManager) Rotate(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[key]; ok {
+		delete(m.sessions, key)
+		m.rotations++
+		m.generator.Metrics.AddRotation()
+		m.generator.Metrics.AddSessions(-1)
+	}
+}
+
+// Stats returns the use of the current identity of key.
+func (m *SessionManager) Stats(key string) (SessionStats, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	managed, ok := m.sessions[key]
+	if !ok {
+		return SessionStats{}, false
+	}
+	return managed.stats, true
+}
+
+// Cookies returns the cookie jar of the current identity of key, e.g. to export it next to the
+// fingerprint of the identity. ok is false when key has no session.
+func (m *SessionManager) Cookies(key string) (jar *CookieJar, ok bool) {
+	m.mu.Lock()
+	managed, ok := m.sessions[key]
+	m.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	return managed.session.Cookies(), true
+}
+
+// Rotations returns the number of identities replaced so far.
+func (m *SessionManager) Rotations() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rotations
+}
+
+func (m *SessionManager) sessionLocked(key string) *managedSession {
+	now := m.now()
+	if managed, ok := m.sessions[key]; ok {
+		if !slices.ContainsFunc(m.policies, func(policy RotationPolicy) bool { return policy.ShouldRotate(managed.stats, now) }) {
+			return managed
+		}
+		m.rotations++
+		m.generator.Metrics.AddRotation()
+	} else {
+		m.generator.Metrics.AddSessions(1)
+	}
+
+	options := m.options
+	if m.Feedback != nil {
+		withFeedback := HeaderGeneratorOptions{Strict: m.generator.globalOptions.Strict}
+		if m.options != nil {
+			withFeedback = *m.options
+		}
+		withFeedback.consistent = m.Feedback.consistent(key)
+		options = &withFeedback
+	}
+
+	session := m.generator.NewSession(options, m.clientHints)
+	session.SimulateCache = m.SimulateCache
+	session.KeepCookies = m.KeepCookies
+	managed := &managedSession{session: session, stats: SessionStats{Started: now}}
+	m.sessions[key] = managed
+	return managed
+}
@@ -0,0 +1,75 @@
+package header
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"fingerprint-go/internal/seal"
+)
+
+// KeyProvider returns the AES-128, AES-192 or AES-256 key sealing exported identities at rest with
+// AES-GCM, e.g. a data key decrypted by a KMS. It is called for every seal and open, so that rotated
+// keys are picked up.
+type KeyProvider func() ([]byte, error)
+
+// KeyFromEnv returns a KeyProvider reading a base64-encoded key from the environment variable name.
+func KeyFromEnv(name string) KeyProvider {
+	return func() ([]byte, error) {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("The environment variable %s holding the sealing key is not set.", name)
+		}
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("The sealing key in %s is not base64: %w", name, err)
+		}
+		return key, nil
+	}
+}
+
+// SealWithKey encrypts data with the key of provider, see KeyProvider.
+func SealWithKey(data []byte, provider KeyProvider) ([]byte, error) {
+	key, err := provider()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := seal.Seal(data, key)
+	if err != nil {
+		return nil, fmt.Errorf("The data could not be sealed: %w", err)
+	}
+	return sealed, nil
+}
+
+// OpenWithKey decrypts data sealed by SealWithKey with the key of provider.
+func OpenWithKey(sealed []byte, provider KeyProvider) ([]byte, error) {
+	key, err := provider()
+	if err != nil {
+		return nil, err
+	}
+	data, err := seal.Open(sealed, key)
+	if err != nil {
+		return nil, fmt.Errorf("The data could not be opened: %w", err)
+	}
+	return data, nil
+}
+
+// ExportSealed returns the cookies of the jar encoded to JSON and sealed with the key of provider, for
+// jars persisted at rest. Read them back with ImportSealed.
+func (j *CookieJar) ExportSealed(provider KeyProvider) ([]byte, error) {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+	return SealWithKey(data, provider)
+}
+
+// ImportSealed replaces the cookies of the jar with those sealed by ExportSealed.
+func (j *CookieJar) ImportSealed(sealed []byte, provider KeyProvider) error {
+	data, err := OpenWithKey(sealed, provider)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, j)
+}
@@ -0,0 +1,245 @@
+package header
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session generates headers for consecutive requests of a single browser identity. The identity's
+// headers are sampled once; each request then varies the per-request headers the way a browser does:
+// the first navigation has no Referer and sec-fetch-site "none", later ones carry the Referer of the
+// previous page and a sec-fetch-site computed from it. With SimulateCache, navigating to the current page
+// again is a reload and revisits send the validators recorded with RecordResponse. With KeepCookies, the
+// cookies recorded with RecordResponse are sent back, see Cookies.
+type Session struct {
+	SimulateCache bool
+	KeepCookies   bool
+
+	generator   *HeaderGenerator
+	options     *HeaderGeneratorOptions
+	clientHints *ClientHints
+
+	mu         sync.Mutex
+	base       map[string]string
+	chain      NavigationChain
+	validators map[string]CacheValidators
+	cookies    *CookieJar
+	hsts       map[string]HSTSPolicy
+}
+
+// NewSession starts a session generating headers according to options. clientHints, if set, provides the
+// high-entropy client hint values sent to origins that request them.
+func (g *HeaderGenerator) NewSession(options *HeaderGeneratorOptions, clientHints *ClientHints) *Session {
+	return &Session{
+		generator:   g,
+		options:     options,
+		clientHints: clientHints,
+	}
+}
+
+// UserAgent returns the user agent of the session, or "" before the first request.
+func (s *Session) UserAgent() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return GetUserAgent(s.base)
+}
+
+// Headers returns the headers for navigating to request.URL from the current page of the session.
+// Request.Referrer overrides the session's current page as the referring page.
+func (s *Session) Headers(request *Request) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.base == nil {
+		// The base keeps the generated names, as the client hint policy matches them in lowercase; the
+		// casing is applied to the headers of each request.
+		base, _, err := s.generator.requestHeaders(s.options, &Request{URL: request.URL, ClientHints: s.clientHints})
+		if err != nil {
+			return nil, err
+		}
+		s.base = base
+	}
+
+	headers := make(map[string]string, len(s.base)+len(request.Headers))
+	for k, v := range s.base {
+		if !strings.EqualFold(k, "referer") {
+			headers[k] = v
+		}
+	}
+
+	from := s.chain.Current()
+	if request.Referrer != "" {
+		from = request.Referrer
+	}
+	policy := request.ReferrerPolicy
+	if policy == "" {
+		policy = s.chain.Policy
+	}
+
+	if site := headerName(headers, "Sec-Fetch-Site"); headers[site] != "" {
+		headers[site] = SecFetchSite(from, request.URL)
+	}
+	if referer := Referer(from, request.URL, policy); referer != "" {
+		headers[headerName(headers, "Referer")] = referer
+	}
+
+	headerOptions := s.generator.ResolveOptions(s.options)
+	if headerOptions.ClientHintPolicy != nil {
+		clientHints := request.ClientHints
+		if clientHints == nil {
+			clientHints = s.clientHints
+		}
+		applyClientHintPolicy(headers, s.generator.capabilities, headerOptions.ClientHintPolicy, request.URL, clientHints)
+	}
+
+	cacheMode, validators := request.CacheMode, request.Validators
+	if s.SimulateCache {
+		if cacheMode == CacheModeDefault && request.ResourceType.IsNavigation() && request.URL == s.chain.Current() {
+			cacheMode = CacheModeReload
+		}
+		if stored, ok := s.validators[request.URL]; ok && validators == nil {
+			validators = &stored
+		}
+	}
+	applyCacheHeaders(headers, cacheMode, validators)
+
+	if !request.ResourceType.IsNavigation() || request.Range != "" {
+		applyResourceType(headers, request.ResourceType, request.Range)
+	}
+
+	if s.KeepCookies {
+		if u, err := url.Parse(request.URL); err == nil {
+			if cookies := s.cookiesLocked().Cookies(u); len(cookies) > 0 {
+				parts := make([]string, len(cookies))
+				for i, cookie := range cookies {
+					parts[i] = cookie.String()
+				}
+				headers[headerName(headers, "Cookie")] = strings.Join(parts, "; ")
+			}
+		}
+	}
+
+	for k, v := range request.Headers {
+		headers[k] = v
+	}
+
+	// Subresources are loaded by the current page and do not navigate away from it.
+	if request.ResourceType.IsNavigation() && request.ResourceType != ResourceTypeDownload {
+		s.chain.Navigate(request.URL)
+		s.chain.Policy = ""
+	}
+
+	return applyHeaderCasing(headers, headerOptions.Casing), nil
+}
+
+// info returns the identity of the session, or nil before the first request.
+func (s *Session) info() *GenerationInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.base == nil {
+		return nil
+	}
+	return InfoFromHeaders(s.base)
+}
+
+// Cookies returns the cookie jar of the session. The jar belongs to the identity of the session, so a
+// SessionManager starts every rotated identity without cookies.
+func (s *Session) Cookies() *CookieJar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cookiesLocked()
+}
+
+func (s *Session) cookiesLocked() *CookieJar {
+	if s.cookies == nil {
+		s.cookies = NewCookieJar()
+	}
+	return s.cookies
+}
+
+// RecordResponse stores the ETag and Last-Modified validators of a response to rawURL for later revisits,
+// the Strict-Transport-Security policy it sets, see CachingIdentifiers, and, with KeepCookies, its
+// cookies.
+func (s *Session) RecordResponse(rawURL string, responseHeaders http.Header) {
+	if u, err := url.Parse(rawURL); err == nil {
+		if s.KeepCookies {
+			if cookies := (&http.Response{Header: responseHeaders}).Cookies(); len(cookies) > 0 {
+				s.Cookies().SetCookies(u, cookies)
+			}
+		}
+		if value := responseHeaders.Get("Strict-Transport-Security"); value != "" {
+			s.recordHSTS(u, value, time.Now())
+		}
+	}
+
+	validators := CacheValidators{
+		ETag:         responseHeaders.Get("ETag"),
+		LastModified: responseHeaders.Get("Last-Modified"),
+	}
+	if validators.ETag == "" && validators.LastModified == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.validators == nil {
+		s.validators = make(map[string]CacheValidators)
+	}
+	s.validators[rawURL] = validators
+}
+
+// SetReferrerPolicy sets the Referrer-Policy of the current page, as received in its response.
+func (s *Session) SetReferrerPolicy(policy ReferrerPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chain.Policy = policy
+}
+
+// SecFetchSite returns the sec-fetch-site value of a request to to initiated from the page at from:
+// "none" for user-initiated requests without a page, otherwise "same-origin", "same-site" or "cross-site".
+func SecFetchSite(from string, to string) string {
+	if from == "" {
+		return "none"
+	}
+	fromURL, err1 := url.Parse(from)
+	toURL, err2 := url.Parse(to)
+	if err1 != nil || err2 != nil {
+		return "cross-site"
+	}
+
+	if !strings.EqualFold(fromURL.Scheme, toURL.Scheme) {
+		return "cross-site"
+	}
+	if strings.EqualFold(fromURL.Host, toURL.Host) {
+		return "same-origin"
+	}
+	if registrableDomain(fromURL.Hostname()) == registrableDomain(toURL.Hostname()) {
+		return "same-site"
+	}
+	return "cross-site"
+}
+
+// registrableDomain approximates the eTLD+1 of host: the last two labels, or three when the second to
+// last label is a common second-level suffix like "co" in "example.co.uk". An IP address is a site of
+// its own.
+func registrableDomain(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(host, ".")), ".")
+	if len(labels) <= 2 {
+		return strings.Join(labels, ".")
+	}
+	n := 2
+	switch labels[len(labels)-2] {
+	case "co", "com", "net", "org", "gov", "edu", "ac", "ne", "or", "go":
+		if len(labels[len(labels)-1]) == 2 {
+			n = 3
+		}
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
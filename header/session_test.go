@@ -0,0 +1,52 @@
+package header
+
+import "testing"
+
+func TestSecFetchSite(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want string
+	}{
+		{"", "https://example.com/", "none"},
+		{"https://example.com/a", "https://example.com/b", "same-origin"},
+		{"https://example.com/", "https://EXAMPLE.com/", "same-origin"},
+		{"https://example.com/", "https://www.example.com/", "same-site"},
+		{"https://a.example.co.uk/", "https://b.example.co.uk/", "same-site"},
+		{"https://example.co.uk/", "https://a.example.co.uk/", "same-site"},
+		{"https://example.co.uk/", "https://other.co.uk/", "cross-site"},
+		{"https://example.com/", "https://example.org/", "cross-site"},
+		{"https://example.com/", "http://example.com/", "cross-site"},
+		{"https://example.com:8443/", "https://example.com/", "same-site"},
+		{"http://192.168.1.1/", "http://10.0.1.1/", "cross-site"},
+	}
+	for _, test := range tests {
+		if got := SecFetchSite(test.from, test.to); got != test.want {
+			t.Errorf("SecFetchSite(%q, %q) = %q, want %q", test.from, test.to, got, test.want)
+		}
+	}
+}
+
+func TestRegistrableDomain(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"example.com", "example.com"},
+		{"www.example.com", "example.com"},
+		{"a.b.example.com.", "example.com"},
+		{"example.co.uk", "example.co.uk"},
+		{"a.example.co.uk", "example.co.uk"},
+		{"shop.example.com.au", "example.com.au"},
+		// "co" is only a second-level suffix under a two-letter country code.
+		{"www.example.co", "example.co"},
+		{"localhost", "localhost"},
+		{"192.168.1.1", "192.168.1.1"},
+		{"::1", "::1"},
+	}
+	for _, test := range tests {
+		if got := registrableDomain(test.host); got != test.want {
+			t.Errorf("registrableDomain(%q) = %q, want %q", test.host, got, test.want)
+		}
+	}
+}
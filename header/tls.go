@@ -0,0 +1,249 @@
+package header
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Extension numbers of the ClientHello with a special role in JA3 and JA4.
+const (
+	tlsExtensionServerName = 0
+	tlsExtensionALPN       = 16
+)
+
+// TLSProfile is the ClientHello a browser sends, without GREASE values. Its fields map to a
+// utls.ClientHelloSpec, UTLSClientHelloID names the closest predefined uTLS ClientHelloID.
+type TLSProfile struct {
+	// UTLSClientHelloID is the name of the closest utls.ClientHelloID, e.g. "HelloChrome_131".
+	UTLSClientHelloID string `json:"utlsClientHelloId"`
+	// Version is the legacy version of the ClientHello, 771 (TLS 1.2) for TLS 1.3 clients.
+	Version             uint16   `json:"version"`
+	CipherSuites        []uint16 `json:"cipherSuites"`
+	Extensions          []uint16 `json:"extensions"`
+	SupportedGroups     []uint16 `json:"supportedGroups"`
+	PointFormats        []uint8  `json:"pointFormats"`
+	SignatureAlgorithms []uint16 `json:"signatureAlgorithms"`
+	SupportedVersions   []uint16 `json:"supportedVersions"`
+	ALPN                []string `json:"alpn"`
+	// ShuffledExtensions is set when the browser sends the extensions in a random order, so that JA3
+	// differs between connections and only JA4 is stable.
+	ShuffledExtensions bool `json:"shuffledExtensions,omitempty"`
+}
+
+var (
+	chromeCipherSuites = []uint16{4865, 4866, 4867, 49195, 49199, 49196, 49200, 52393, 52392, 49171, 49172, 156, 157, 47, 53}
+	chromeSignatures   = []uint16{1027, 2052, 1025, 1283, 2053, 1281, 2054, 1537}
+
+	firefoxCipherSuites = []uint16{4865, 4867, 4866, 49195, 49199, 52393, 52392, 49196, 49200, 49162, 49161, 49171, 49172, 156, 157, 47, 53}
+	firefoxSignatures   = []uint16{1027, 1283, 1539, 2052, 2053, 2054, 1025, 1281, 1537, 515, 513}
+
+	safariCipherSuites = []uint16{4865, 4866, 4867, 49196, 49195, 52393, 49200, 49199, 52392, 49162, 49161, 49172, 49171, 157, 156, 53, 47, 49160, 49170, 10}
+	// Safari lists ecdsa_secp384r1_sha384 (2053) twice.
+	safariSignatures = []uint16{1027, 2052, 1025, 1283, 515, 2053, 2053, 1281, 2054, 1537, 513}
+)
+
+// chromeTLSProfile returns the ClientHello of Chrome and the browsers built on it, such as Edge.
+func chromeTLSProfile(version int) *TLSProfile {
+	profile := &TLSProfile{
+		UTLSClientHelloID:   "HelloChrome_102",
+		Version:             771,
+		CipherSuites:        chromeCipherSuites,
+		Extensions:          []uint16{0, 23, 65281, 10, 11, 35, 16, 5, 13, 18, 51, 45, 43, 27, 17513},
+		SupportedGroups:     []uint16{29, 23, 24},
+		PointFormats:        []uint8{0},
+		SignatureAlgorithms: chromeSignatures,
+		SupportedVersions:   []uint16{772, 771},
+		ALPN:                []string{"h2", "http/1.1"},
+		ShuffledExtensions:  version >= 110,
+	}
+	switch {
+	case version >= 133:
+		profile.UTLSClientHelloID = "HelloChrome_133"
+	case version >= 131:
+		profile.UTLSClientHelloID = "HelloChrome_131"
+	case version >= 124:
+		profile.UTLSClientHelloID = "HelloChrome_120_PQ"
+	case version >= 120:
+		profile.UTLSClientHelloID = "HelloChrome_120"
+	case version >= 106:
+		profile.UTLSClientHelloID = "HelloChrome_106_Shuffle"
+	}
+	switch {
+	case version >= 131:
+		profile.SupportedGroups = []uint16{4588, 29, 23, 24}
+	case version >= 124:
+		profile.SupportedGroups = []uint16{25497, 29, 23, 24}
+	}
+	if version >= 133 {
+		// The new codepoint of application settings (ALPS).
+		profile.Extensions[slices.Index(profile.Extensions, 17513)] = 17613
+	}
+	if version >= 117 {
+		// GREASE encrypted client hello, which makes the ClientHello too large for the padding
+		// extension.
+		profile.Extensions = append(profile.Extensions, 65037)
+	} else {
+		profile.Extensions = append(profile.Extensions, 21)
+	}
+	return profile
+}
+
+func firefoxTLSProfile(version int) *TLSProfile {
+	profile := &TLSProfile{
+		UTLSClientHelloID:   "HelloFirefox_102",
+		Version:             771,
+		CipherSuites:        firefoxCipherSuites,
+		Extensions:          []uint16{0, 23, 65281, 10, 11, 35, 16, 5, 34, 51, 43, 13, 45, 28},
+		SupportedGroups:     []uint16{29, 23, 24, 25, 256, 257},
+		PointFormats:        []uint8{0},
+		SignatureAlgorithms: firefoxSignatures,
+		SupportedVersions:   []uint16{772, 771},
+		ALPN:                []string{"h2", "http/1.1"},
+	}
+	switch {
+	case version >= 120:
+		profile.UTLSClientHelloID = "HelloFirefox_120"
+	case version >= 105:
+		profile.UTLSClientHelloID = "HelloFirefox_105"
+	}
+	if version >= 132 {
+		profile.SupportedGroups = append([]uint16{4588}, profile.SupportedGroups...)
+	}
+	if version >= 118 {
+		profile.Extensions = append(profile.Extensions, 65037)
+	} else {
+		profile.Extensions = append(profile.Extensions, 21)
+	}
+	return profile
+}
+
+func safariTLSProfile(mobile bool) *TLSProfile {
+	id := "HelloSafari_16_0"
+	if mobile {
+		id = "HelloIOS_14"
+	}
+	return &TLSProfile{
+		UTLSClientHelloID:   id,
+		Version:             771,
+		CipherSuites:        safariCipherSuites,
+		Extensions:          []uint16{0, 23, 65281, 10, 11, 16, 5, 13, 18, 51, 45, 43, 27, 21},
+		SupportedGroups:     []uint16{29, 23, 24, 25},
+		PointFormats:        []uint8{0},
+		SignatureAlgorithms: safariSignatures,
+		SupportedVersions:   []uint16{772, 771, 770, 769},
+		ALPN:                []string{"h2", "http/1.1"},
+	}
+}
+
+// TLSProfileFor returns the ClientHello of browser ("chrome", "edge", "firefox" or "safari") at the
+// major version on operatingSystem. ok is false for other browsers.
+func TLSProfileFor(browser string, version int, operatingSystem string) (profile *TLSProfile, ok bool) {
+	switch strings.ToLower(browser) {
+	case "chrome", "edge":
+		return chromeTLSProfile(version), true
+	case "firefox":
+		return firefoxTLSProfile(version), true
+	case "safari":
+		return safariTLSProfile(operatingSystem == "ios"), true
+	}
+	return nil, false
+}
+
+// TLSProfile returns the ClientHello of the browser of info, see TLSProfileFor.
+func (info *GenerationInfo) TLSProfile() (*TLSProfile, bool) {
+	major, _ := strconv.Atoi(strings.Split(info.Version, ".")[0])
+	return TLSProfileFor(info.Browser, major, info.OperatingSystem)
+}
+
+// setTLSFingerprints sets the JA3 and JA4 fingerprints of the browser of info.
+func (info *GenerationInfo) setTLSFingerprints() {
+	if profile, ok := info.TLSProfile(); ok {
+		info.JA3 = profile.JA3()
+		info.JA4 = profile.JA4()
+	}
+}
+
+// JA3 returns the JA3 fingerprint string of the ClientHello:
+// version,ciphers,extensions,groups,point formats with dash-separated decimal values.
+func (p *TLSProfile) JA3() string {
+	return strings.Join([]string{
+		strconv.Itoa(int(p.Version)),
+		joinDecimal(p.CipherSuites),
+		joinDecimal(p.Extensions),
+		joinDecimal(p.SupportedGroups),
+		joinDecimal(p.PointFormats),
+	}, ",")
+}
+
+// JA3Hash returns the MD5 hash of JA3, as most services report it.
+func (p *TLSProfile) JA3Hash() string {
+	sum := md5.Sum([]byte(p.JA3()))
+	return hex.EncodeToString(sum[:])
+}
+
+// JA4 returns the JA4 fingerprint of the ClientHello sent over TCP to a domain name.
+func (p *TLSProfile) JA4() string {
+	version := "00"
+	switch slices.Max(append([]uint16{p.Version}, p.SupportedVersions...)) {
+	case 772:
+		version = "13"
+	case 771:
+		version = "12"
+	case 770:
+		version = "11"
+	case 769:
+		version = "10"
+	}
+	alpn := "00"
+	if len(p.ALPN) > 0 && p.ALPN[0] != "" {
+		first := p.ALPN[0]
+		alpn = first[:1] + first[len(first)-1:]
+	}
+	a := fmt.Sprintf("t%sd%02d%02d%s", version, min(len(p.CipherSuites), 99), min(len(p.Extensions), 99), alpn)
+
+	ciphers := hexValues(p.CipherSuites)
+	slices.Sort(ciphers)
+
+	var extensions []string
+	for _, extension := range p.Extensions {
+		if extension != tlsExtensionServerName && extension != tlsExtensionALPN {
+			extensions = append(extensions, fmt.Sprintf("%04x", extension))
+		}
+	}
+	slices.Sort(extensions)
+	c := strings.Join(extensions, ",")
+	if len(p.SignatureAlgorithms) > 0 {
+		c += "_" + strings.Join(hexValues(p.SignatureAlgorithms), ",")
+	}
+	return a + "_" + truncatedHash(strings.Join(ciphers, ",")) + "_" + truncatedHash(c)
+}
+
+// truncatedHash returns the first 12 hex digits of the SHA-256 hash of s, "000000000000" for "".
+func truncatedHash(s string) string {
+	if s == "" {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func joinDecimal[T uint8 | uint16](values []T) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(int(v))
+	}
+	return strings.Join(parts, "-")
+}
+
+func hexValues(values []uint16) []string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%04x", v)
+	}
+	return parts
+}
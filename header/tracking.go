@@ -0,0 +1,118 @@
+package header
+
+import (
+	"maps"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HSTSPolicy is a Strict-Transport-Security policy a host set for an identity.
+type HSTSPolicy struct {
+	Expires           time.Time `json:"expires"`
+	IncludeSubDomains bool      `json:"includeSubDomains,omitempty"`
+}
+
+// CachingIdentifiers are the values a session received that let sites recognize its identity without
+// cookies: a unique ETag or Last-Modified comes back in If-None-Match and If-Modified-Since, and the set
+// of hosts a browser upgrades to HTTPS because of HSTS can encode an identifier. An identity that must
+// not be linked to another must not carry these over, like its cookies.
+type CachingIdentifiers struct {
+	// Validators are the cache validators recorded per URL.
+	Validators map[string]CacheValidators `json:"validators,omitempty"`
+	// HSTS are the unexpired HSTS policies per host.
+	HSTS map[string]HSTSPolicy `json:"hsts,omitempty"`
+}
+
+// Empty reports whether no caching identifiers were recorded.
+func (c CachingIdentifiers) Empty() bool {
+	return len(c.Validators) == 0 && len(c.HSTS) == 0
+}
+
+// recordHSTS stores the Strict-Transport-Security policy value received from u. Policies received over
+// HTTP are ignored like browsers do, and max-age=0 removes the policy of the host.
+func (s *Session) recordHSTS(u *url.URL, value string, now time.Time) {
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return
+	}
+	maxAge := -1
+	var policy HSTSPolicy
+	for _, directive := range strings.Split(value, ";") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(strings.TrimSpace(arg), `"`)); err == nil && seconds >= 0 {
+				maxAge = seconds
+			}
+		case "includesubdomains":
+			policy.IncludeSubDomains = true
+		}
+	}
+	if maxAge < 0 {
+		return
+	}
+
+	host := strings.ToLower(u.Hostname())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if maxAge == 0 {
+		delete(s.hsts, host)
+		return
+	}
+	if s.hsts == nil {
+		s.hsts = make(map[string]HSTSPolicy)
+	}
+	policy.Expires = now.Add(time.Duration(maxAge) * time.Second)
+	s.hsts[host] = policy
+}
+
+// CachingIdentifiers returns the caching identifiers the session recorded with RecordResponse.
+func (s *Session) CachingIdentifiers() CachingIdentifiers {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	identifiers := CachingIdentifiers{Validators: maps.Clone(s.validators)}
+	now := time.Now()
+	for host, policy := range s.hsts {
+		if !policy.Expires.After(now) {
+			continue
+		}
+		if identifiers.HSTS == nil {
+			identifiers.HSTS = make(map[string]HSTSPolicy)
+		}
+		identifiers.HSTS[host] = policy
+	}
+	return identifiers
+}
+
+// ClearCachingIdentifiers forgets the caching identifiers of the session, as clearing the cache and the
+// HSTS state of a browser does. Cookies are kept.
+func (s *Session) ClearCachingIdentifiers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validators = nil
+	s.hsts = nil
+}
+
+// CachingIdentifiers returns the caching identifiers of the current identity of key. ok is false when
+// key has no session.
+func (m *SessionManager) CachingIdentifiers(key string) (identifiers CachingIdentifiers, ok bool) {
+	m.mu.Lock()
+	managed, ok := m.sessions[key]
+	m.mu.Unlock()
+	if !ok {
+		return CachingIdentifiers{}, false
+	}
+	return managed.session.CachingIdentifiers(), true
+}
+
+// ClearCachingIdentifiers forgets the caching identifiers of the current identity of key.
+func (m *SessionManager) ClearCachingIdentifiers(key string) {
+	m.mu.Lock()
+	managed, ok := m.sessions[key]
+	m.mu.Unlock()
+	if ok {
+		managed.session.ClearCachingIdentifiers()
+	}
+}
@@ -0,0 +1,193 @@
+package header
+
+import (
+	"cmp"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"fingerprint-go/uautil"
+)
+
+// UserAgentClientHints are the client hints of a Chromium-based browser: the sec-ch-ua* header values
+// and the navigator.userAgentData fields. Values the user agent does not reveal, such as the Windows
+// release behind Windows NT 10.0 or the CPU of a Mac, are set to the most common ones.
+type UserAgentClientHints struct {
+	// Headers are the low- and high-entropy sec-ch-ua* header values by lowercase name.
+	Headers         map[string]string
+	Brands          []ClientHintBrand
+	FullVersionList []ClientHintBrand
+	Mobile          bool
+	Platform        string
+	PlatformVersion string
+	Architecture    string
+	Bitness         string
+	Model           string
+	UaFullVersion   string
+	WoW64           bool
+}
+
+// lastCatalinaChrome is the last Chrome major version released for macOS 10.15.
+const lastCatalinaChrome = 128
+
+// firstWindowsReleaseChrome is the first Chrome major version reporting the Windows release, e.g.
+// "15.0.0" for Windows 11, as sec-ch-ua-platform-version.
+const firstWindowsReleaseChrome = 95
+
+var (
+	androidUserAgentModelPattern = regexp.MustCompile(`Android [\d.]+; ([^;)]+?)(?: Build/[^;)]*)?(?:; wv)?\)`)
+	legacyWindowsPattern         = regexp.MustCompile(`Windows NT 6\.(\d)`)
+	windowsNTPattern             = regexp.MustCompile(`Windows NT (\d+\.\d+)`)
+	macOSUserAgentVersionPattern = regexp.MustCompile(`Mac OS X (\d+)[_.](\d+)(?:[_.](\d+))?`)
+)
+
+// ClientHintsForUA returns the client hints a browser with userAgent sends and exposes, for user agents
+// that do not come from the generator. Hints the browser version does not send yet according to
+// DefaultCapabilities are left out. ok is false when the browser does not implement client hints:
+// Firefox, Safari, every browser on iOS and Chromium before version 89.
+func ClientHintsForUA(userAgent string) (hints *UserAgentClientHints, ok bool) {
+	operatingSystem := GetOperatingSystem(userAgent)
+	if operatingSystem == "ios" {
+		return nil, false
+	}
+	browser := GetClientHintBrowser(userAgent)
+	version := GetBrowserVersion(userAgent)
+	chromium := GetChromiumVersion(userAgent)
+	if ClientHintBrandName(browser) == "" || len(version) == 0 || len(chromium) == 0 {
+		return nil, false
+	}
+	capabilities := DefaultCapabilities
+	supports := func(name string) bool {
+		return capabilities.SupportsHeader(GetBrowser(userAgent), version, name)
+	}
+	if !supports("sec-ch-ua") {
+		return nil, false
+	}
+
+	hints = &UserAgentClientHints{
+		Brands:          ClientHintBrands(browser, version[0], strconv.Itoa(chromium[0]), strconv.Itoa(version[0])),
+		Mobile:          strings.Contains(userAgent, "Mobile"),
+		Platform:        clientHintPlatform(userAgent, operatingSystem),
+		PlatformVersion: clientHintPlatformVersion(userAgent, operatingSystem, chromium[0]),
+	}
+
+	// Chrome and WebView report the Chromium build as their version, Edge has builds of its own.
+	chromiumFull := RealChromeFullVersion(versionString(chromium))
+	browserFull := chromiumFull
+	if browser == "edge" {
+		browserFull = RealEdgeFullVersion(uautil.FullVersion(userAgent))
+	}
+	hints.UaFullVersion = browserFull
+	hints.FullVersionList = ClientHintBrands(browser, version[0], chromiumFull, browserFull)
+
+	switch operatingSystem {
+	case "android":
+		if match := androidUserAgentModelPattern.FindStringSubmatch(userAgent); match != nil && match[1] != "K" {
+			hints.Model = strings.TrimPrefix(match[1], "SAMSUNG ")
+		}
+	case "macos":
+		// Apple silicon Macs ship with macOS 11 or later.
+		hints.Architecture, hints.Bitness = "x86", "64"
+		if !strings.HasPrefix(hints.PlatformVersion, "10.") {
+			hints.Architecture = "arm"
+		}
+	default:
+		hints.Architecture, hints.Bitness = "x86", "64"
+		if strings.Contains(userAgent, "WOW64") {
+			hints.Bitness, hints.WoW64 = "32", true
+		}
+	}
+
+	// Values the browser version does not expose yet are left empty.
+	if !supports("sec-ch-ua-full-version-list") {
+		hints.FullVersionList = nil
+	}
+	if !supports("sec-ch-ua-bitness") {
+		hints.Bitness = ""
+	}
+	if !supports("sec-ch-ua-wow64") {
+		hints.WoW64 = false
+	}
+
+	hints.Headers = map[string]string{
+		"sec-ch-ua":        FormatClientHintBrands(hints.Brands),
+		"sec-ch-ua-mobile": "?0",
+	}
+	if hints.Mobile {
+		hints.Headers["sec-ch-ua-mobile"] = "?1"
+	}
+	if supports("sec-ch-ua-platform") {
+		hints.Headers["sec-ch-ua-platform"] = `"` + hints.Platform + `"`
+	}
+	high := hints.ClientHints()
+	for _, hint := range HighEntropyClientHints {
+		if value, ok := high.value(hint); ok && supports(hint) {
+			hints.Headers[hint] = value
+		}
+	}
+	return hints, true
+}
+
+// ClientHints returns the high-entropy client hint values of h, as used by Request.ClientHints.
+func (h *UserAgentClientHints) ClientHints() *ClientHints {
+	return &ClientHints{
+		Architecture:    h.Architecture,
+		Bitness:         h.Bitness,
+		Model:           h.Model,
+		PlatformVersion: h.PlatformVersion,
+		FullVersion:     h.UaFullVersion,
+		FullVersionList: FormatClientHintBrands(h.FullVersionList),
+		WoW64:           h.WoW64,
+	}
+}
+
+// clientHintPlatform returns the navigator.userAgentData.platform of userAgent.
+func clientHintPlatform(userAgent string, operatingSystem string) string {
+	switch operatingSystem {
+	case "windows":
+		return "Windows"
+	case "macos":
+		return "macOS"
+	case "android":
+		return "Android"
+	}
+	if strings.Contains(userAgent, "CrOS") {
+		return "Chrome OS"
+	}
+	return "Linux"
+}
+
+// clientHintPlatformVersion returns the navigator.userAgentData.platformVersion of userAgent. Windows
+// NT 10.0 is taken to be Windows 11, the frozen macOS 10.15 of the user agent to be the real version
+// up to the last Chrome supporting it and the reduced Android version to be Android 14. Chrome before
+// 95 reports the Windows NT version instead of the Windows release.
+func clientHintPlatformVersion(userAgent string, operatingSystem string, chromeMajor int) string {
+	switch operatingSystem {
+	case "windows":
+		if chromeMajor < firstWindowsReleaseChrome {
+			if match := windowsNTPattern.FindStringSubmatch(userAgent); match != nil {
+				return match[1]
+			}
+			return "10.0"
+		}
+		if match := legacyWindowsPattern.FindStringSubmatch(userAgent); match != nil {
+			return "0." + match[1] + ".0"
+		}
+		return "15.0.0"
+	case "macos":
+		match := macOSUserAgentVersionPattern.FindStringSubmatch(userAgent)
+		if match != nil && (match[1] != "10" || match[2] != "15") {
+			return match[1] + "." + match[2] + "." + cmp.Or(match[3], "0")
+		}
+		if chromeMajor <= lastCatalinaChrome {
+			return "10.15.7"
+		}
+		return "15.0.0"
+	case "android":
+		if major, ok := GetOperatingSystemVersion(userAgent); ok {
+			return strconv.Itoa(major) + ".0.0"
+		}
+		return "14.0.0"
+	}
+	return "6.8.0"
+}
@@ -2,9 +2,16 @@ package header
 
 import (
 	"math/rand"
+	"regexp"
+	"strconv"
 	"strings"
+
+	"fingerprint-go/uautil"
 )
 
+// androidVersionPattern extracts the Android release of a user agent.
+var androidVersionPattern = regexp.MustCompile(`Android (\d+)`)
+
 // ShuffleArray randomly shuffles a slice of strings
 func ShuffleArray(arr []string) []string {
 	shuffled := make([]string, len(arr))
@@ -16,29 +23,85 @@ func ShuffleArray(arr []string) []string {
 }
 
 func GetUserAgent(headers map[string]string) string {
+	return uautil.UserAgent(headers)
+}
+
+// getHeaderValue returns the value of the header matching name case-insensitively, or "".
+func getHeaderValue(headers map[string]string, name string) string {
 	for k, v := range headers {
-		if strings.ToLower(k) == "user-agent" {
+		if strings.EqualFold(k, name) {
 			return v
 		}
 	}
 	return ""
 }
 
+// GetBrowser returns the browser of userAgent, see uautil.Browser.
 func GetBrowser(userAgent string) string {
-	userAgent = strings.ToLower(userAgent)
-	if strings.Contains(userAgent, "edg") {
-		return "edge"
+	return uautil.Browser(userAgent)
+}
+
+// GetBrowserVersion returns the version components of the browser identified in userAgent, or nil.
+func GetBrowserVersion(userAgent string) []int {
+	return uautil.Version(userAgent)
+}
+
+// GetDevice returns "mobile" for user agents of phones and tablets and "desktop" otherwise.
+func GetDevice(userAgent string) string {
+	return uautil.Device(userAgent)
+}
+
+// GetOperatingSystem returns the operating system of userAgent as one of SupportedOperatingSystems, or "".
+func GetOperatingSystem(userAgent string) string {
+	return uautil.OperatingSystem(userAgent)
+}
+
+// GetOperatingSystemVersion returns the major version of the operating system of userAgent, see
+// uautil.OperatingSystemVersion.
+func GetOperatingSystemVersion(userAgent string) (int, bool) {
+	return uautil.OperatingSystemVersion(userAgent)
+}
+
+// GetOperatingSystemFromPlatform returns the operating system of a navigator.userAgentData.platform
+// value as one of SupportedOperatingSystems, or "".
+func GetOperatingSystemFromPlatform(platform string) string {
+	switch platform {
+	case "Windows":
+		return "windows"
+	case "macOS":
+		return "macos"
+	case "Linux", "Chrome OS", "Chromium OS":
+		return "linux"
+	case "Android":
+		return "android"
+	case "iOS":
+		return "ios"
+	}
+	return ""
+}
+
+// GetPlatformOperatingSystemVersion returns the major operating system version described by a
+// navigator.userAgentData.platformVersion value. On Windows, platform versions from 13 are Windows 11.
+func GetPlatformOperatingSystemVersion(operatingSystem string, platformVersion string) (int, bool) {
+	parts := strings.Split(platformVersion, ".")
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, false
 	}
-	if strings.Contains(userAgent, "chrome") && !strings.Contains(userAgent, "edg") {
-		return "chrome"
+	if operatingSystem != "windows" {
+		return major, operatingSystem != "linux"
 	}
-	if strings.Contains(userAgent, "safari") && !strings.Contains(userAgent, "chrome") {
-		return "safari"
+
+	switch {
+	case major >= 13:
+		return 11, true
+	case major > 0:
+		return 10, true
 	}
-	if strings.Contains(userAgent, "firefox") {
-		return "firefox"
+	if len(parts) > 1 && parts[1] == "1" {
+		return 7, true
 	}
-	return ""
+	return 8, true
 }
 
 // GetBrowsersFromQuery is a placeholder for `browserslist` equivalent in Go.
@@ -0,0 +1,87 @@
+package header
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxUserAgentLength is the longest user agent accepted as a constraint. Real user agents are far
+// shorter; longer values are rejected before they reach the constraint lookups.
+const MaxUserAgentLength = 2048
+
+// maxOptionValueLength is the longest browser, operating system, device or locale name accepted.
+const maxOptionValueLength = 128
+
+// InputError is returned when a user agent or option value cannot be used as a constraint.
+type InputError struct {
+	// Field is the option the value was given for, e.g. "userAgent" or "locales".
+	Field  string
+	Value  string
+	Reason string
+}
+
+func (e *InputError) Error() string {
+	value := e.Value
+	if len(value) > 64 {
+		value = value[:64] + "..."
+	}
+	return fmt.Sprintf("The %s value %q is invalid: %s.", e.Field, value, e.Reason)
+}
+
+// ValidateUserAgent checks that userAgent can be used as a constraint: it must be valid UTF-8 without
+// control characters, at most MaxUserAgentLength bytes long and must not be a dataset token such as a
+// node name like "*BROWSER".
+func ValidateUserAgent(userAgent string) error {
+	return validateInput("userAgent", userAgent, MaxUserAgentLength)
+}
+
+func validateInput(field string, value string, maxLength int) error {
+	invalid := func(reason string) error {
+		return &InputError{Field: field, Value: value, Reason: reason}
+	}
+	switch {
+	case value == "":
+		return invalid("it is empty")
+	case len(value) > maxLength:
+		return invalid(fmt.Sprintf("it is longer than %d bytes", maxLength))
+	case !utf8.ValidString(value):
+		return invalid("it is not valid UTF-8")
+	case strings.IndexFunc(value, unicode.IsControl) >= 0:
+		return invalid("it contains control characters")
+	case strings.HasPrefix(value, "*"):
+		return invalid("it is a reserved dataset token")
+	}
+	return nil
+}
+
+// validateOptions checks the user agents and the names in options before they are used as constraints.
+func validateOptions(options *HeaderGeneratorOptions, userAgentValues []string) error {
+	for _, userAgent := range userAgentValues {
+		if err := ValidateUserAgent(userAgent); err != nil {
+			return err
+		}
+	}
+
+	names := map[string][]string{"devices": options.Devices, "locales": options.Locales}
+	for _, browser := range options.Browsers {
+		switch b := browser.(type) {
+		case string:
+			names["browsers"] = append(names["browsers"], b)
+		case BrowserSpecification:
+			names["browsers"] = append(names["browsers"], b.Name)
+		}
+	}
+	for _, operatingSystem := range PrepareOperatingSystems(options) {
+		names["operatingSystems"] = append(names["operatingSystems"], operatingSystem.Name)
+	}
+	for field, values := range names {
+		for _, value := range values {
+			if err := validateInput(field, value, maxOptionValueLength); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
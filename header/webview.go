@@ -0,0 +1,164 @@
+package header
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DeviceWebView is the device of an Android WebView embedded in an app. It is sampled like Chrome on an
+// Android phone and turned into the WebView of the app named by WebViewPackage, which sends its package
+// name as X-Requested-With.
+const DeviceWebView = "webview"
+
+// DefaultWebViewPackage is the app package name sent by WebViews when WebViewPackage is not set.
+const DefaultWebViewPackage = "com.google.android.googlequicksearchbox"
+
+var webViewPlatformPattern = regexp.MustCompile(`(Linux; Android [^)]*?)(; wv)?\)`)
+
+// IsWebView reports whether userAgent is the user agent of an Android WebView.
+func IsWebView(userAgent string) bool {
+	return strings.Contains(userAgent, "; wv)")
+}
+
+// WebViewUserAgent returns the user agent of the Android WebView matching a Chrome on Android user agent.
+func WebViewUserAgent(chromeUserAgent string) string {
+	if IsWebView(chromeUserAgent) {
+		return chromeUserAgent
+	}
+	userAgent := webViewPlatformPattern.ReplaceAllString(chromeUserAgent, "$1; wv)")
+	return strings.Replace(userAgent, "(KHTML, like Gecko) Chrome/", "(KHTML, like Gecko) Version/4.0 Chrome/", 1)
+}
+
+// ChromeUserAgent returns the Chrome user agent a WebView, in-app browser or Electron user agent is
+// derived from.
+func ChromeUserAgent(webViewUserAgent string) string {
+	if IsElectron(webViewUserAgent) {
+		return electronTokensPattern.ReplaceAllString(webViewUserAgent, " $3")
+	}
+	if !IsWebView(webViewUserAgent) {
+		return webViewUserAgent
+	}
+	userAgent := webViewUserAgent
+	if app, ok := inAppBrowsers[GetInAppBrowser(userAgent)]; ok {
+		userAgent = app.token.ReplaceAllString(userAgent, "")
+	}
+	userAgent = strings.Replace(userAgent, "; wv)", ")", 1)
+	return strings.Replace(userAgent, " Version/4.0 Chrome/", " Chrome/", 1)
+}
+
+// webViewOnly reports whether devices ask for WebViews only. Together with "mobile", WebViews are not
+// generated, as the dataset holds browsers.
+func webViewOnly(devices []string) bool {
+	return slices.Contains(devices, DeviceWebView) && !slices.Contains(devices, "mobile")
+}
+
+// generatesWebView reports whether options ask for WebViews or in-app browsers only.
+func (options *HeaderGeneratorOptions) generatesWebView() bool {
+	return webViewOnly(options.Devices) || options.InAppBrowser != ""
+}
+
+// inputDevices returns the devices of the input network standing for devices.
+func inputDevices(devices []string) []string {
+	var result []string
+	for _, device := range devices {
+		if device == DeviceWebView {
+			device = "mobile"
+		}
+		if !slices.Contains(result, device) {
+			result = append(result, device)
+		}
+	}
+	return result
+}
+
+// applyWebView turns the headers of Chrome on Android into the headers of a WebView of the app packageName.
+func applyWebView(headers map[string]string, packageName string) {
+	for name, value := range headers {
+		if strings.EqualFold(name, "user-agent") {
+			headers[name] = WebViewUserAgent(value)
+		}
+	}
+	headers[headerName(headers, "X-Requested-With")] = cmp.Or(packageName, DefaultWebViewPackage)
+}
+
+// In-app browsers are the WebViews social apps open links in. They extend the WebView user agent with
+// a token of the app.
+const (
+	InAppBrowserFacebook  = "facebook"
+	InAppBrowserInstagram = "instagram"
+	InAppBrowserLine      = "line"
+)
+
+type inAppBrowser struct {
+	packageName string
+	token       *regexp.Regexp
+	suffix      func(userAgent string) string
+}
+
+var inAppBrowsers = map[string]inAppBrowser{
+	InAppBrowserFacebook: {
+		packageName: "com.facebook.katana",
+		token:       regexp.MustCompile(` \[FB_IAB/FB4A;FBAV/[^\]]*\]$`),
+		suffix: func(string) string {
+			return " [FB_IAB/FB4A;FBAV/452.0.0.44.109;]"
+		},
+	},
+	InAppBrowserInstagram: {
+		packageName: "com.instagram.android",
+		token:       regexp.MustCompile(` Instagram [\d.]+ Android \([^)]*\)$`),
+		suffix: func(userAgent string) string {
+			release := "13"
+			if match := androidVersionPattern.FindStringSubmatch(userAgent); match != nil {
+				release = match[1]
+			}
+			return " Instagram 321.0.0.39.106 Android (" + androidAPILevel(release) + "/" + release +
+				"; 420dpi; 1080x2400; Google; Pixel 7; panther; panther; en_US; 568880215)"
+		},
+	},
+	InAppBrowserLine: {
+		packageName: "jp.naver.line.android",
+		token:       regexp.MustCompile(` Line/[\d.]+/IAB$`),
+		suffix: func(string) string {
+			return " Line/14.4.1/IAB"
+		},
+	},
+}
+
+// androidAPILevel returns the API level of an Android release.
+func androidAPILevel(release string) string {
+	levels := map[string]string{"10": "29", "11": "30", "12": "31", "13": "33", "14": "34", "15": "35"}
+	if level, ok := levels[release]; ok {
+		return level
+	}
+	return "33"
+}
+
+// GetInAppBrowser returns the in-app browser userAgent belongs to, e.g. InAppBrowserFacebook, or "".
+func GetInAppBrowser(userAgent string) string {
+	for name, app := range inAppBrowsers {
+		if app.token.MatchString(userAgent) {
+			return name
+		}
+	}
+	return ""
+}
+
+// applyInAppBrowser turns the headers of a WebView into those of the in-app browser name. In-app
+// browsers do not send high-entropy client hints.
+func applyInAppBrowser(headers map[string]string, name string) {
+	app, ok := inAppBrowsers[name]
+	if !ok {
+		return
+	}
+	applyWebView(headers, app.packageName)
+	for header, value := range headers {
+		if strings.EqualFold(header, "user-agent") {
+			headers[header] = value + app.suffix(value)
+		}
+		if slices.Contains(HighEntropyClientHints, strings.ToLower(header)) {
+			delete(headers, header)
+		}
+	}
+}
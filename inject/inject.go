@@ -0,0 +1,206 @@
+// Package inject injects the init script of a fingerprint into proxied HTML responses, for crawlers
+// that fetch pages through an HTTP proxy instead of driving a browser over the DevTools protocol.
+package inject
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"fingerprint-go/fingerprint"
+)
+
+// openingTags match the tags the script is inserted after, in order of preference.
+var openingTags = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)<head(\s[^>]*)?>`),
+	regexp.MustCompile(`(?i)<html(\s[^>]*)?>`),
+}
+
+// Injector inserts the init script of a fingerprint into HTML responses.
+type Injector struct {
+	script string
+	hash   string
+}
+
+// NewInjector returns an Injector for the init script of fp, see fingerprint.InitScript. Minify is
+// recommended, since the script is sent with every page.
+func NewInjector(fp *fingerprint.Fingerprint, options *fingerprint.InitScriptOptions) (*Injector, error) {
+	script, err := fingerprint.InitScript(fp, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build init script: %w", err)
+	}
+	// A closing script tag in a string would end the script element early.
+	script = strings.ReplaceAll(script, "</", `<\/`)
+	sum := sha256.Sum256([]byte(script))
+	return &Injector{script: script, hash: "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"}, nil
+}
+
+// Inject inserts the script into an HTML document, after the opening head tag, the opening html tag or
+// at the start.
+func (in *Injector) Inject(document []byte) []byte {
+	element := "<script>" + in.script + "</script>"
+	at := 0
+	for _, tag := range openingTags {
+		if loc := tag.FindIndex(document); loc != nil {
+			at = loc[1]
+			break
+		}
+	}
+	injected := make([]byte, 0, len(document)+len(element))
+	injected = append(injected, document[:at]...)
+	injected = append(injected, element...)
+	return append(injected, document[at:]...)
+}
+
+// ModifyResponse injects the script into resp when it is an HTML document, and can be used as the
+// ModifyResponse of an httputil.ReverseProxy. Bodies compressed with gzip or deflate are sent
+// decompressed, other encodings such as br are passed through unchanged, since rewriting the
+// request's Accept-Encoding would change the headers of the fingerprint.
+func (in *Injector) ModifyResponse(resp *http.Response) error {
+	if !isHTML(resp.Header) || resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return nil
+	}
+	reader, ok, err := decodedBody(resp.Body, resp.Header.Get("Content-Encoding"))
+	if err != nil || !ok {
+		return err
+	}
+	body, err := io.ReadAll(reader)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	body = in.Inject(body)
+
+	in.rewriteHeader(resp.Header, len(body))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Uncompressed = true
+	return nil
+}
+
+// Handler returns a middleware that injects the script into the HTML responses of next, e.g. an
+// httputil.ReverseProxy or a caching layer serving fetched pages.
+func (in *Injector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &responseRecorder{header: make(http.Header)}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		resp := &http.Response{
+			StatusCode: recorder.status,
+			Header:     recorder.header,
+			Body:       io.NopCloser(&recorder.body),
+			Request:    r,
+		}
+		if err := in.ModifyResponse(resp); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		for name, values := range resp.Header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	})
+}
+
+// rewriteHeader updates the headers of a response whose body was replaced by the injected document of
+// length bytes. Inline scripts restricted by a Content-Security-Policy are allowed by the hash of the
+// script.
+func (in *Injector) rewriteHeader(header http.Header, length int) {
+	header.Del("Content-Encoding")
+	header.Del("ETag")
+	header.Del("Content-MD5")
+	header.Set("Content-Length", strconv.Itoa(length))
+	for _, name := range []string{"Content-Security-Policy", "Content-Security-Policy-Report-Only"} {
+		policies := header.Values(name)
+		for x, policy := range policies {
+			policies[x] = allowScriptHash(policy, in.hash)
+		}
+	}
+}
+
+// allowScriptHash adds hash to the directive restricting inline scripts of policy: script-src-elem,
+// script-src or default-src, whichever comes first in that order. Directives allowing any inline
+// script are left alone, since a hash would disable their 'unsafe-inline'.
+func allowScriptHash(policy string, hash string) string {
+	directives := strings.Split(policy, ";")
+	for _, name := range []string{"script-src-elem", "script-src", "default-src"} {
+		for x, directive := range directives {
+			fields := strings.Fields(directive)
+			if len(fields) == 0 || !strings.EqualFold(fields[0], name) {
+				continue
+			}
+			for _, source := range fields[1:] {
+				if strings.EqualFold(source, "'unsafe-inline'") && !strings.Contains(directive, "'nonce-") &&
+					!strings.Contains(directive, "'sha") {
+					return policy
+				}
+			}
+			directives[x] = strings.TrimRight(directive, " ") + " " + hash
+			return strings.Join(directives, ";")
+		}
+	}
+	return policy
+}
+
+func isHTML(header http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+// decodedBody returns the decompressed body, ok is false for unsupported encodings.
+func decodedBody(body io.Reader, encoding string) (reader io.Reader, ok bool, err error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, true, nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decompress response body: %w", err)
+		}
+		return reader, true, nil
+	case "deflate":
+		reader, err := zlib.NewReader(body)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decompress response body: %w", err)
+		}
+		return reader, true, nil
+	}
+	return nil, false, nil
+}
+
+// responseRecorder buffers a response, so that its body can be rewritten before it is sent.
+type responseRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
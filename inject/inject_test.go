@@ -0,0 +1,32 @@
+package inject
+
+import "testing"
+
+func TestAllowScriptHash(t *testing.T) {
+	const hash = "'sha256-abc='"
+	tests := []struct {
+		name   string
+		policy string
+		want   string
+	}{
+		{"script-src", "script-src 'self'", "script-src 'self' 'sha256-abc='"},
+		{"default-src", "default-src 'self'; img-src *", "default-src 'self' 'sha256-abc='; img-src *"},
+		{"script-src before default-src", "default-src 'none'; script-src 'self'", "default-src 'none'; script-src 'self' 'sha256-abc='"},
+		{"script-src-elem first", "script-src 'self'; script-src-elem 'self'", "script-src 'self'; script-src-elem 'self' 'sha256-abc='"},
+		{"case-insensitive name", "Script-Src 'self'", "Script-Src 'self' 'sha256-abc='"},
+		{"trailing space", "script-src 'self' ; img-src *", "script-src 'self' 'sha256-abc='; img-src *"},
+		{"unsafe-inline", "script-src 'self' 'unsafe-inline'", "script-src 'self' 'unsafe-inline'"},
+		{"unsafe-inline in default-src", "default-src 'unsafe-inline'", "default-src 'unsafe-inline'"},
+		// A nonce or hash already disables 'unsafe-inline'.
+		{"unsafe-inline with nonce", "script-src 'unsafe-inline' 'nonce-xyz'", "script-src 'unsafe-inline' 'nonce-xyz' 'sha256-abc='"},
+		{"unsafe-inline with hash", "script-src 'unsafe-inline' 'sha384-def='", "script-src 'unsafe-inline' 'sha384-def=' 'sha256-abc='"},
+		{"nonce", "script-src 'nonce-xyz' 'strict-dynamic'", "script-src 'nonce-xyz' 'strict-dynamic' 'sha256-abc='"},
+		{"no script restriction", "img-src *; frame-ancestors 'none'", "img-src *; frame-ancestors 'none'"},
+		{"empty", "", ""},
+	}
+	for _, test := range tests {
+		if got := allowScriptHash(test.policy, hash); got != test.want {
+			t.Errorf("%s: allowScriptHash(%q) = %q, want %q", test.name, test.policy, got, test.want)
+		}
+	}
+}
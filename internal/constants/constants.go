@@ -0,0 +1,22 @@
+// Package constants holds the node names and tokens of the dataset format shared by the generators,
+// the network builder and the public dataset package.
+package constants
+
+const (
+	BrowserHttpNodeName     = "*BROWSER_HTTP"
+	HttpVersionNodeName     = "*HTTP_VERSION"
+	BrowserNodeName         = "*BROWSER"
+	OperatingSystemNodeName = "*OPERATING_SYSTEM"
+	DeviceNodeName          = "*DEVICE"
+
+	// MissingValueDatasetToken stands for attributes a record does not have.
+	MissingValueDatasetToken = "*MISSING_VALUE*"
+	// StringifiedPrefix marks values holding JSON-encoded objects.
+	StringifiedPrefix = "*STRINGIFIED*"
+	// RecordWeightKey holds the training weight of a record, a float64 that defaults to 1.
+	RecordWeightKey = "*WEIGHT"
+
+	// ScreenOffsetStep is the step screen offsets are bucketed to when the networks are built with
+	// bucketed screens and jittered within when they are generated.
+	ScreenOffsetStep = 10
+)
@@ -0,0 +1,62 @@
+// Package seal encrypts exported identities at rest with AES-GCM, for the sealed bundles of the
+// fingerprint package and the sealed cookie jars of the header package.
+package seal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// magic starts sealed data, so that unsealed data is told apart instead of failing authentication.
+var magic = []byte("FPSEAL1\n")
+
+// Seal encrypts plaintext with key, an AES-128, AES-192 or AES-256 key, under a random nonce.
+func Seal(plaintext []byte, key []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := append(bytes.Clone(magic), nonce...)
+	return aead.Seal(sealed, nonce, plaintext, magic), nil
+}
+
+// Open decrypts data sealed by Seal with key.
+func Open(sealed []byte, key []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if !IsSealed(sealed) {
+		return nil, errors.New("the data is not sealed")
+	}
+	sealed = sealed[len(magic):]
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("the sealed data is truncated")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], magic)
+	if err != nil {
+		return nil, errors.New("the sealed data could not be opened with the key")
+	}
+	return plaintext, nil
+}
+
+// IsSealed reports whether data was sealed by Seal.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,39 @@
+package seal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	sealed, err := Seal([]byte("cookies"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || bytes.Contains(sealed, []byte("cookies")) {
+		t.Fatalf("Seal returned %q", sealed)
+	}
+	if plaintext, err := Open(sealed, key); err != nil || string(plaintext) != "cookies" {
+		t.Fatalf("Open = %q, %v", plaintext, err)
+	}
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+	tests := []struct {
+		name   string
+		sealed []byte
+		key    []byte
+	}{
+		{"other key", sealed, bytes.Repeat([]byte{8}, 32)},
+		{"tampered", tampered, key},
+		{"truncated", sealed[:len(magic)+4], key},
+		{"not sealed", []byte("cookies"), key},
+		{"invalid key", sealed, []byte("short")},
+	}
+	for _, test := range tests {
+		if _, err := Open(test.sealed, test.key); err == nil {
+			t.Errorf("%s: Open succeeded", test.name)
+		}
+	}
+}
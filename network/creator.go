@@ -7,18 +7,22 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"fingerprint-go/bayesian"
+	"fingerprint-go/internal/constants"
+	"fingerprint-go/uautil"
 )
 
+// The dataset constants are also available from the dataset package.
 const (
-	BrowserHttpNodeName      = "*BROWSER_HTTP"
-	HttpVersionNodeName      = "*HTTP_VERSION"
-	BrowserNodeName          = "*BROWSER"
-	OperatingSystemNodeName  = "*OPERATING_SYSTEM"
-	DeviceNodeName           = "*DEVICE"
-	MissingValueDatasetToken = "*MISSING_VALUE*"
-	StringifiedPrefix        = "*STRINGIFIED*"
+	BrowserHttpNodeName      = constants.BrowserHttpNodeName
+	HttpVersionNodeName      = constants.HttpVersionNodeName
+	BrowserNodeName          = constants.BrowserNodeName
+	OperatingSystemNodeName  = constants.OperatingSystemNodeName
+	DeviceNodeName           = constants.DeviceNodeName
+	MissingValueDatasetToken = constants.MissingValueDatasetToken
+	StringifiedPrefix        = constants.StringifiedPrefix
 )
 
 var NonGeneratedNodes = []string{
@@ -30,18 +34,38 @@ var NonGeneratedNodes = []string{
 
 var PluginCharacteristicsAttributes = []string{"plugins", "mimeTypes"}
 
-func prepareRecords(records []map[string]any, preprocessingType string) ([]map[string]any, error) {
+// prepareRecords validates records and deconstructs them into the header or fingerprint records, with
+// the recency weight of every record, see RecencyHalfLife.
+func (c *GeneratorNetworksCreator) prepareRecords(records []map[string]any, files []datasetFile, preprocessingType string) ([]map[string]any, []float64, error) {
 	var cleanedRecords []map[string]any
 
-	for _, rec := range records {
+	validPerFile := make([]int, len(files))
+	file, fileEnd := 0, 0
+	for x, rec := range records {
+		for file < len(files) && x >= fileEnd+files[file].Records {
+			fileEnd += files[file].Records
+			file++
+		}
 		if validRec, ok := ValidateRecord(rec); ok {
 			cleanedRecords = append(cleanedRecords, validRec)
+			if file < len(files) {
+				validPerFile[file]++
+			}
 		}
 	}
 
 	fmt.Printf("Found %d/%d valid records.\n", len(cleanedRecords), len(records))
+	if len(files) > 1 {
+		for x, f := range files {
+			fmt.Printf("  %s: %d/%d valid records\n", f.Path, validPerFile[x], f.Records)
+		}
+	}
+	if c.Strict && len(cleanedRecords) < len(records) {
+		return nil, nil, fmt.Errorf("%d of %d dataset records are invalid", len(records)-len(cleanedRecords), len(records))
+	}
 
 	var deconstructedRecords []map[string]any
+	var capturedRecords []map[string]any
 
 	for _, record := range cleanedRecords {
 		if preprocessingType == "headers" {
@@ -54,11 +78,13 @@ func prepareRecords(records []map[string]any, preprocessingType string) ([]map[s
 			if hOk {
 				headers[HttpVersionNodeName] = "_" + httpVersion + "_"
 				deconstructedRecords = append(deconstructedRecords, headers)
+				capturedRecords = append(capturedRecords, record)
 			}
 		} else {
 			bfMap, ok := record["browserFingerprint"].(map[string]any)
 			if ok {
 				deconstructedRecords = append(deconstructedRecords, bfMap)
+				capturedRecords = append(capturedRecords, record)
 			}
 		}
 	}
@@ -83,105 +109,154 @@ func prepareRecords(records []map[string]any, preprocessingType string) ([]map[s
 		reorganizedRecords = append(reorganizedRecords, reorganizedRecord)
 	}
 
-	return reorganizedRecords, nil
+	return reorganizedRecords, c.recencyWeights(capturedRecords), nil
 }
 
-type GeneratorNetworksCreator struct{}
+type GeneratorNetworksCreator struct {
+	// Strict fails the preparation when the dataset holds invalid records instead of skipping them.
+	Strict bool
+	// Format is the format of the datasets read, one of DatasetFormats. It defaults to FormatJSON.
+	Format string
+	// RecencyHalfLife, when set, weights the records by their capture time for training: a record
+	// captured RecencyHalfLife before the newest one counts half as much. Newly released browser
+	// versions are otherwise outweighed by months of older records.
+	RecencyHalfLife time.Duration
+	// TimestampField is the top-level record field holding the capture time, as an RFC 3339 string or
+	// a Unix time in seconds or milliseconds. It defaults to "timestamp".
+	TimestampField string
+	// MinPluginSetRecords is the number of records a set of plugins and MIME types must be seen in to
+	// be kept; rarer sets are replaced by the most common set of the browser. It defaults to
+	// DefaultMinPluginSetRecords, 1 keeps every set.
+	MinPluginSetRecords int
+	// BucketScreens rounds the screen offsets and collapses rare outer heights, see bucketScreens, so
+	// that the screen node does not get a value per record. Generate with
+	// FingerprintGeneratorOptions.ScreenJitter to restore the variety of the offsets.
+	BucketScreens bool
+	// MinScreenVariantRecords is the number of records an outer height of a screen size must be seen in
+	// to be kept by BucketScreens. It defaults to DefaultMinScreenVariantRecords.
+	MinScreenVariantRecords int
+}
 
 func NewGeneratorNetworksCreator() *GeneratorNetworksCreator {
 	return &GeneratorNetworksCreator{}
 }
 
 func (c *GeneratorNetworksCreator) getDeviceOS(userAgent string) (device string, operatingSystem string) {
-	uaLower := strings.ToLower(userAgent)
-	operatingSystem = MissingValueDatasetToken
-	device = "desktop"
-
-	if strings.Contains(uaLower, "windows") {
-		operatingSystem = "windows"
-	}
-
-	mobilePattern := regexp.MustCompile(`(?i)(phone|android|mobile)`)
-	if mobilePattern.MatchString(uaLower) {
-		device = "mobile"
-		if regexp.MustCompile(`(?i)(iphone|mac)`).MatchString(uaLower) {
-			operatingSystem = "ios"
-		} else if strings.Contains(uaLower, "android") {
-			operatingSystem = "android"
-		}
-	} else if strings.Contains(uaLower, "linux") {
-		operatingSystem = "linux"
-	} else if strings.Contains(uaLower, "mac") {
-		operatingSystem = "macos"
+	operatingSystem = uautil.OperatingSystem(userAgent)
+	if operatingSystem == "" {
+		operatingSystem = MissingValueDatasetToken
 	}
-
-	return device, operatingSystem
+	return uautil.Device(userAgent), operatingSystem
 }
 
+var unsupportedBrowsersPattern = regexp.MustCompile(`(?i)(opr|yabrowser|SamsungBrowser|UCBrowser|vivaldi)`)
+
 func (c *GeneratorNetworksCreator) getBrowserNameVersion(userAgent string) string {
-	canonicalNames := map[string]string{
-		"chrome":  "chrome",
-		"crios":   "chrome",
-		"firefox": "firefox",
-		"fxios":   "firefox",
-		"safari":  "safari",
-		"edge":    "edge",
-		"edg":     "edge",
-		"edga":    "edge",
-		"edgios":  "edge",
+	if unsupportedBrowsersPattern.MatchString(userAgent) {
+		return MissingValueDatasetToken
 	}
 
-	unsupportedBrowsers := regexp.MustCompile(`(?i)(opr|yabrowser|SamsungBrowser|UCBrowser|vivaldi)`)
-	edgeRegex := regexp.MustCompile(`(?i)(edg(a|ios|e)?)/([0-9.]*)`)
-	safariRegex := regexp.MustCompile(`(?i)Version/([\d.]+)( Mobile/[a-z0-9]+)? Safari`)
-	supportedBrowsers := regexp.MustCompile(`(?i)(firefox|fxios|chrome|crios|safari)/([0-9.]*)`)
-
-	if unsupportedBrowsers.MatchString(userAgent) {
+	browser, version := uautil.Browser(userAgent), uautil.FullVersion(userAgent)
+	if browser == "" || version == "" {
 		return MissingValueDatasetToken
 	}
+	return browser + "/" + version
+}
 
-	if match := edgeRegex.FindStringSubmatch(userAgent); match != nil {
-		return "edge/" + match[3]
+func (c *GeneratorNetworksCreator) PrepareHeaderGeneratorFiles(datasetPath string, resultsPath string) error {
+	data, err := c.readDataset(datasetPath)
+	if err != nil {
+		return err
 	}
 
-	if match := safariRegex.FindStringSubmatch(userAgent); match != nil {
-		return "safari/" + match[1]
+	// This assumes the bayesian networks already exist at these paths or we are initializing them
+	// The TS implementation used relative paths. In Go, you will have to provide the correct ones.
+	inputNetworkStructurePath := filepath.Join("network_structures", "input-network-structure.zip")
+	headerNetworkStructurePath := filepath.Join("network_structures", "header-network-structure.zip")
+
+	inputGeneratorNetwork := bayesian.NewNetwork(inputNetworkStructurePath)
+	headerGeneratorNetwork := bayesian.NewNetwork(headerNetworkStructurePath)
+
+	finalRecords, err := c.headerRecords(data, headerGeneratorNetwork)
+	if err != nil {
+		return err
 	}
 
-	if match := supportedBrowsers.FindStringSubmatch(userAgent); match != nil {
-		browser := strings.ToLower(match[1])
-		return canonicalNames[browser] + "/" + match[2]
+	_ = inputGeneratorNetwork
+
+	/* Note: bayesian package doesn't define SetProbabilitiesAccordingToData yet, so you would implement it in network.go or just leave as stub.
+	   headerGeneratorNetwork.SetProbabilitiesAccordingToData(finalRecords)
+	   inputGeneratorNetwork.SetProbabilitiesAccordingToData(finalRecords)
+	*/
+
+	/*
+	   inputNetworkDefinitionPath := filepath.Join(resultsPath, "input-network-definition.zip")
+	   headerNetworkDefinitionPath := filepath.Join(resultsPath, "header-network-definition.zip")
+	   headerGeneratorNetwork.SaveNetworkDefinition(headerNetworkDefinitionPath)
+	   inputGeneratorNetwork.SaveNetworkDefinition(inputNetworkDefinitionPath)
+	*/
+
+	// The helper file maps every browser and HTTP version to the number of records it was seen in, so
+	// that the header generator can tell how well a version range is covered by the dataset.
+	browserHelperFilePath := filepath.Join(resultsPath, "browser-helper-file.json")
+	browserHttpRecords := make(map[string]int)
+	for _, record := range finalRecords {
+		if browserHttp, ok := record[BrowserHttpNodeName].(string); ok {
+			browserHttpRecords[browserHttp]++
+		}
 	}
 
-	return MissingValueDatasetToken
-}
+	b, _ := json.Marshal(browserHttpRecords)
+	if err := os.WriteFile(browserHelperFilePath, b, 0644); err != nil {
+		return err
+	}
 
-func (c *GeneratorNetworksCreator) PrepareHeaderGeneratorFiles(datasetPath string, resultsPath string) error {
-	datasetText, err := os.ReadFile(datasetPath)
+	headersOrder, err := c.deriveHeaderOrders(data)
 	if err != nil {
 		return err
 	}
+	b, _ = json.Marshal(headersOrder)
+	return os.WriteFile(filepath.Join(resultsPath, "headers-order.json"), b, 0644)
+}
 
-	var parsedRecords []map[string]any
-	if err := json.Unmarshal(datasetText, &parsedRecords); err != nil {
+func (c *GeneratorNetworksCreator) PrepareFingerprintGeneratorFiles(datasetPath string, resultsPath string) error {
+	data, err := c.readDataset(datasetPath)
+	if err != nil {
 		return err
 	}
 
-	records, err := prepareRecords(parsedRecords, "headers")
+	fingerprintNetworkStructurePath := filepath.Join("network_structures", "fingerprint-network-structure.zip")
+	fingerprintGeneratorNetwork := bayesian.NewNetwork(fingerprintNetworkStructurePath)
+
+	selectedRecords, err := c.fingerprintRecords(data, fingerprintGeneratorNetwork)
 	if err != nil {
 		return err
 	}
+	_ = selectedRecords
 
-	// This assumes the bayesian networks already exist at these paths or we are initializing them
-	// The TS implementation used relative paths. In Go, you will have to provide the correct ones.
-	inputNetworkStructurePath := filepath.Join("network_structures", "input-network-structure.zip")
-	headerNetworkStructurePath := filepath.Join("network_structures", "header-network-structure.zip")
+	// fingerprintNetworkDefinitionPath := filepath.Join(resultsPath, "fingerprint-network-definition.zip")
+	fmt.Println("Building the fingerprint network...")
+	// fingerprintGeneratorNetwork.SetProbabilitiesAccordingToData(selectedRecords)
+	// fingerprintGeneratorNetwork.SaveNetworkDefinition(fingerprintNetworkDefinitionPath)
 
-	inputGeneratorNetwork := bayesian.NewNetwork(inputNetworkStructurePath)
-	headerGeneratorNetwork := bayesian.NewNetwork(headerNetworkStructurePath)
+	return nil
+}
+
+// headerRecords prepares the header records of the dataset for the attributes of headerNetwork, with
+// the browser, operating system and device derived from the user agent.
+func (c *GeneratorNetworksCreator) headerRecords(data *dataset, headerNetwork *bayesian.Network) (bayesian.RecordList, error) {
+	var parsedRecords []map[string]any
+	if err := json.Unmarshal(data.text, &parsedRecords); err != nil {
+		return nil, err
+	}
+
+	records, weights, err := c.prepareRecords(parsedRecords, data.files, "headers")
+	if err != nil {
+		return nil, err
+	}
 
 	desiredHeaderAttributes := make(map[string]struct{})
-	for attr := range headerGeneratorNetwork.NodesByName {
+	for attr := range headerNetwork.NodesByName {
 		isGenerated := true
 		for _, nonGen := range NonGeneratedNodes {
 			if attr == nonGen {
@@ -235,58 +310,21 @@ func (c *GeneratorNetworksCreator) PrepareHeaderGeneratorFiles(datasetPath strin
 		finalRecords = append(finalRecords, record)
 	}
 
-	/* Note: bayesian package doesn't define SetProbabilitiesAccordingToData yet, so you would implement it in network.go or just leave as stub.
-	   headerGeneratorNetwork.SetProbabilitiesAccordingToData(finalRecords)
-	   inputGeneratorNetwork.SetProbabilitiesAccordingToData(finalRecords)
-	*/
-
-	/*
-	   inputNetworkDefinitionPath := filepath.Join(resultsPath, "input-network-definition.zip")
-	   headerNetworkDefinitionPath := filepath.Join(resultsPath, "header-network-definition.zip")
-	   headerGeneratorNetwork.SaveNetworkDefinition(headerNetworkDefinitionPath)
-	   inputGeneratorNetwork.SaveNetworkDefinition(inputNetworkDefinitionPath)
-	*/
-
-	browserHelperFilePath := filepath.Join(resultsPath, "browser-helper-file.json")
-	uniqueBrowsersAndHttpsSet := make(map[string]struct{})
-	for _, record := range finalRecords {
-		if browserHttp, ok := record[BrowserHttpNodeName].(string); ok {
-			uniqueBrowsersAndHttpsSet[browserHttp] = struct{}{}
-		}
-	}
-
-	var uniqueBrowsersAndHttps []string
-	for k := range uniqueBrowsersAndHttpsSet {
-		uniqueBrowsersAndHttps = append(uniqueBrowsersAndHttps, k)
-	}
-
-	b, _ := json.Marshal(uniqueBrowsersAndHttps)
-	if err := os.WriteFile(browserHelperFilePath, b, 0644); err != nil {
-		return err
-	}
-
-	return nil
+	setRecordWeights(finalRecords, weights)
+	return finalRecords, nil
 }
 
-func (c *GeneratorNetworksCreator) PrepareFingerprintGeneratorFiles(datasetPath string, resultsPath string) error {
-	datasetTextBytes, err := os.ReadFile(datasetPath)
-	if err != nil {
-		return err
-	}
-
-	datasetText := string(datasetTextBytes)
-	if strings.HasPrefix(datasetText, "\ufeff") {
-		datasetText = datasetText[3:]
-	}
-
+// fingerprintRecords prepares the fingerprint records of the dataset for the attributes of
+// fingerprintNetwork, with non-string values stringified.
+func (c *GeneratorNetworksCreator) fingerprintRecords(data *dataset, fingerprintNetwork *bayesian.Network) (bayesian.RecordList, error) {
 	var parsedRecords []map[string]any
-	if err := json.Unmarshal([]byte(datasetText), &parsedRecords); err != nil {
-		return err
+	if err := json.Unmarshal(data.text, &parsedRecords); err != nil {
+		return nil, err
 	}
 
-	records, err := prepareRecords(parsedRecords, "fingerprints")
+	records, weights, err := c.prepareRecords(parsedRecords, data.files, "fingerprints")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for x, record := range records {
@@ -312,7 +350,12 @@ func (c *GeneratorNetworksCreator) PrepareFingerprintGeneratorFiles(datasetPath
 		} else {
 			record["pluginsData"] = MissingValueDatasetToken
 		}
+	}
 
+	c.canonicalizePlugins(records)
+	c.bucketScreens(records)
+
+	for x, record := range records {
 		for attr, val := range record {
 			if val == nil || val == "" {
 				record[attr] = MissingValueDatasetToken
@@ -326,11 +369,8 @@ func (c *GeneratorNetworksCreator) PrepareFingerprintGeneratorFiles(datasetPath
 		records[x] = record
 	}
 
-	fingerprintNetworkStructurePath := filepath.Join("network_structures", "fingerprint-network-structure.zip")
-	fingerprintGeneratorNetwork := bayesian.NewNetwork(fingerprintNetworkStructurePath)
-
 	desiredFingerprintAttributes := make(map[string]struct{})
-	for attr := range fingerprintGeneratorNetwork.NodesByName {
+	for attr := range fingerprintNetwork.NodesByName {
 		desiredFingerprintAttributes[attr] = struct{}{}
 	}
 
@@ -349,10 +389,6 @@ func (c *GeneratorNetworksCreator) PrepareFingerprintGeneratorFiles(datasetPath
 		selectedRecords = append(selectedRecords, selRec)
 	}
 
-	// fingerprintNetworkDefinitionPath := filepath.Join(resultsPath, "fingerprint-network-definition.zip")
-	fmt.Println("Building the fingerprint network...")
-	// fingerprintGeneratorNetwork.SetProbabilitiesAccordingToData(selectedRecords)
-	// fingerprintGeneratorNetwork.SaveNetworkDefinition(fingerprintNetworkDefinitionPath)
-
-	return nil
+	setRecordWeights(selectedRecords, weights)
+	return selectedRecords, nil
 }
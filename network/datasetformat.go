@@ -0,0 +1,249 @@
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Dataset formats read by GeneratorNetworksCreator.
+const (
+	// FormatJSON is a JSON array of records with browserFingerprint and requestFingerprint objects.
+	FormatJSON = "json"
+	// FormatApify is a dataset export of the Apify fingerprint collector actor, e.g. items.json, as a
+	// JSON array or as JSON Lines.
+	FormatApify = "apify"
+)
+
+// DatasetFormats are the formats GeneratorNetworksCreator.Format accepts.
+var DatasetFormats = []string{FormatJSON, FormatApify}
+
+// apifyItem is an item of an Apify dataset export. The collector actor has stored the fingerprints
+// under different names over time, all of which are accepted.
+type apifyItem struct {
+	BrowserFingerprint json.RawMessage `json:"browserFingerprint"`
+	Fingerprint        json.RawMessage `json:"fingerprint"`
+	RequestFingerprint *apifyRequest   `json:"requestFingerprint"`
+	Request            *apifyRequest   `json:"request"`
+	Timestamp          json.RawMessage `json:"timestamp"`
+	CreatedAt          json.RawMessage `json:"createdAt"`
+	apifyRequest
+}
+
+type apifyRequest struct {
+	Headers     json.RawMessage `json:"headers"`
+	HttpVersion string          `json:"httpVersion"`
+}
+
+// datasetFile is a file of a dataset and the number of records read from it.
+type datasetFile struct {
+	Path    string
+	Records int
+}
+
+// dataset is a FormatJSON dataset merged from the files it was read from, in order.
+type dataset struct {
+	text  []byte
+	files []datasetFile
+}
+
+// readDataset reads the dataset at datasetPath in the creator's format and returns it as a FormatJSON
+// dataset. datasetPath is a file, a directory whose .json and .jsonl files are merged, or a glob
+// pattern of files to merge, since captures often arrive as many daily dumps. Byte order marks are
+// removed and UTF-16 files are converted to UTF-8.
+func (c *GeneratorNetworksCreator) readDataset(datasetPath string) (*dataset, error) {
+	paths, err := datasetPaths(datasetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The files are read one at a time and only their records are kept.
+	var records []json.RawMessage
+	data := &dataset{files: make([]datasetFile, 0, len(paths))}
+	for _, path := range paths {
+		text, err := c.readDatasetFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		var fileRecords []json.RawMessage
+		if err := json.Unmarshal(text, &fileRecords); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		data.files = append(data.files, datasetFile{Path: path, Records: len(fileRecords)})
+		if len(paths) == 1 {
+			data.text = text
+			return data, nil
+		}
+		records = append(records, fileRecords...)
+	}
+
+	data.text, err = json.Marshal(records)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// datasetPaths returns the dataset files at datasetPath, see readDataset.
+func datasetPaths(datasetPath string) ([]string, error) {
+	if info, err := os.Stat(datasetPath); err == nil {
+		if !info.IsDir() {
+			return []string{datasetPath}, nil
+		}
+		var paths []string
+		for _, pattern := range []string{"*.json", "*.jsonl"} {
+			matches, err := filepath.Glob(filepath.Join(datasetPath, pattern))
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, matches...)
+		}
+		slices.Sort(paths)
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no dataset files in %s", datasetPath)
+		}
+		return paths, nil
+	} else if !strings.ContainsAny(datasetPath, "*?[") {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(datasetPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no dataset files match %s", datasetPath)
+	}
+	return paths, nil
+}
+
+func (c *GeneratorNetworksCreator) readDatasetFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err = decodeDatasetText(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.Format {
+	case "", FormatJSON:
+		return data, nil
+	case FormatApify:
+		return convertApifyDataset(data)
+	}
+	return nil, fmt.Errorf("unsupported dataset format %q", c.Format)
+}
+
+// decodeDatasetText returns the UTF-8 text of a dataset encoded in UTF-8 or, with a byte order mark,
+// in UTF-16.
+func decodeDatasetText(data []byte) ([]byte, error) {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		data = data[3:]
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		order = binary.BigEndian
+	}
+
+	if order != nil {
+		data = data[2:]
+		if len(data)%2 != 0 {
+			return nil, errors.New("the dataset is not valid UTF-16")
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[2*i:])
+		}
+		data = []byte(string(utf16.Decode(units)))
+	}
+
+	if !utf8.Valid(data) {
+		return nil, errors.New("the dataset is not valid UTF-8")
+	}
+	return data, nil
+}
+
+// convertApifyDataset converts an Apify dataset export to a FormatJSON dataset, keeping the order of
+// the headers. The capture time of an item is kept as its timestamp.
+func convertApifyDataset(data []byte) ([]byte, error) {
+	var items []apifyItem
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, err
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(nil, len(data)+1)
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			var item apifyItem
+			if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+				return nil, fmt.Errorf("line %d of the dataset: %w", line, err)
+			}
+			items = append(items, item)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	type record struct {
+		BrowserFingerprint json.RawMessage `json:"browserFingerprint"`
+		RequestFingerprint apifyRequest    `json:"requestFingerprint"`
+		Timestamp          json.RawMessage `json:"timestamp,omitempty"`
+	}
+	records := make([]record, 0, len(items))
+	for _, item := range items {
+		request := &item.apifyRequest
+		if item.RequestFingerprint != nil {
+			request = item.RequestFingerprint
+		} else if item.Request != nil {
+			request = item.Request
+		}
+		browserFingerprint := item.BrowserFingerprint
+		if browserFingerprint == nil {
+			browserFingerprint = item.Fingerprint
+		}
+		if browserFingerprint == nil || request.Headers == nil {
+			// Left for ValidateRecord to reject.
+			browserFingerprint, request = json.RawMessage("null"), &apifyRequest{Headers: json.RawMessage("null")}
+		}
+		timestamp := item.Timestamp
+		if timestamp == nil {
+			timestamp = item.CreatedAt
+		}
+		records = append(records, record{
+			BrowserFingerprint: browserFingerprint,
+			RequestFingerprint: apifyRequest{Headers: request.Headers, HttpVersion: apifyHttpVersion(request.HttpVersion)},
+			Timestamp:          timestamp,
+		})
+	}
+	return json.Marshal(records)
+}
+
+// apifyHttpVersion normalizes HTTP versions like "HTTP/2.0", "h2" or "1.1" to "1" or "2".
+func apifyHttpVersion(httpVersion string) string {
+	httpVersion = strings.TrimPrefix(strings.ToLower(httpVersion), "http/")
+	switch {
+	case strings.HasPrefix(httpVersion, "1"):
+		return "1"
+	case httpVersion == "":
+		return ""
+	}
+	return "2"
+}
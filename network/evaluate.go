@@ -0,0 +1,64 @@
+package network
+
+import (
+	"os"
+	"path/filepath"
+
+	"fingerprint-go/bayesian"
+)
+
+// EvaluationSeed selects the records EvaluateNetworks holds out. Keep it when comparing network
+// definitions, so that they are evaluated on the same records.
+const EvaluationSeed = 1
+
+// EvaluateNetworks evaluates the network definitions in dataFilesPath on the fraction holdout of the
+// dataset's records, see bayesian.Network.Evaluate. The evaluations are keyed by the definition file
+// name; definitions missing from dataFilesPath are left out. Evaluating custom-trained and bundled
+// definitions on the same dataset compares how well they fit it.
+func (c *GeneratorNetworksCreator) EvaluateNetworks(datasetPath string, dataFilesPath string, holdout float64) (map[string]*bayesian.Evaluation, error) {
+	data, err := c.readDataset(datasetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluations := make(map[string]*bayesian.Evaluation)
+	load := func(name string) *bayesian.Network {
+		path := filepath.Join(dataFilesPath, name)
+		if _, err := os.Stat(path); err != nil {
+			return nil
+		}
+		return bayesian.NewNetwork(path)
+	}
+
+	headerNetworks := make(map[string]*bayesian.Network)
+	for _, name := range []string{"header-network-definition.zip", "input-network-definition.zip"} {
+		if network := load(name); network != nil {
+			headerNetworks[name] = network
+		}
+	}
+	if len(headerNetworks) > 0 {
+		// The header network's attributes include the input network's ones.
+		attributes := headerNetworks["header-network-definition.zip"]
+		if attributes == nil {
+			attributes = headerNetworks["input-network-definition.zip"]
+		}
+		records, err := c.headerRecords(data, attributes)
+		if err != nil {
+			return nil, err
+		}
+		_, heldOut := bayesian.SplitRecords(records, holdout, EvaluationSeed)
+		for name, network := range headerNetworks {
+			evaluations[name] = network.Evaluate(heldOut)
+		}
+	}
+
+	if fingerprintNetwork := load("fingerprint-network-definition.zip"); fingerprintNetwork != nil {
+		records, err := c.fingerprintRecords(data, fingerprintNetwork)
+		if err != nil {
+			return nil, err
+		}
+		_, heldOut := bayesian.SplitRecords(records, holdout, EvaluationSeed)
+		evaluations["fingerprint-network-definition.zip"] = fingerprintNetwork.Evaluate(heldOut)
+	}
+	return evaluations, nil
+}
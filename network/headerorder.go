@@ -0,0 +1,149 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fingerprint-go/uautil"
+)
+
+// wireHeaders are the headers of a dataset record in the order they were received.
+type wireHeaders struct {
+	names     []string
+	userAgent string
+}
+
+func (h *wireHeaders) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := token.(string)
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		h.names = append(h.names, name)
+		if userAgent, ok := value.(string); ok && strings.EqualFold(name, "user-agent") {
+			h.userAgent = userAgent
+		}
+	}
+	return nil
+}
+
+// headerOrderStats collects the observed wire orders of one browser or browser version.
+type headerOrderStats struct {
+	// names holds the names of every header as received, with each casing seen.
+	names map[string][]string
+	// precedes counts the records where the first header was sent before the second one.
+	precedes map[[2]string]int
+}
+
+func (s *headerOrderStats) add(names []string) {
+	lowered := make([]string, 0, len(names))
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		if !slices.Contains(s.names[lower], name) {
+			s.names[lower] = append(s.names[lower], name)
+		}
+		if !slices.Contains(lowered, lower) {
+			lowered = append(lowered, lower)
+		}
+	}
+	for i, first := range lowered {
+		for _, second := range lowered[i+1:] {
+			s.precedes[[2]string{first, second}]++
+		}
+	}
+}
+
+// order returns the header names ordered by the number of headers each one precedes in most of the
+// records both were sent in. The lowercase name comes first, followed by the HTTP/1 casings.
+func (s *headerOrderStats) order() []string {
+	lowered := make([]string, 0, len(s.names))
+	for name := range s.names {
+		lowered = append(lowered, name)
+	}
+	wins := make(map[string]int, len(lowered))
+	for _, first := range lowered {
+		for _, second := range lowered {
+			if s.precedes[[2]string{first, second}] > s.precedes[[2]string{second, first}] {
+				wins[first]++
+			}
+		}
+	}
+	slices.SortFunc(lowered, func(a, b string) int {
+		if wins[a] != wins[b] {
+			return wins[b] - wins[a]
+		}
+		return strings.Compare(a, b)
+	})
+
+	var order []string
+	for _, name := range lowered {
+		names := slices.Clone(s.names[name])
+		slices.SortFunc(names, func(a, b string) int {
+			if (a == name) != (b == name) {
+				if a == name {
+					return -1
+				}
+				return 1
+			}
+			return strings.Compare(a, b)
+		})
+		order = append(order, names...)
+	}
+	return order
+}
+
+// deriveHeaderOrders derives headers-order.json from the raw dataset: for every browser, e.g.
+// "chrome", and every browser major version, e.g. "chrome/120", the order the headers were received
+// in. Pseudo-headers and records of unsupported browsers are skipped.
+func (c *GeneratorNetworksCreator) deriveHeaderOrders(data *dataset) (map[string][]string, error) {
+	var records []struct {
+		RequestFingerprint struct {
+			Headers wireHeaders `json:"headers"`
+		} `json:"requestFingerprint"`
+	}
+	if err := json.Unmarshal(data.text, &records); err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]*headerOrderStats)
+	for _, record := range records {
+		headers := record.RequestFingerprint.Headers
+		browser := c.getBrowserNameVersion(strings.ToLower(headers.userAgent))
+		if browser == MissingValueDatasetToken {
+			continue
+		}
+		names := slices.DeleteFunc(headers.names, func(name string) bool {
+			return strings.HasPrefix(name, ":")
+		})
+
+		name, _, _ := strings.Cut(browser, "/")
+		keys := []string{name}
+		if version := uautil.Version(headers.userAgent); len(version) > 0 {
+			keys = append(keys, name+"/"+strconv.Itoa(version[0]))
+		}
+		for _, key := range keys {
+			if stats[key] == nil {
+				stats[key] = &headerOrderStats{names: make(map[string][]string), precedes: make(map[[2]string]int)}
+			}
+			stats[key].add(names)
+		}
+	}
+
+	orders := make(map[string][]string, len(stats))
+	for key, s := range stats {
+		orders[key] = s.order()
+	}
+	return orders, nil
+}
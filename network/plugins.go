@@ -0,0 +1,142 @@
+package network
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"fingerprint-go/uautil"
+)
+
+// DefaultMinPluginSetRecords is the default GeneratorNetworksCreator.MinPluginSetRecords.
+const DefaultMinPluginSetRecords = 5
+
+// pluginSet is a set of plugins and MIME types seen in the dataset.
+type pluginSet struct {
+	records int
+	// forms counts the records of every normalized form of the set, which differ in the order of the
+	// plugins and MIME types.
+	forms map[string]int
+	data  map[string]map[string]string
+}
+
+// form returns the most common form of the set, so that the plugins keep an order browsers use.
+func (s *pluginSet) form() map[string]string {
+	var best string
+	for form, count := range s.forms {
+		if best == "" || count > s.forms[best] || count == s.forms[best] && form < best {
+			best = form
+		}
+	}
+	return s.data[best]
+}
+
+// canonicalizePlugins replaces the pluginsData of the fingerprint records with a canonical form, so
+// that the pluginsData node does not get a value per record: the plugins and MIME types are
+// normalized, sets that only differ in their order share the most common order and sets seen in
+// fewer than MinPluginSetRecords records are replaced by the most common set of their browser.
+func (c *GeneratorNetworksCreator) canonicalizePlugins(records []map[string]any) {
+	minRecords := c.MinPluginSetRecords
+	if minRecords <= 0 {
+		minRecords = DefaultMinPluginSetRecords
+	}
+
+	sets := make(map[string]*pluginSet)
+	keys := make([]string, len(records))
+	for x, record := range records {
+		data, ok := record["pluginsData"].(map[string]string)
+		if !ok {
+			continue
+		}
+		normalized, key := normalizePluginsData(data)
+		set := sets[key]
+		if set == nil {
+			set = &pluginSet{forms: make(map[string]int), data: make(map[string]map[string]string)}
+			sets[key] = set
+		}
+		form, _ := json.Marshal(normalized)
+		set.records++
+		set.forms[string(form)]++
+		set.data[string(form)] = normalized
+		keys[x] = key
+	}
+
+	// The most common set of every browser represents its rare sets.
+	representatives := make(map[string]string)
+	for x, record := range records {
+		key := keys[x]
+		if key == "" || sets[key].records < minRecords {
+			continue
+		}
+		browser := pluginBrowser(record)
+		if current, ok := representatives[browser]; !ok || sets[key].records > sets[current].records ||
+			sets[key].records == sets[current].records && key < current {
+			representatives[browser] = key
+		}
+	}
+
+	for x, record := range records {
+		key := keys[x]
+		if key == "" {
+			continue
+		}
+		if sets[key].records < minRecords {
+			if representative, ok := representatives[pluginBrowser(record)]; ok {
+				key = representative
+			}
+		}
+		record["pluginsData"] = sets[key].form()
+	}
+}
+
+func pluginBrowser(record map[string]any) string {
+	userAgent, _ := record["userAgent"].(string)
+	return uautil.Browser(userAgent)
+}
+
+// normalizePluginsData normalizes the plugin characteristics of a record and returns them with a key
+// identifying the set of plugins and MIME types regardless of their order.
+func normalizePluginsData(data map[string]string) (map[string]string, string) {
+	normalized := make(map[string]string, len(data))
+	var key strings.Builder
+	for _, attr := range PluginCharacteristicsAttributes {
+		value, ok := data[attr]
+		if !ok {
+			continue
+		}
+		var entries []any
+		if json.Unmarshal([]byte(value), &entries) != nil {
+			normalized[attr] = strings.TrimSpace(value)
+			key.WriteString(attr + "=" + normalized[attr] + "\n")
+			continue
+		}
+
+		encoded := make([]string, len(entries))
+		for i, entry := range entries {
+			b, _ := json.Marshal(normalizePluginValue(entry))
+			encoded[i] = string(b)
+		}
+		normalized[attr] = "[" + strings.Join(encoded, ",") + "]"
+		slices.Sort(encoded)
+		key.WriteString(attr + "=" + strings.Join(encoded, ",") + "\n")
+	}
+	return normalized, key.String()
+}
+
+// normalizePluginValue trims the strings of a plugin or MIME type. Objects are encoded with sorted
+// keys.
+func normalizePluginValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []any:
+		for i := range v {
+			v[i] = normalizePluginValue(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = normalizePluginValue(v[k])
+		}
+	}
+	return value
+}
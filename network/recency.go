@@ -0,0 +1,75 @@
+package network
+
+import (
+	"math"
+	"time"
+
+	"fingerprint-go/bayesian"
+)
+
+// captureTime returns the capture time of a dataset record from its TimestampField.
+func (c *GeneratorNetworksCreator) captureTime(record map[string]any) (time.Time, bool) {
+	field := c.TimestampField
+	if field == "" {
+		field = "timestamp"
+	}
+	switch value := record[field].(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339, value)
+		return t, err == nil
+	case float64:
+		// Unix times in milliseconds have more than 12 digits until the year 33658.
+		if value > 1e12 {
+			return time.UnixMilli(int64(value)), true
+		}
+		return time.Unix(int64(value), 0), true
+	}
+	return time.Time{}, false
+}
+
+// recencyWeights returns the training weights of records by capture time, nil without a
+// RecencyHalfLife. The newest record weighs 1 and records without a capture time weigh as much as
+// the oldest one.
+func (c *GeneratorNetworksCreator) recencyWeights(records []map[string]any) []float64 {
+	if c.RecencyHalfLife <= 0 || len(records) == 0 {
+		return nil
+	}
+
+	times := make([]time.Time, len(records))
+	var newest, oldest time.Time
+	for x, record := range records {
+		t, ok := c.captureTime(record)
+		if !ok {
+			continue
+		}
+		times[x] = t
+		if newest.IsZero() || t.After(newest) {
+			newest = t
+		}
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+	}
+	if newest.IsZero() {
+		return nil
+	}
+
+	weights := make([]float64, len(records))
+	for x, t := range times {
+		if t.IsZero() {
+			t = oldest
+		}
+		weights[x] = math.Exp2(-float64(newest.Sub(t)) / float64(c.RecencyHalfLife))
+	}
+	return weights
+}
+
+// setRecordWeights stores the training weights of records under bayesian.RecordWeightKey.
+func setRecordWeights(records bayesian.RecordList, weights []float64) {
+	if len(weights) != len(records) {
+		return
+	}
+	for x, record := range records {
+		record[bayesian.RecordWeightKey] = weights[x]
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"regexp"
 	"strings"
+
+	"fingerprint-go/uautil"
 )
 
 var KnownWebGLRendererParts = []string{
@@ -73,6 +74,22 @@ var KnownWebGLRendererParts = []string{
 	"llvmpipe",
 }
 
+// VirtualWebGLRendererParts are the KnownWebGLRendererParts that identify virtual machines, emulators
+// and software rasterizers rather than physical GPUs.
+var VirtualWebGLRendererParts = []string{
+	"Android Emulator",
+	"Google SwiftShader",
+	"Microsoft Basic Render Driver",
+	"Parallels",
+	"Parallels Display Adapter",
+	"SwiftShader",
+	"VMware",
+	"VMware SVGA 3D",
+	"VirtualBox",
+	"VirtualBox Graphics Adapter",
+	"llvmpipe",
+}
+
 var KnownOsFonts = map[string][]string{
 	"WINDOWS": {
 		"Cambria Math",
@@ -95,66 +112,64 @@ type RobotPattern struct {
 	Pattern string `json:"pattern"`
 }
 
-var robotUserAgents []RobotPattern
+var (
+	robotUserAgents            []RobotPattern
+	robotUserAgentsFetchFailed bool
+)
 
+// FetchRobotUserAgents loads the COUNTER robots list used by ValidateRecord to reject crawler records.
+// The list is fetched once; use LoadRobotUserAgents to provide it offline.
 func FetchRobotUserAgents() error {
-	if len(robotUserAgents) > 0 {
+	if len(robotUserAgents) > 0 || robotUserAgentsFetchFailed {
 		return nil
 	}
-	resp, err := http.Get("https://raw.githubusercontent.com/atmire/COUNTER-Robots/master/COUNTER_Robots_list.json")
-	if err != nil {
-		return fmt.Errorf("failed to fetch robot user agents: %w", err)
+	if err := fetchRobotUserAgents(); err != nil {
+		robotUserAgentsFetchFailed = true
+		return err
 	}
-	defer resp.Body.Close()
+	return nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// LoadRobotUserAgents loads the COUNTER robots list (COUNTER_Robots_list.json) from r instead of fetching it.
+func LoadRobotUserAgents(r io.Reader) error {
+	body, err := io.ReadAll(r)
 	if err != nil {
-		return fmt.Errorf("failed to read robot user agents body: %w", err)
+		return fmt.Errorf("failed to read robot user agents: %w", err)
 	}
-
-	err = json.Unmarshal(body, &robotUserAgents)
-	if err != nil {
-		// attempt to parse as array of objects
+	var patterns []RobotPattern
+	if err := json.Unmarshal(body, &patterns); err != nil {
 		return fmt.Errorf("failed to unmarshal robot user agents: %w", err)
 	}
-
+	robotUserAgents = patterns
 	return nil
 }
 
-// SimpleUAParse is a placeholder for a port of ua-parser-js
-// For a complete implementation, use an external module like "github.com/mssola/user_agent" or similar.
+// SimpleUAParse returns the operating system, device type and browser of userAgent with the names
+// ua-parser-js reports, e.g. "Windows", "tablet" and "Chrome".
 func SimpleUAParse(userAgent string) (osName string, deviceType string, browserName string) {
-	ua := strings.ToLower(userAgent)
-
-	if strings.Contains(ua, "windows") {
-		osName = "Windows"
-	} else if strings.Contains(ua, "mac os x") {
-		osName = "macOS"
-	} else if strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") {
-		osName = "iOS"
-	} else if strings.Contains(ua, "android") {
-		osName = "Android"
-	} else if strings.Contains(ua, "linux") {
-		osName = "Linux"
-	}
-
-	if strings.Contains(ua, "mobile") {
-		deviceType = "mobile"
-	} else if strings.Contains(ua, "tablet") || strings.Contains(ua, "ipad") {
+	osName = map[string]string{
+		"windows": "Windows",
+		"macos":   "macOS",
+		"ios":     "iOS",
+		"android": "Android",
+		"linux":   "Linux",
+	}[uautil.OperatingSystem(userAgent)]
+
+	switch {
+	case uautil.IsTablet(userAgent):
 		deviceType = "tablet"
-	} else {
+	case uautil.IsMobile(userAgent):
+		deviceType = "mobile"
+	default:
 		deviceType = "desktop"
 	}
 
-	if strings.Contains(ua, "firefox") || strings.Contains(ua, "fxios") {
-		browserName = "Firefox"
-	} else if strings.Contains(ua, "edg") {
-		browserName = "Edge"
-	} else if strings.Contains(ua, "chrome") || strings.Contains(ua, "crios") {
-		browserName = "Chrome"
-	} else if strings.Contains(ua, "safari") {
-		browserName = "Safari"
-	}
+	browserName = map[string]string{
+		"firefox": "Firefox",
+		"edge":    "Edge",
+		"chrome":  "Chrome",
+		"safari":  "Safari",
+	}[uautil.Browser(userAgent)]
 
 	return osName, deviceType, browserName
 }
@@ -195,6 +210,11 @@ func ValidateRecord(record map[string]any) (map[string]any, bool) {
 		}
 	}
 
+	// Safari build check
+	if !uautil.SafariBuildMatches(userAgent) {
+		return nil, false
+	}
+
 	// Simple UA parse
 	osName, deviceType, browserName := SimpleUAParse(userAgent)
 	isDesktop := deviceType != "mobile" && deviceType != "wearable" && deviceType != "tablet"
@@ -0,0 +1,20 @@
+//go:build !js && !wasip1
+
+package network
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const robotUserAgentsURL = "https://raw.githubusercontent.com/atmire/COUNTER-Robots/master/COUNTER_Robots_list.json"
+
+func fetchRobotUserAgents() error {
+	resp, err := http.Get(robotUserAgentsURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch robot user agents: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return LoadRobotUserAgents(resp.Body)
+}
@@ -0,0 +1,13 @@
+//go:build js || wasip1
+
+package network
+
+import (
+	"errors"
+)
+
+// WebAssembly builds run in sandboxes without general network access, so the robots list has to be
+// provided with LoadRobotUserAgents.
+func fetchRobotUserAgents() error {
+	return errors.New("fetching robot user agents is not supported on this platform, use LoadRobotUserAgents")
+}
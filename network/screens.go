@@ -0,0 +1,84 @@
+package network
+
+import (
+	"fmt"
+	"math"
+
+	"fingerprint-go/internal/constants"
+)
+
+// DefaultMinScreenVariantRecords is the default GeneratorNetworksCreator.MinScreenVariantRecords.
+const DefaultMinScreenVariantRecords = 5
+
+// screenOffsets are the screen fields bucketed to multiples of constants.ScreenOffsetStep. They depend on
+// where the window was and how far the page was scrolled when the record was captured.
+var screenOffsets = []string{"screenX", "pageXOffset", "pageYOffset"}
+
+// bucketScreens reduces the variations of the screen values of the fingerprint records, which otherwise
+// make almost every screen value unique: the offsets are rounded down to multiples of
+// constants.ScreenOffsetStep, and outer heights seen in fewer than MinScreenVariantRecords records of a
+// screen size are replaced by the most common outer height of the size. The inner heights follow the
+// outer height, so that the height of the browser window decorations is kept.
+func (c *GeneratorNetworksCreator) bucketScreens(records []map[string]any) {
+	if !c.BucketScreens {
+		return
+	}
+	minRecords := c.MinScreenVariantRecords
+	if minRecords <= 0 {
+		minRecords = DefaultMinScreenVariantRecords
+	}
+
+	outerHeights := make(map[string]map[float64]int)
+	for _, record := range records {
+		screen, ok := record["screen"].(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, field := range screenOffsets {
+			if offset, ok := screen[field].(float64); ok {
+				screen[field] = math.Floor(offset/constants.ScreenOffsetStep) * constants.ScreenOffsetStep
+			}
+		}
+		if outerHeight, ok := screen["outerHeight"].(float64); ok {
+			size := screenSize(screen)
+			if outerHeights[size] == nil {
+				outerHeights[size] = make(map[float64]int)
+			}
+			outerHeights[size][outerHeight]++
+		}
+	}
+
+	for _, record := range records {
+		screen, ok := record["screen"].(map[string]any)
+		if !ok {
+			continue
+		}
+		outerHeight, ok := screen["outerHeight"].(float64)
+		counts := outerHeights[screenSize(screen)]
+		if !ok || counts[outerHeight] >= minRecords {
+			continue
+		}
+
+		common := outerHeight
+		for height, count := range counts {
+			if count > counts[common] || count == counts[common] && height > common {
+				common = height
+			}
+		}
+		if counts[common] < minRecords {
+			continue
+		}
+		delta := common - outerHeight
+		screen["outerHeight"] = common
+		for _, field := range []string{"innerHeight", "clientHeight"} {
+			if height, ok := screen[field].(float64); ok {
+				screen[field] = max(height+delta, 0)
+			}
+		}
+	}
+}
+
+// screenSize identifies the screen of a record regardless of the browser window.
+func screenSize(screen map[string]any) string {
+	return fmt.Sprint(screen["width"], screen["height"], screen["availWidth"], screen["availHeight"], screen["devicePixelRatio"])
+}
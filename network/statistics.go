@@ -0,0 +1,110 @@
+package network
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RareValuesToken replaces the values of exported statistics seen in fewer records than the threshold.
+const RareValuesToken = "*RARE_VALUES*"
+
+// privateHeaders are never exported: their values identify the user or the session.
+var privateHeaders = []string{"cookie", "authorization", "proxy-authorization", "referer", "x-forwarded-for"}
+
+// DatasetStatistics are aggregate value frequencies of a dataset, without any record.
+type DatasetStatistics struct {
+	// Records is the number of valid records.
+	Records int `json:"records"`
+	// MinValueCount is the number of records a value must be seen in to be exported. Rarer values are
+	// counted under RareValuesToken.
+	MinValueCount int `json:"minValueCount"`
+	// Headers maps every request header to the number of records of each of its values.
+	Headers map[string]map[string]int `json:"headers"`
+	// Fingerprints maps every fingerprint attribute to the number of records of each of its values.
+	Fingerprints map[string]map[string]int `json:"fingerprints"`
+}
+
+// ExportStatistics writes the value frequencies of the dataset's headers and fingerprint attributes to
+// dataset-statistics.json in resultsPath. Values seen in fewer than minValueCount records and headers
+// that identify a user, such as cookies, are left out, so that the file can be shared to debug
+// generation quality without sharing captured fingerprints.
+func (c *GeneratorNetworksCreator) ExportStatistics(datasetPath string, resultsPath string, minValueCount int) error {
+	data, err := c.readDataset(datasetPath)
+	if err != nil {
+		return err
+	}
+
+	statistics := DatasetStatistics{MinValueCount: max(minValueCount, 1)}
+	for _, preprocessingType := range []string{"headers", "fingerprints"} {
+		var parsedRecords []map[string]any
+		if err := json.Unmarshal(data.text, &parsedRecords); err != nil {
+			return err
+		}
+		records, _, err := c.prepareRecords(parsedRecords, data.files, preprocessingType)
+		if err != nil {
+			return err
+		}
+
+		frequencies := valueFrequencies(records, statistics.MinValueCount)
+		if preprocessingType == "headers" {
+			for name := range frequencies {
+				if isPrivateHeader(name) {
+					delete(frequencies, name)
+				}
+			}
+			statistics.Records = len(records)
+			statistics.Headers = frequencies
+		} else {
+			statistics.Fingerprints = frequencies
+		}
+	}
+
+	b, err := json.MarshalIndent(statistics, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(resultsPath, "dataset-statistics.json"), b, 0644)
+}
+
+func isPrivateHeader(name string) bool {
+	for _, private := range privateHeaders {
+		if strings.EqualFold(name, private) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueFrequencies counts the records of every attribute value, counting values seen in fewer than
+// minValueCount records under RareValuesToken.
+func valueFrequencies(records []map[string]any, minValueCount int) map[string]map[string]int {
+	frequencies := make(map[string]map[string]int)
+	for _, record := range records {
+		for attribute, value := range record {
+			if frequencies[attribute] == nil {
+				frequencies[attribute] = make(map[string]int)
+			}
+			frequencies[attribute][statisticsValue(value)]++
+		}
+	}
+
+	for _, counts := range frequencies {
+		for value, count := range counts {
+			if count < minValueCount && value != MissingValueDatasetToken && value != RareValuesToken {
+				delete(counts, value)
+				counts[RareValuesToken] += count
+			}
+		}
+	}
+	return frequencies
+}
+
+func statisticsValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(value)
+	return StringifiedPrefix + string(b)
+}
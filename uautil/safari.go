@@ -0,0 +1,75 @@
+package uautil
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SafariBuild is the Safari/ build token shipped by the Safari releases from From (major and minor
+// version) up to the next entry of the operating system.
+type SafariBuild struct {
+	OperatingSystem string
+	From            [2]int
+	// Build is the Safari/ token, or its prefix when it changed with every release, e.g. "603." for
+	// Safari 10.1 builds 603.1.30 to 603.3.8.
+	Build string
+}
+
+// SafariBuilds maps Safari versions to their WebKit builds. macOS froze the token at 605.1.15 with
+// Safari 11.1 and iOS at 604.1 with Safari 11, whatever the real WebKit version.
+var SafariBuilds = []SafariBuild{
+	{OperatingSystem: "macos", From: [2]int{9, 0}, Build: "601."},
+	{OperatingSystem: "macos", From: [2]int{10, 0}, Build: "602."},
+	{OperatingSystem: "macos", From: [2]int{10, 1}, Build: "603."},
+	{OperatingSystem: "macos", From: [2]int{11, 0}, Build: "604."},
+	{OperatingSystem: "macos", From: [2]int{11, 1}, Build: "605.1.15"},
+	{OperatingSystem: "ios", From: [2]int{9, 0}, Build: "601.1"},
+	{OperatingSystem: "ios", From: [2]int{10, 0}, Build: "602.1"},
+	{OperatingSystem: "ios", From: [2]int{11, 0}, Build: "604.1"},
+}
+
+var safariBuildToken = regexp.MustCompile(`\bSafari/([\d.]+)`)
+
+// SafariWebKitBuild returns the Safari/ build token of Safari version on operatingSystem ("macos" or
+// "ios"), or "" when the version is not in SafariBuilds. For releases whose build changed with every
+// update, it is the prefix of the token.
+func SafariWebKitBuild(operatingSystem string, version []int) string {
+	if len(version) == 0 {
+		return ""
+	}
+	release := [2]int{version[0], 0}
+	if len(version) > 1 {
+		release[1] = version[1]
+	}
+
+	build := ""
+	for _, entry := range SafariBuilds {
+		if entry.OperatingSystem != operatingSystem {
+			continue
+		}
+		if entry.From[0] < release[0] || entry.From[0] == release[0] && entry.From[1] <= release[1] {
+			build = entry.Build
+		}
+	}
+	return build
+}
+
+// SafariBuildMatches reports whether the Version/ and Safari/ tokens of a Safari user agent
+// correspond. It is true for other user agents and Safari versions missing from SafariBuilds.
+func SafariBuildMatches(userAgent string) bool {
+	if Browser(userAgent) != "safari" {
+		return true
+	}
+	expected := SafariWebKitBuild(OperatingSystem(userAgent), Version(userAgent))
+	if expected == "" {
+		return true
+	}
+	match := safariBuildToken.FindStringSubmatch(userAgent)
+	if match == nil {
+		return false
+	}
+	if strings.HasSuffix(expected, ".") {
+		return strings.HasPrefix(match[1], expected)
+	}
+	return match[1] == expected
+}
@@ -0,0 +1,184 @@
+// Package uautil extracts the browser, its version, the operating system and the device class from
+// user agents. The header generator, the network creator and the record validator share it, so they
+// agree on what a user agent describes.
+package uautil
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	edgeToken    = regexp.MustCompile(`(?i)\bEdg(?:e|A|iOS)?/(\d+(?:\.\d+)*)`)
+	firefoxToken = regexp.MustCompile(`(?i)\b(?:Firefox|FxiOS)/(\d+(?:\.\d+)*)`)
+	chromeToken  = regexp.MustCompile(`(?i)\b(?:Chrome|CriOS)/(\d+(?:\.\d+)*)`)
+	safariToken  = regexp.MustCompile(`(?i)\bSafari/\d`)
+	versionToken = regexp.MustCompile(`(?i)\bVersion/(\d+(?:\.\d+)*)`)
+
+	windowsVersionPattern = regexp.MustCompile(`Windows NT (\d+)\.(\d+)`)
+	macOSVersionPattern   = regexp.MustCompile(`Mac OS X (\d+)[_.](\d+)`)
+	iOSVersionPattern     = regexp.MustCompile(`OS (\d+)_\d+(?:_\d+)? like Mac OS X`)
+	androidVersionPattern = regexp.MustCompile(`Android (\d+)`)
+)
+
+// UserAgent returns the value of the User-Agent header, whatever its casing, or "".
+func UserAgent(headers map[string]string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, "user-agent") {
+			return v
+		}
+	}
+	return ""
+}
+
+// Browser returns the browser of userAgent as "edge", "firefox", "chrome" or "safari", or "". Edge is
+// recognized by its Edg token only, and Safari only when no other browser token is present, since
+// Chromium and Firefox based browsers on iOS and macOS carry the Safari token as well.
+func Browser(userAgent string) string {
+	switch {
+	case edgeToken.MatchString(userAgent):
+		return "edge"
+	case firefoxToken.MatchString(userAgent):
+		return "firefox"
+	case chromeToken.MatchString(userAgent):
+		return "chrome"
+	case safariToken.MatchString(userAgent):
+		return "safari"
+	}
+	return ""
+}
+
+// FullVersion returns the version of the browser of userAgent as written in the user agent, e.g.
+// "120.0.6099.71", or "".
+func FullVersion(userAgent string) string {
+	var pattern *regexp.Regexp
+	switch Browser(userAgent) {
+	case "edge":
+		pattern = edgeToken
+	case "firefox":
+		pattern = firefoxToken
+	case "chrome":
+		pattern = chromeToken
+	case "safari":
+		pattern = versionToken
+	default:
+		return ""
+	}
+	match := pattern.FindStringSubmatch(userAgent)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// Version returns the components of the browser version of userAgent, or nil.
+func Version(userAgent string) []int {
+	fullVersion := FullVersion(userAgent)
+	if fullVersion == "" {
+		return nil
+	}
+	return versionComponents(fullVersion)
+}
+
+// ChromiumVersion returns the components of the Chromium version in userAgent, which Edge, WebView and
+// Electron user agents carry as well, or nil.
+func ChromiumVersion(userAgent string) []int {
+	match := chromeToken.FindStringSubmatch(userAgent)
+	if match == nil {
+		return nil
+	}
+	return versionComponents(match[1])
+}
+
+func versionComponents(fullVersion string) []int {
+	var version []int
+	for _, part := range strings.Split(fullVersion, ".") {
+		i, _ := strconv.Atoi(part)
+		version = append(version, i)
+	}
+	return version
+}
+
+// OperatingSystem returns the operating system of userAgent as "ios", "android", "windows", "macos"
+// or "linux", or "".
+func OperatingSystem(userAgent string) string {
+	userAgent = strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(userAgent, "iphone") || strings.Contains(userAgent, "ipad") || strings.Contains(userAgent, "ipod"):
+		return "ios"
+	case strings.Contains(userAgent, "android"):
+		return "android"
+	case strings.Contains(userAgent, "windows"):
+		return "windows"
+	case strings.Contains(userAgent, "mac os x"):
+		return "macos"
+	case strings.Contains(userAgent, "linux") || strings.Contains(userAgent, "cros"):
+		return "linux"
+	}
+	return ""
+}
+
+// OperatingSystemVersion returns the major version of the operating system of userAgent. It is not
+// known when the user agent does not include it or reports a frozen value: Windows NT 10.0 stands for
+// both Windows 10 and 11, and browsers report macOS 10.15 and Android 10 regardless of the real version.
+func OperatingSystemVersion(userAgent string) (int, bool) {
+	switch OperatingSystem(userAgent) {
+	case "windows":
+		if match := windowsVersionPattern.FindStringSubmatch(userAgent); match != nil {
+			switch match[1] + "." + match[2] {
+			case "6.1":
+				return 7, true
+			case "6.2", "6.3":
+				return 8, true
+			}
+		}
+	case "macos":
+		if match := macOSVersionPattern.FindStringSubmatch(userAgent); match != nil {
+			major, _ := strconv.Atoi(match[1])
+			minor, _ := strconv.Atoi(match[2])
+			if major > 10 {
+				return major, true
+			}
+			if minor < 15 {
+				return 10, true
+			}
+		}
+	case "ios":
+		if match := iOSVersionPattern.FindStringSubmatch(userAgent); match != nil {
+			major, _ := strconv.Atoi(match[1])
+			return major, true
+		}
+	case "android":
+		if match := androidVersionPattern.FindStringSubmatch(userAgent); match != nil {
+			major, _ := strconv.Atoi(match[1])
+			if major != 10 || !strings.Contains(userAgent, "; K)") {
+				return major, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// IsMobile reports whether userAgent belongs to a phone or tablet.
+func IsMobile(userAgent string) bool {
+	operatingSystem := OperatingSystem(userAgent)
+	lower := strings.ToLower(userAgent)
+	return operatingSystem == "android" || operatingSystem == "ios" || strings.Contains(lower, "mobile") || strings.Contains(lower, "phone")
+}
+
+// IsTablet reports whether userAgent belongs to a tablet: an iPad, or an Android device whose browser
+// does not announce itself as mobile.
+func IsTablet(userAgent string) bool {
+	lower := strings.ToLower(userAgent)
+	return strings.Contains(lower, "ipad") || strings.Contains(lower, "tablet") ||
+		OperatingSystem(userAgent) == "android" && !strings.Contains(lower, "mobile")
+}
+
+// Device returns "mobile" for user agents of phones and tablets and "desktop" otherwise.
+func Device(userAgent string) string {
+	if IsMobile(userAgent) {
+		return "mobile"
+	}
+	return "desktop"
+}
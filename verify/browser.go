@@ -0,0 +1,127 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"fingerprint-go/fingerprint"
+)
+
+// Evaluator evaluates a JavaScript expression in a page and returns the JSON encoded result.
+// A chromedp adapter can implement it with chromedp.Run(ctx, chromedp.Evaluate(expression, &raw)).
+type Evaluator interface {
+	Evaluate(ctx context.Context, expression string) ([]byte, error)
+}
+
+// ProbeScript collects the values the probes compare against the generated fingerprint.
+const ProbeScript = `(() => {
+	const result = {
+		userAgent: navigator.userAgent,
+		platform: navigator.platform,
+		hardwareConcurrency: navigator.hardwareConcurrency,
+		deviceMemory: navigator.deviceMemory === undefined ? null : navigator.deviceMemory,
+		maxTouchPoints: navigator.maxTouchPoints,
+		languages: Array.from(navigator.languages || []),
+		webdriver: !!navigator.webdriver,
+		touchEvent: 'ontouchstart' in window,
+		timeZone: Intl.DateTimeFormat().resolvedOptions().timeZone,
+		timezoneOffset: new Date().getTimezoneOffset(),
+		screenWidth: screen.width,
+		screenHeight: screen.height,
+		webglVendor: '',
+		webglRenderer: '',
+	};
+	try {
+		const gl = document.createElement('canvas').getContext('webgl');
+		const info = gl.getExtension('WEBGL_debug_renderer_info');
+		result.webglVendor = gl.getParameter(info.UNMASKED_VENDOR_WEBGL);
+		result.webglRenderer = gl.getParameter(info.UNMASKED_RENDERER_WEBGL);
+	} catch (e) {}
+	return result;
+})()`
+
+// Observed holds the values read from the page by ProbeScript.
+type Observed struct {
+	UserAgent           string   `json:"userAgent"`
+	Platform            string   `json:"platform"`
+	HardwareConcurrency int      `json:"hardwareConcurrency"`
+	DeviceMemory        *float64 `json:"deviceMemory"`
+	MaxTouchPoints      int      `json:"maxTouchPoints"`
+	Languages           []string `json:"languages"`
+	Webdriver           bool     `json:"webdriver"`
+	TouchEvent          bool     `json:"touchEvent"`
+	TimeZone            string   `json:"timeZone"`
+	TimezoneOffset      int      `json:"timezoneOffset"`
+	ScreenWidth         float64  `json:"screenWidth"`
+	ScreenHeight        float64  `json:"screenHeight"`
+	WebGLVendor         string   `json:"webglVendor"`
+	WebGLRenderer       string   `json:"webglRenderer"`
+}
+
+// InBrowser runs ProbeScript through ev in a page where fp has been injected, and reports whether the page
+// exposes the values of fp together with the result of the DefaultChecks.
+func InBrowser(ctx context.Context, ev Evaluator, fp *fingerprint.BrowserFingerprintWithHeaders) (*Report, error) {
+	raw, err := ev.Evaluate(ctx, ProbeScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate probe script: %w", err)
+	}
+
+	var observed Observed
+	if err := json.Unmarshal(raw, &observed); err != nil {
+		return nil, fmt.Errorf("failed to decode probe results: %w", err)
+	}
+
+	report := Fingerprint(fp)
+	compareObserved(report, &observed, &fp.Fingerprint)
+	return report, nil
+}
+
+func compareObserved(report *Report, observed *Observed, fp *fingerprint.Fingerprint) {
+	nav := fp.Navigator
+
+	report.add("page-user-agent", observed.UserAgent == nav.UserAgent, mismatch(observed.UserAgent, nav.UserAgent))
+	report.add("page-platform", observed.Platform == nav.Platform, mismatch(observed.Platform, nav.Platform))
+	report.add("page-hardware-concurrency", observed.HardwareConcurrency == nav.HardwareConcurrency, mismatch(observed.HardwareConcurrency, nav.HardwareConcurrency))
+
+	memoryMatches := (observed.DeviceMemory == nil) == (nav.DeviceMemory == nil) &&
+		(observed.DeviceMemory == nil || *observed.DeviceMemory == *nav.DeviceMemory)
+	report.add("page-device-memory", memoryMatches, "")
+
+	touchPoints := 0
+	if nav.MaxTouchPoints != nil {
+		touchPoints = *nav.MaxTouchPoints
+	}
+	report.add("page-touch-points", observed.MaxTouchPoints == touchPoints, mismatch(observed.MaxTouchPoints, touchPoints))
+	report.add("page-touch-events", observed.TouchEvent == isMobile(nav.UserAgent), fmt.Sprintf("ontouchstart present: %t", observed.TouchEvent))
+
+	report.add("page-languages", slices.Equal(observed.Languages, nav.Languages), mismatch(observed.Languages, nav.Languages))
+	report.add("page-webdriver", !observed.Webdriver, "navigator.webdriver is set")
+
+	screenMatches := observed.ScreenWidth == fp.Screen.Width && observed.ScreenHeight == fp.Screen.Height
+	report.add("page-screen", screenMatches, fmt.Sprintf("got %vx%v, want %vx%v", observed.ScreenWidth, observed.ScreenHeight, fp.Screen.Width, fp.Screen.Height))
+
+	if fp.VideoCard.Renderer != "" {
+		webglMatches := observed.WebGLVendor == fp.VideoCard.Vendor && observed.WebGLRenderer == fp.VideoCard.Renderer
+		report.add("page-webgl-vendor", webglMatches, fmt.Sprintf("got %q/%q", observed.WebGLVendor, observed.WebGLRenderer))
+	}
+
+	if location, err := time.LoadLocation(observed.TimeZone); err == nil && observed.TimeZone != "" {
+		_, offset := time.Now().In(location).Zone()
+		expected := -offset / 60
+		report.add("page-timezone", expected == observed.TimezoneOffset,
+			fmt.Sprintf("Intl time zone %s implies offset %d, Date reports %d", observed.TimeZone, expected, observed.TimezoneOffset))
+	}
+
+	for i := range report.Results {
+		if report.Results[i].Passed {
+			report.Results[i].Detail = ""
+		}
+	}
+}
+
+func mismatch(got, want any) string {
+	return fmt.Sprintf("got %v, want %v", got, want)
+}
@@ -0,0 +1,216 @@
+package verify
+
+import (
+	"fmt"
+	"strings"
+
+	"fingerprint-go/fingerprint"
+	"fingerprint-go/header"
+)
+
+// Result is the outcome of a single consistency probe.
+type Result struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report collects the results of a probe battery.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Passed reports whether every probe in the report passed.
+func (r *Report) Passed() bool {
+	for _, res := range r.Results {
+		if !res.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Failures returns the probes that did not pass.
+func (r *Report) Failures() []Result {
+	var failures []Result
+	for _, res := range r.Results {
+		if !res.Passed {
+			failures = append(failures, res)
+		}
+	}
+	return failures
+}
+
+func (r *Report) add(name string, passed bool, detail string) {
+	r.Results = append(r.Results, Result{Name: name, Passed: passed, Detail: detail})
+}
+
+// Check is a consistency probe run against a generated fingerprint. It returns an empty string
+// when the fingerprint passes and a description of the inconsistency otherwise.
+type Check struct {
+	Name string
+	Run  func(fp *fingerprint.BrowserFingerprintWithHeaders) string
+}
+
+// DefaultChecks is the bundled battery of probes, modelled on the cheap checks detection scripts run first.
+var DefaultChecks = []Check{
+	{Name: "navigator-user-agent", Run: checkNavigatorUserAgent},
+	{Name: "navigator-platform", Run: checkNavigatorPlatform},
+	{Name: "user-agent-data", Run: checkUserAgentData},
+	{Name: "webgl-vendor", Run: checkWebGLVendor},
+	{Name: "touch-support", Run: checkTouchSupport},
+	{Name: "languages", Run: checkLanguages},
+	{Name: "screen-avail-area", Run: checkAvailArea},
+	{Name: "webkit", Run: checkWebKit},
+	{Name: "pdf-viewer", Run: checkPDFViewer},
+	{Name: "vendor-flavors", Run: checkVendorFlavors},
+	{Name: "battery", Run: checkBattery},
+	CapabilitiesCheck(header.DefaultCapabilities),
+}
+
+// Fingerprint runs the DefaultChecks against fp without a browser.
+func Fingerprint(fp *fingerprint.BrowserFingerprintWithHeaders) *Report {
+	return Run(fp, DefaultChecks)
+}
+
+// Run runs the given checks against fp.
+func Run(fp *fingerprint.BrowserFingerprintWithHeaders, checks []Check) *Report {
+	report := &Report{}
+	for _, check := range checks {
+		detail := check.Run(fp)
+		report.add(check.Name, detail == "", detail)
+	}
+	return report
+}
+
+func isMobile(userAgent string) bool {
+	os := header.GetOperatingSystem(userAgent)
+	return os == "ios" || os == "android" || strings.Contains(strings.ToLower(userAgent), "mobile")
+}
+
+func checkNavigatorUserAgent(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+	headerUA := header.GetUserAgent(fp.Headers)
+	if headerUA == "" {
+		return ""
+	}
+	if fp.Fingerprint.Navigator.UserAgent != headerUA {
+		return fmt.Sprintf("navigator.userAgent %q differs from the User-Agent header %q", fp.Fingerprint.Navigator.UserAgent, headerUA)
+	}
+	return ""
+}
+
+func checkNavigatorPlatform(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+	platform := fp.Fingerprint.Navigator.Platform
+	var ok bool
+	switch header.GetOperatingSystem(fp.Fingerprint.Navigator.UserAgent) {
+	case "windows":
+		ok = platform == "Win32" || platform == "Win64"
+	case "macos":
+		ok = platform == "MacIntel"
+	case "ios":
+		ok = platform == "iPhone" || platform == "iPad" || platform == "iPod" || platform == "MacIntel"
+	case "android", "linux":
+		ok = strings.HasPrefix(platform, "Linux")
+	default:
+		return ""
+	}
+	if !ok {
+		return fmt.Sprintf("navigator.platform %q does not match the user agent", platform)
+	}
+	return ""
+}
+
+func checkUserAgentData(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+	nav := fp.Fingerprint.Navigator
+	browser := header.GetBrowser(nav.UserAgent)
+	if browser == "firefox" || browser == "safari" {
+		if len(nav.UserAgentData.Brands) > 0 {
+			return fmt.Sprintf("%s does not expose navigator.userAgentData but brands are set", browser)
+		}
+		return ""
+	}
+	if len(nav.UserAgentData.Brands) == 0 {
+		return ""
+	}
+	if nav.UserAgentData.Mobile != isMobile(nav.UserAgent) {
+		return fmt.Sprintf("userAgentData.mobile is %t for a user agent with mobile=%t", nav.UserAgentData.Mobile, isMobile(nav.UserAgent))
+	}
+	return ""
+}
+
+func checkWebGLVendor(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+	renderer := fp.Fingerprint.VideoCard.Renderer
+	if renderer == "" {
+		return ""
+	}
+	os := header.GetOperatingSystem(fp.Fingerprint.Navigator.UserAgent)
+	switch {
+	case (strings.Contains(renderer, "Direct3D") || strings.Contains(renderer, "D3D11")) && os != "windows":
+		return fmt.Sprintf("Direct3D renderer %q on %s", renderer, os)
+	case (strings.Contains(renderer, "Apple") || strings.Contains(renderer, "Metal")) && os != "macos" && os != "ios":
+		return fmt.Sprintf("Apple renderer %q on %s", renderer, os)
+	case (strings.Contains(renderer, "Adreno") || strings.Contains(renderer, "Mali") || strings.Contains(renderer, "PowerVR")) && os != "android" && os != "linux":
+		return fmt.Sprintf("mobile renderer %q on %s", renderer, os)
+	}
+	return ""
+}
+
+func checkTouchSupport(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+	nav := fp.Fingerprint.Navigator
+	touchPoints := 0
+	if nav.MaxTouchPoints != nil {
+		touchPoints = *nav.MaxTouchPoints
+	}
+	if isMobile(nav.UserAgent) && touchPoints == 0 {
+		return "mobile user agent reports no touch points"
+	}
+	return ""
+}
+
+func checkLanguages(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+	nav := fp.Fingerprint.Navigator
+	if len(nav.Languages) == 0 {
+		return ""
+	}
+	if nav.Language != nav.Languages[0] {
+		return fmt.Sprintf("navigator.language %q is not the first of navigator.languages", nav.Language)
+	}
+	for k, v := range fp.Headers {
+		if strings.ToLower(k) != "accept-language" {
+			continue
+		}
+		first := strings.TrimSpace(strings.Split(strings.Split(v, ",")[0], ";")[0])
+		if first != nav.Languages[0] {
+			return fmt.Sprintf("Accept-Language starts with %q but navigator.languages with %q", first, nav.Languages[0])
+		}
+	}
+	return ""
+}
+
+func checkAvailArea(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+	return fingerprint.AvailAreaProblem(&fp.Fingerprint.Screen, header.GetOperatingSystem(fp.Fingerprint.Navigator.UserAgent))
+}
+
+func checkWebKit(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+	return fingerprint.WebKitProblem(&fp.Fingerprint)
+}
+
+func checkPDFViewer(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+	return fingerprint.PDFViewerProblem(&fp.Fingerprint)
+}
+
+func checkVendorFlavors(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+	return fingerprint.VendorFlavorsProblem(&fp.Fingerprint)
+}
+
+func checkBattery(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+	return fingerprint.BatteryProblem(&fp.Fingerprint)
+}
+
+// CapabilitiesCheck returns the check that the headers only hold what their browser version sends
+// according to capabilities, e.g. those of the generator's data files.
+func CapabilitiesCheck(capabilities *header.Capabilities) Check {
+	return Check{Name: "capabilities", Run: func(fp *fingerprint.BrowserFingerprintWithHeaders) string {
+		return capabilities.Problem(fp.Headers)
+	}}
+}